@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"network/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) GetACLs(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	acls, err := h.services.Devices.GetACLs(routerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(acls)
+}
+
+func (h *Handler) CreateACLEntry(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	var req models.CreateACLEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	entry, err := h.services.Devices.CreateACLEntry(routerID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+func (h *Handler) DeleteACLEntry(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+	entryID, ok := paramID(c, "entryId")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid entry ID",
+		})
+	}
+
+	if err := h.services.Devices.DeleteACLEntry(routerID, entryID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "ACL entry deleted successfully",
+	})
+}
+
+func (h *Handler) ClearACLCounters(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	if err := h.services.Devices.ClearACLCounters(routerID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "ACL counters cleared",
+	})
+}
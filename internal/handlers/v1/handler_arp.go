@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"network/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Кэш ARP (IPv4) и таблица соседей (IPv6) обслуживаются одними обработчиками
+func (h *Handler) GetARPTable(c *fiber.Ctx) error {
+	return h.getARPTable(c, false)
+}
+
+func (h *Handler) GetNeighborTable(c *fiber.Ctx) error {
+	return h.getARPTable(c, true)
+}
+
+func (h *Handler) CreateARPEntry(c *fiber.Ctx) error {
+	return h.createARPEntry(c, false)
+}
+
+func (h *Handler) CreateNeighborEntry(c *fiber.Ctx) error {
+	return h.createARPEntry(c, true)
+}
+
+func (h *Handler) FlushARPTable(c *fiber.Ctx) error {
+	return h.flushARPTable(c, false)
+}
+
+func (h *Handler) FlushNeighborTable(c *fiber.Ctx) error {
+	return h.flushARPTable(c, true)
+}
+
+func (h *Handler) getARPTable(c *fiber.Ctx, ipv6 bool) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	table, err := h.services.Devices.GetARPTable(routerID, ipv6)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(table)
+}
+
+func (h *Handler) UpdateARPConfig(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	var req models.UpdateARPConfigRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	table, err := h.services.Devices.UpdateARPConfig(routerID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(table)
+}
+
+func (h *Handler) createARPEntry(c *fiber.Ctx, ipv6 bool) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	var req models.CreateARPEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	entry, err := h.services.Devices.CreateARPEntry(routerID, ipv6, &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+func (h *Handler) DeleteARPEntry(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+	entryID, ok := paramID(c, "entryId")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid entry ID",
+		})
+	}
+
+	if err := h.services.Devices.DeleteARPEntry(routerID, entryID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Static entry deleted successfully",
+	})
+}
+
+func (h *Handler) flushARPTable(c *fiber.Ctx, ipv6 bool) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	if err := h.services.Devices.FlushARPTable(routerID, ipv6); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	message := "ARP cache flushed"
+	if ipv6 {
+		message = "Neighbor cache flushed"
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": message,
+	})
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"network/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) GetBGP(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	info, err := h.services.Devices.GetBGP(routerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(info)
+}
+
+func (h *Handler) UpdateBGP(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	var req models.UpdateBGPRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	info, err := h.services.Devices.UpdateBGP(routerID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(info)
+}
+
+func (h *Handler) CreateBGPNeighbor(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	var req models.CreateBGPNeighborRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	neighbor, err := h.services.Devices.CreateBGPNeighbor(routerID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(neighbor)
+}
+
+func (h *Handler) DeleteBGPNeighbor(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+	neighborID, ok := paramID(c, "neighborId")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid neighbor ID",
+		})
+	}
+
+	if err := h.services.Devices.DeleteBGPNeighbor(routerID, neighborID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Neighbor deleted successfully",
+	})
+}
+
+func (h *Handler) CreateBGPNetwork(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	var req models.CreateBGPNetworkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	network, err := h.services.Devices.CreateBGPNetwork(routerID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(network)
+}
+
+func (h *Handler) DeleteBGPNetwork(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+	networkID, ok := paramID(c, "networkId")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid network ID",
+		})
+	}
+
+	if err := h.services.Devices.DeleteBGPNetwork(routerID, networkID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Network deleted successfully",
+	})
+}
+
+func (h *Handler) CreateBGPRouteMapEntry(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	var req models.CreateBGPRouteMapEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	entry, err := h.services.Devices.CreateBGPRouteMapEntry(routerID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+func (h *Handler) DeleteBGPRouteMapEntry(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+	entryID, ok := paramID(c, "entryId")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid route-map entry ID",
+		})
+	}
+
+	if err := h.services.Devices.DeleteBGPRouteMapEntry(routerID, entryID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Route-map entry deleted successfully",
+	})
+}
+
+func (h *Handler) GetBGPAdjRIBIn(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	ribs, err := h.services.Devices.GetBGPAdjRIBIn(routerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(ribs)
+}
+
+func (h *Handler) GetBGPLocRIB(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	paths, err := h.services.Devices.GetBGPLocRIB(routerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(paths)
+}
@@ -50,6 +50,30 @@ func (h *Handler) PingIP(c *fiber.Ctx) error {
 	return c.JSON(result)
 }
 
+func (h *Handler) Traceroute(c *fiber.Ctx) error {
+	var req models.TracerouteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.IPAddress == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "IP address is required",
+		})
+	}
+
+	result, err := h.services.Devices.Traceroute(&req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
+
 func (h *Handler) SendPacket(c *fiber.Ctx) error {
 	var req models.PacketRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -100,7 +124,7 @@ func (h *Handler) ConnectRouter(c *fiber.Ctx) error {
 }
 
 func (h *Handler) GetAllRouters(c *fiber.Ctx) error {
-	routers, err := h.services.Devices.GetAllRouters()
+	routers, err := h.services.Devices.GetAllRouters(models.DeviceType(c.Query("type")))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -109,6 +133,70 @@ func (h *Handler) GetAllRouters(c *fiber.Ctx) error {
 	return c.JSON(routers)
 }
 
+func (h *Handler) GetRouter(c *fiber.Ctx) error {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	router, err := h.services.Devices.GetRouter(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(router)
+}
+
+func (h *Handler) DeleteRouter(c *fiber.Ctx) error {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	if err := h.services.Devices.DeleteRouter(id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Router deleted successfully",
+	})
+}
+
+func (h *Handler) DeletePort(c *fiber.Ctx) error {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	number, err := c.ParamsInt("number")
+	if err != nil || number < 1 || number > 65535 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid port number",
+		})
+	}
+
+	if err := h.services.Devices.DeletePort(id, number); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Port deleted successfully",
+	})
+}
+
 func (h *Handler) ConfigureRouter(c *fiber.Ctx) error {
 	var req models.ConfigureRouterRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -178,6 +266,68 @@ func (h *Handler) CreateRouterConnection(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
+func (h *Handler) GetConnection(c *fiber.Ctx) error {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid connection ID",
+		})
+	}
+
+	connection, err := h.services.Devices.GetConnection(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(connection)
+}
+
+func (h *Handler) DeleteConnection(c *fiber.Ctx) error {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid connection ID",
+		})
+	}
+
+	if err := h.services.Devices.DeleteConnection(id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Connection deleted successfully",
+	})
+}
+
+func (h *Handler) UpdateConnection(c *fiber.Ctx) error {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid connection ID",
+		})
+	}
+
+	var req models.UpdateConnectionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	connection, err := h.services.Devices.UpdateConnection(id, &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(connection)
+}
+
 func (h *Handler) GetAllConnections(c *fiber.Ctx) error {
 	connections, err := h.services.Devices.GetAllConnections()
 	if err != nil {
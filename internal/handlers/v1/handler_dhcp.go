@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"network/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) GetDHCPPools(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	pools, err := h.services.Devices.GetDHCPPools(routerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(pools)
+}
+
+func (h *Handler) CreateDHCPPool(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	var req models.CreateDHCPPoolRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	pool, err := h.services.Devices.CreateDHCPPool(routerID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(pool)
+}
+
+func (h *Handler) DeleteDHCPPool(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+	poolID, ok := paramID(c, "poolId")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid pool ID",
+		})
+	}
+
+	if err := h.services.Devices.DeleteDHCPPool(routerID, poolID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "DHCP pool deleted successfully",
+	})
+}
+
+func (h *Handler) GetDHCPLeases(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+
+	leases, err := h.services.Devices.GetDHCPLeases(routerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(leases)
+}
+
+func (h *Handler) DeleteDHCPLease(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+	leaseID, ok := paramID(c, "leaseId")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid lease ID",
+		})
+	}
+
+	if err := h.services.Devices.DeleteDHCPLease(routerID, leaseID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "DHCP lease deleted successfully",
+	})
+}
+
+func (h *Handler) RenewDHCPLease(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+	interfaceID, ok := paramID(c, "interfaceId")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid interface ID",
+		})
+	}
+
+	exchange, err := h.services.Devices.RenewDHCPLease(routerID, interfaceID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(exchange)
+}
+
+func (h *Handler) ReleaseDHCPLease(c *fiber.Ctx) error {
+	routerID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid router ID",
+		})
+	}
+	interfaceID, ok := paramID(c, "interfaceId")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid interface ID",
+		})
+	}
+
+	exchange, err := h.services.Devices.ReleaseDHCPLease(routerID, interfaceID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(exchange)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"network/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) GetSimulationConfig(c *fiber.Ctx) error {
+	config, err := h.services.Devices.GetSimulationConfig()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(config)
+}
+
+func (h *Handler) UpdateSimulationConfig(c *fiber.Ctx) error {
+	var req models.UpdateSimulationConfigRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	config, err := h.services.Devices.UpdateSimulationConfig(&req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(config)
+}
+
+func (h *Handler) GetClock(c *fiber.Ctx) error {
+	return c.JSON(h.services.Devices.GetClock())
+}
+
+func (h *Handler) StepClock(c *fiber.Ctx) error {
+	var req models.StepClockRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	clock, err := h.services.Devices.StepClock(&req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(clock)
+}
+
+func (h *Handler) RunClock(c *fiber.Ctx) error {
+	var req models.RunClockRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	clock, err := h.services.Devices.RunClock(&req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(clock)
+}
+
+func (h *Handler) StartClock(c *fiber.Ctx) error {
+	var req models.StartClockRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	clock, err := h.services.Devices.StartClock(&req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(clock)
+}
+
+func (h *Handler) PauseClock(c *fiber.Ctx) error {
+	return c.JSON(h.services.Devices.PauseClock())
+}
+
+func (h *Handler) ResetClock(c *fiber.Ctx) error {
+	clock, err := h.services.Devices.ResetClock()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(clock)
+}
+
+func (h *Handler) SchedulePackets(c *fiber.Ctx) error {
+	var req models.SchedulePacketRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	packets, err := h.services.Devices.SchedulePackets(&req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(packets)
+}
+
+func (h *Handler) ScheduleFailure(c *fiber.Ctx) error {
+	var req models.ScheduleFailureRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	events, err := h.services.Devices.ScheduleFailure(&req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(events)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"network/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) GetMACTable(c *fiber.Ctx) error {
+	switchID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid switch ID",
+		})
+	}
+
+	table, err := h.services.Devices.GetMACTable(switchID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(table)
+}
+
+func (h *Handler) UpdateMACTable(c *fiber.Ctx) error {
+	switchID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid switch ID",
+		})
+	}
+
+	var req models.UpdateMACTableRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	table, err := h.services.Devices.UpdateMACTable(switchID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(table)
+}
+
+func (h *Handler) FlushMACTable(c *fiber.Ctx) error {
+	switchID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid switch ID",
+		})
+	}
+
+	if err := h.services.Devices.FlushMACTable(switchID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "MAC address table flushed",
+	})
+}
+
+func (h *Handler) GetVLANs(c *fiber.Ctx) error {
+	switchID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid switch ID",
+		})
+	}
+
+	vlans, err := h.services.Devices.GetVLANs(switchID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(vlans)
+}
+
+func (h *Handler) GetSTP(c *fiber.Ctx) error {
+	switchID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid switch ID",
+		})
+	}
+
+	info, err := h.services.Devices.GetSTP(switchID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(info)
+}
+
+func (h *Handler) UpdateSTP(c *fiber.Ctx) error {
+	switchID, ok := paramID(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid switch ID",
+		})
+	}
+
+	var req models.UpdateSTPRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	info, err := h.services.Devices.UpdateSTP(switchID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(info)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"network/internal/service"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -16,8 +17,39 @@ func NewHandler(services *service.Service) *Handler {
 	}
 }
 
+// paramID parses a positive numeric path parameter
+func paramID(c *fiber.Ctx, key string) (uint, bool) {
+	id, err := c.ParamsInt(key)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// selfSerialized lists requests that lock the topology themselves: real ICMP probes
+// may wait for replies for minutes and must not block other requests and the clock
+var selfSerialized = map[string]bool{
+	"/api/v1/ping":       true,
+	"/api/v1/traceroute": true,
+}
+
+// serialize runs modifying requests one at a time, together with the running simulation clock
+func (h *Handler) serialize(c *fiber.Ctx) error {
+	if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+		return c.Next()
+	}
+	if selfSerialized[strings.ToLower(strings.TrimSuffix(c.Path(), "/"))] {
+		return c.Next()
+	}
+	var err error
+	h.services.Devices.Exclusive(func() {
+		err = c.Next()
+	})
+	return err
+}
+
 func (h *Handler) InitRoute(app *fiber.App) fiber.Handler {
-	api := app.Group("/api/v1")
+	api := app.Group("/api/v1", h.serialize)
 
 	api.Post("/routers", h.CreateRouter)
 	api.Get("/routers", h.GetAllRouters)
@@ -26,10 +58,110 @@ func (h *Handler) InitRoute(app *fiber.App) fiber.Handler {
 	api.Post("/routers/connection", h.CreateRouterConnection)
 	api.Get("/routers/connections", h.GetAllConnections)
 	api.Get("/routers/connections/by-ip", h.GetConnectionsByRouterIP)
+	api.Get("/routers/connections/:id", h.GetConnection)
+	api.Patch("/routers/connections/:id", h.UpdateConnection)
+	api.Delete("/routers/connections/:id", h.DeleteConnection)
+
+	// Маршруты с параметром :id регистрируются после статических путей /routers/...
+	api.Get("/routers/:id", h.GetRouter)
+	api.Delete("/routers/:id", h.DeleteRouter)
+	api.Delete("/routers/:id/ports/:number", h.DeletePort)
+
+	api.Get("/routers/:id/interfaces", h.GetInterfaces)
+	api.Post("/routers/:id/interfaces", h.CreateInterface)
+	api.Patch("/routers/:id/interfaces/:interfaceId", h.UpdateInterface)
+	api.Delete("/routers/:id/interfaces/:interfaceId", h.DeleteInterface)
+	api.Post("/routers/:id/interfaces/:interfaceId/dhcp/renew", h.RenewDHCPLease)
+	api.Post("/routers/:id/interfaces/:interfaceId/dhcp/release", h.ReleaseDHCPLease)
+
+	api.Get("/routers/:id/routes", h.GetRoutes)
+	api.Post("/routers/:id/routes", h.CreateRoute)
+	api.Patch("/routers/:id/routes/:routeId", h.UpdateRoute)
+	api.Delete("/routers/:id/routes/:routeId", h.DeleteRoute)
+
+	api.Get("/routers/:id/ospf", h.GetOSPF)
+	api.Put("/routers/:id/ospf", h.UpdateOSPF)
+	api.Get("/routers/:id/ospf/spf", h.GetOSPFSPF)
+	api.Get("/ospf/database", h.GetOSPFDatabase)
+
+	api.Get("/routers/:id/bgp", h.GetBGP)
+	api.Put("/routers/:id/bgp", h.UpdateBGP)
+	api.Post("/routers/:id/bgp/neighbors", h.CreateBGPNeighbor)
+	api.Delete("/routers/:id/bgp/neighbors/:neighborId", h.DeleteBGPNeighbor)
+	api.Post("/routers/:id/bgp/networks", h.CreateBGPNetwork)
+	api.Delete("/routers/:id/bgp/networks/:networkId", h.DeleteBGPNetwork)
+	api.Post("/routers/:id/bgp/route-maps", h.CreateBGPRouteMapEntry)
+	api.Delete("/routers/:id/bgp/route-maps/:entryId", h.DeleteBGPRouteMapEntry)
+	api.Get("/routers/:id/bgp/adj-rib-in", h.GetBGPAdjRIBIn)
+	api.Get("/routers/:id/bgp/loc-rib", h.GetBGPLocRIB)
+
+	api.Get("/routers/:id/rip", h.GetRIP)
+	api.Put("/routers/:id/rip", h.UpdateRIP)
+	api.Get("/rip/convergences", h.GetRIPConvergences)
+	api.Get("/rip/convergences/:id", h.GetRIPConvergence)
+
+	api.Get("/routers/:id/arp", h.GetARPTable)
+	api.Put("/routers/:id/arp", h.UpdateARPConfig)
+	api.Post("/routers/:id/arp", h.CreateARPEntry)
+	api.Delete("/routers/:id/arp", h.FlushARPTable)
+	api.Delete("/routers/:id/arp/:entryId", h.DeleteARPEntry)
+	api.Get("/routers/:id/neighbors", h.GetNeighborTable)
+	api.Post("/routers/:id/neighbors", h.CreateNeighborEntry)
+	api.Delete("/routers/:id/neighbors", h.FlushNeighborTable)
+	api.Delete("/routers/:id/neighbors/:entryId", h.DeleteARPEntry)
+
+	api.Get("/routers/:id/acls", h.GetACLs)
+	api.Post("/routers/:id/acls", h.CreateACLEntry)
+	api.Delete("/routers/:id/acls/counters", h.ClearACLCounters)
+	api.Delete("/routers/:id/acls/:entryId", h.DeleteACLEntry)
+	api.Get("/routers/:id/firewall", h.GetFirewall)
+	api.Put("/routers/:id/firewall", h.UpdateFirewall)
+	api.Get("/routers/:id/conntrack", h.GetConntrack)
+	api.Delete("/routers/:id/conntrack", h.FlushConntrack)
+	api.Get("/routers/:id/nat", h.GetNAT)
+	api.Post("/routers/:id/nat", h.CreateNATRule)
+	api.Get("/routers/:id/nat/translations", h.GetNATTranslations)
+	api.Delete("/routers/:id/nat/translations", h.ClearNATTranslations)
+	api.Delete("/routers/:id/nat/:ruleId", h.DeleteNATRule)
+	api.Get("/routers/:id/dhcp/pools", h.GetDHCPPools)
+	api.Post("/routers/:id/dhcp/pools", h.CreateDHCPPool)
+	api.Delete("/routers/:id/dhcp/pools/:poolId", h.DeleteDHCPPool)
+	api.Get("/routers/:id/dhcp/leases", h.GetDHCPLeases)
+	api.Delete("/routers/:id/dhcp/leases/:leaseId", h.DeleteDHCPLease)
+
+	api.Get("/switches/:id/mac-table", h.GetMACTable)
+	api.Put("/switches/:id/mac-table", h.UpdateMACTable)
+	api.Delete("/switches/:id/mac-table", h.FlushMACTable)
+	api.Get("/switches/:id/vlans", h.GetVLANs)
+	api.Get("/switches/:id/stp", h.GetSTP)
+	api.Put("/switches/:id/stp", h.UpdateSTP)
+
+	api.Post("/hosts", h.CreateHost)
+	api.Put("/hosts/:id/gateway", h.UpdateGateway)
 
 	api.Post("/ping", h.PingIP)
+	api.Post("/traceroute", h.Traceroute)
 	api.Post("/packet", h.SendPacket)
 
+	api.Get("/ipam/pools", h.GetAllIPPools)
+	api.Post("/ipam/pools", h.CreateIPPool)
+	api.Get("/ipam/pools/:id", h.GetIPPool)
+	api.Delete("/ipam/pools/:id", h.DeleteIPPool)
+	api.Get("/ipam/pools/:id/allocations", h.GetIPAllocations)
+	api.Post("/ipam/pools/:id/allocations", h.ReserveIP)
+	api.Delete("/ipam/allocations/:id", h.ReleaseIP)
+
+	api.Get("/simulation", h.GetSimulationConfig)
+	api.Put("/simulation", h.UpdateSimulationConfig)
+	api.Get("/simulation/clock", h.GetClock)
+	api.Post("/simulation/clock/step", h.StepClock)
+	api.Post("/simulation/clock/run-until", h.RunClock)
+	api.Post("/simulation/clock/start", h.StartClock)
+	api.Post("/simulation/clock/pause", h.PauseClock)
+	api.Post("/simulation/clock/reset", h.ResetClock)
+	api.Post("/simulation/packets", h.SchedulePackets)
+	api.Post("/simulation/failures", h.ScheduleFailure)
+
 	api.Patch("/routers/configure", h.ConfigureRouter)
 	api.Patch("/ports/configure", h.ConfigurePort)
 
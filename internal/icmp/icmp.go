@@ -0,0 +1,176 @@
+// Package icmp implements ICMPv4 echo probes over raw or datagram sockets.
+package icmp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// ICMPv4 message types
+const (
+	TypeEchoReply       = 0
+	TypeDestUnreachable = 3
+	TypeEchoRequest     = 8
+	TypeTimeExceeded    = 11
+)
+
+const headerSize = 8
+
+// Reply represents an ICMP message received in response to an echo probe
+type Reply struct {
+	From net.IP
+	Type int
+	Code int
+	Seq  int
+	RTT  time.Duration
+}
+
+// Conn is an ICMP endpoint used to send echo requests
+type Conn struct {
+	pc net.PacketConn
+	// dgram is set for unprivileged datagram sockets, where the kernel
+	// rewrites the echo identifier and does not deliver ICMP errors
+	dgram bool
+	id    int
+}
+
+// Listen opens a raw ICMP socket, falling back to an unprivileged
+// datagram socket where the platform supports it
+func Listen() (*Conn, error) {
+	id := os.Getpid() & 0xffff
+
+	pc, err := net.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err == nil {
+		return &Conn{pc: pc, id: id}, nil
+	}
+
+	dpc, derr := listenDatagram()
+	if derr != nil {
+		return nil, fmt.Errorf("failed to open ICMP socket: %w", errors.Join(err, derr))
+	}
+	return &Conn{pc: dpc, dgram: true, id: id}, nil
+}
+
+// Close closes the socket
+func (c *Conn) Close() error {
+	return c.pc.Close()
+}
+
+// Privileged reports whether the socket is a raw socket that receives ICMP errors
+func (c *Conn) Privileged() bool {
+	return !c.dgram
+}
+
+// SetTTL sets the IP time-to-live of outgoing probes
+func (c *Conn) SetTTL(ttl int) error {
+	return setTTL(c.pc, ttl)
+}
+
+// Echo sends an echo request to dst and waits for the matching reply,
+// a time exceeded or a destination unreachable message
+func (c *Conn) Echo(dst net.IP, seq int, payload []byte, timeout time.Duration) (*Reply, error) {
+	msg := marshalEcho(c.id, seq, payload)
+
+	var addr net.Addr = &net.IPAddr{IP: dst}
+	if c.dgram {
+		addr = &net.UDPAddr{IP: dst}
+	}
+
+	start := time.Now()
+	if err := c.pc.SetDeadline(start.Add(timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := c.pc.WriteTo(msg, addr); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1500+len(payload))
+	for {
+		n, from, err := c.pc.ReadFrom(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		reply, ok := c.match(buf[:n], seq)
+		if !ok {
+			continue
+		}
+		reply.RTT = time.Since(start)
+		reply.From = addrIP(from)
+		return reply, nil
+	}
+}
+
+// match parses a received message and checks that it answers the probe seq
+func (c *Conn) match(msg []byte, seq int) (*Reply, bool) {
+	if len(msg) < headerSize {
+		return nil, false
+	}
+
+	reply := &Reply{Type: int(msg[0]), Code: int(msg[1])}
+	switch reply.Type {
+	case TypeEchoReply:
+		reply.Seq = int(binary.BigEndian.Uint16(msg[6:8]))
+		id := int(binary.BigEndian.Uint16(msg[4:6]))
+		if !c.dgram && id != c.id {
+			return nil, false
+		}
+	case TypeTimeExceeded, TypeDestUnreachable:
+		// Message body carries the original IP header and 8 bytes of the probe
+		inner := msg[headerSize:]
+		if len(inner) < 20 {
+			return nil, false
+		}
+		ihl := int(inner[0]&0x0f) * 4
+		if len(inner) < ihl+headerSize || inner[ihl] != TypeEchoRequest {
+			return nil, false
+		}
+		if int(binary.BigEndian.Uint16(inner[ihl+4:ihl+6])) != c.id {
+			return nil, false
+		}
+		reply.Seq = int(binary.BigEndian.Uint16(inner[ihl+6 : ihl+8]))
+	default:
+		return nil, false
+	}
+
+	return reply, reply.Seq == seq&0xffff
+}
+
+func marshalEcho(id, seq int, payload []byte) []byte {
+	msg := make([]byte, headerSize+len(payload))
+	msg[0] = TypeEchoRequest
+	binary.BigEndian.PutUint16(msg[4:6], uint16(id))
+	binary.BigEndian.PutUint16(msg[6:8], uint16(seq))
+	copy(msg[headerSize:], payload)
+	binary.BigEndian.PutUint16(msg[2:4], checksum(msg))
+	return msg
+}
+
+// checksum computes the Internet checksum (RFC 1071)
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	}
+	return nil
+}
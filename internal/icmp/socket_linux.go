@@ -0,0 +1,24 @@
+package icmp
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenDatagram opens an unprivileged ICMP datagram socket
+// (requires the group to be allowed by net.ipv4.ping_group_range)
+func listenDatagram() (net.PacketConn, error) {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, syscall.IPPROTO_ICMP)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrInet4{}); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+
+	f := os.NewFile(uintptr(fd), "icmp")
+	defer f.Close()
+	return net.FilePacketConn(f)
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package icmp
+
+import (
+	"errors"
+	"net"
+)
+
+func listenDatagram() (net.PacketConn, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package icmp
+
+import (
+	"errors"
+	"net"
+)
+
+func setTTL(_ net.PacketConn, _ int) error {
+	return errors.ErrUnsupported
+}
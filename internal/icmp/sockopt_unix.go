@@ -0,0 +1,28 @@
+//go:build unix
+
+package icmp
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+func setTTL(pc net.PacketConn, ttl int) error {
+	sc, ok := pc.(syscall.Conn)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		serr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+	}); err != nil {
+		return err
+	}
+	return serr
+}
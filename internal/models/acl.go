@@ -0,0 +1,72 @@
+package models
+
+// ACLAction represents the action of an access-list entry
+type ACLAction string
+
+const (
+	ACLPermit ACLAction = "permit"
+	ACLDeny   ACLAction = "deny"
+)
+
+// ACLDirection represents the direction in which an access list filters packets on an interface
+type ACLDirection string
+
+const (
+	ACLInbound  ACLDirection = "in"
+	ACLOutbound ACLDirection = "out"
+)
+
+// ACLEntry represents a sequence of a named access list. Entries are evaluated
+// in sequence order; a packet matching no entry is denied.
+type ACLEntry struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	RouterID    uint      `json:"router_id"`
+	Name        string    `json:"name"`
+	Sequence    int       `json:"sequence"`
+	Action      ACLAction `json:"action"`
+	Protocol    string    `json:"protocol"`    // ip, tcp, udp or icmp; ip matches every packet
+	Source      string    `json:"source"`      // CIDR, any when empty
+	Destination string    `json:"destination"` // CIDR, any when empty
+	SrcPortFrom int       `json:"src_port_from"`
+	SrcPortTo   int       `json:"src_port_to"`
+	DstPortFrom int       `json:"dst_port_from"`
+	DstPortTo   int       `json:"dst_port_to"`
+	Description string    `json:"description"`
+	Hits        int64     `json:"hits" gorm:"-"` // packets matched since the last counter reset
+}
+
+type CreateACLEntryRequest struct {
+	Name        string    `json:"name"`
+	Sequence    int       `json:"sequence"` // next multiple of 10 when zero
+	Action      ACLAction `json:"action"`
+	Protocol    string    `json:"protocol"`
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	SrcPortFrom int       `json:"src_port_from"`
+	SrcPortTo   int       `json:"src_port_to"` // equals src_port_from when zero
+	DstPortFrom int       `json:"dst_port_from"`
+	DstPortTo   int       `json:"dst_port_to"` // equals dst_port_from when zero
+	Description string    `json:"description"`
+}
+
+// ACLBinding represents an access list applied to an interface
+type ACLBinding struct {
+	Interface string       `json:"interface"`
+	Direction ACLDirection `json:"direction"`
+}
+
+// ACL represents a named access list of a router with the interfaces it is applied to
+type ACL struct {
+	Name       string       `json:"name"`
+	Entries    []ACLEntry   `json:"entries"`
+	Interfaces []ACLBinding `json:"interfaces"`
+}
+
+// ACLMatch represents the access-list entry that matched a packet on an interface
+type ACLMatch struct {
+	ACL       string       `json:"acl"`
+	Interface string       `json:"interface"`
+	Direction ACLDirection `json:"direction"`
+	Sequence  int          `json:"sequence"` // 0 for the implicit deny at the end of the list
+	Action    ACLAction    `json:"action"`
+}
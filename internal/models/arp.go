@@ -0,0 +1,47 @@
+package models
+
+// ARPConfig represents the ARP and neighbor cache settings of a router
+type ARPConfig struct {
+	ID       uint `json:"-" gorm:"primaryKey"`
+	RouterID uint `json:"router_id" gorm:"uniqueIndex"`
+	Timeout  int  `json:"timeout"` // seconds a dynamic entry is kept, 0 disables aging
+}
+
+// UpdateARPConfigRequest represents the request to configure the ARP cache of a router
+type UpdateARPConfigRequest struct {
+	Timeout *int `json:"timeout"`
+}
+
+// ARPEntryType represents how an ARP or neighbor cache entry was created
+type ARPEntryType string
+
+const (
+	ARPEntryDynamic    ARPEntryType = "dynamic"    // resolved by the simulator while forwarding
+	ARPEntryStatic     ARPEntryType = "static"     // configured by the user
+	ARPEntryIncomplete ARPEntryType = "incomplete" // resolution failed, no device answered
+)
+
+// ARPEntry represents an entry of the ARP (IPv4) or neighbor (IPv6) cache of a router.
+// Only static entries are stored in the database.
+type ARPEntry struct {
+	ID         uint         `json:"id,omitempty" gorm:"primaryKey"`
+	RouterID   uint         `json:"router_id"`
+	IPAddress  string       `json:"ip_address"`
+	MACAddress string       `json:"mac_address"`
+	Interface  string       `json:"interface"`
+	Type       ARPEntryType `json:"type"`
+	Age        int          `json:"age" gorm:"-"` // virtual seconds since the entry was resolved
+}
+
+// CreateARPEntryRequest represents the request to add a static ARP or neighbor entry
+type CreateARPEntryRequest struct {
+	IPAddress  string `json:"ip_address"`
+	MACAddress string `json:"mac_address"`
+	Interface  string `json:"interface"`
+}
+
+// ARPTable represents the ARP or neighbor cache of a router
+type ARPTable struct {
+	ARPConfig
+	Entries []ARPEntry `json:"entries"`
+}
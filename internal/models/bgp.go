@@ -0,0 +1,159 @@
+package models
+
+// BGPProcess represents the BGP speaker of a router
+type BGPProcess struct {
+	ID          uint   `json:"-" gorm:"primaryKey"`
+	RouterID    uint   `json:"router_id" gorm:"uniqueIndex"`
+	Enabled     bool   `json:"enabled"`
+	ASN         uint32 `json:"asn"`
+	BGPRouterID string `json:"bgp_router_id"` // dotted-quad, router IP address when empty
+}
+
+// UpdateBGPRequest represents the request to configure the BGP speaker of a router
+type UpdateBGPRequest struct {
+	Enabled     *bool   `json:"enabled"`
+	ASN         *uint32 `json:"asn"`
+	BGPRouterID *string `json:"bgp_router_id"`
+}
+
+// BGPNeighbor represents a BGP session configured over an existing connection.
+// The session is established when both ends configure each other.
+type BGPNeighbor struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	RouterID     uint   `json:"router_id"`
+	ConnectionID uint   `json:"connection_id"`
+	RemoteAS     uint32 `json:"remote_as"`
+	NextHopSelf  bool   `json:"next_hop_self"`
+	RouteMapIn   string `json:"route_map_in"`
+	RouteMapOut  string `json:"route_map_out"`
+	Description  string `json:"description"`
+}
+
+type CreateBGPNeighborRequest struct {
+	ConnectionID uint   `json:"connection_id"`
+	RemoteAS     uint32 `json:"remote_as"`
+	NextHopSelf  bool   `json:"next_hop_self"`
+	RouteMapIn   string `json:"route_map_in"`
+	RouteMapOut  string `json:"route_map_out"`
+	Description  string `json:"description"`
+}
+
+// BGPSessionType represents whether a session is external or internal
+type BGPSessionType string
+
+const (
+	BGPSessionExternal BGPSessionType = "ebgp"
+	BGPSessionInternal BGPSessionType = "ibgp"
+)
+
+// BGPSessionState represents the state of a BGP session
+type BGPSessionState string
+
+const (
+	BGPSessionIdle        BGPSessionState = "idle"   // connection down or speaker disabled
+	BGPSessionActive      BGPSessionState = "active" // peer does not accept the session
+	BGPSessionEstablished BGPSessionState = "established"
+)
+
+// BGPNeighborInfo represents a configured neighbor together with its session state
+type BGPNeighborInfo struct {
+	BGPNeighbor
+	PeerIP           string          `json:"peer_ip"`
+	PeerDeviceID     uint            `json:"peer_device_id"`
+	Type             BGPSessionType  `json:"type"`
+	State            BGPSessionState `json:"state"`
+	PrefixesReceived int             `json:"prefixes_received"`
+	PrefixesAccepted int             `json:"prefixes_accepted"`
+}
+
+// BGPNetwork represents a prefix announced by a router
+type BGPNetwork struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	RouterID uint   `json:"router_id"`
+	Prefix   string `json:"prefix"`
+}
+
+type CreateBGPNetworkRequest struct {
+	Prefix string `json:"prefix"`
+}
+
+// RouteMapAction represents the action of a route-map entry
+type RouteMapAction string
+
+const (
+	RouteMapPermit RouteMapAction = "permit"
+	RouteMapDeny   RouteMapAction = "deny"
+)
+
+// BGPRouteMapEntry represents a sequence of a route-map. Entries of a route-map
+// are evaluated in sequence order; a path matching no entry is denied.
+type BGPRouteMapEntry struct {
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	RouterID         uint           `json:"router_id"`
+	Name             string         `json:"name"`
+	Sequence         int            `json:"sequence"`
+	Action           RouteMapAction `json:"action"`
+	MatchPrefix      string         `json:"match_prefix"`  // matches prefixes within the CIDR
+	MatchASPath      string         `json:"match_as_path"` // regular expression over the AS path, e.g. "^65001 "
+	SetLocalPref     *uint32        `json:"set_local_pref"`
+	SetMED           *uint32        `json:"set_med"`
+	SetASPathPrepend int            `json:"set_as_path_prepend"` // number of times to prepend the AS
+}
+
+type CreateBGPRouteMapEntryRequest struct {
+	Name             string         `json:"name"`
+	Sequence         int            `json:"sequence"` // next multiple of 10 when zero
+	Action           RouteMapAction `json:"action"`
+	MatchPrefix      string         `json:"match_prefix"`
+	MatchASPath      string         `json:"match_as_path"`
+	SetLocalPref     *uint32        `json:"set_local_pref"`
+	SetMED           *uint32        `json:"set_med"`
+	SetASPathPrepend int            `json:"set_as_path_prepend"`
+}
+
+// BGPInfo represents the BGP configuration of a router
+type BGPInfo struct {
+	BGPProcess
+	Neighbors []BGPNeighborInfo  `json:"neighbors"`
+	Networks  []BGPNetwork       `json:"networks"`
+	RouteMaps []BGPRouteMapEntry `json:"route_maps"`
+}
+
+// BGPPathSource represents where a path was learned from
+type BGPPathSource string
+
+const (
+	BGPPathLocal    BGPPathSource = "local"
+	BGPPathExternal BGPPathSource = "ebgp"
+	BGPPathInternal BGPPathSource = "ibgp"
+)
+
+// BGPPathStatus represents the outcome of inbound processing of a received path
+type BGPPathStatus string
+
+const (
+	BGPPathAccepted           BGPPathStatus = "accepted"
+	BGPPathDenied             BGPPathStatus = "denied"      // rejected by the inbound route-map
+	BGPPathASLoop             BGPPathStatus = "as-loop"     // own AS found in the AS path
+	BGPPathNextHopUnreachable BGPPathStatus = "unreachable" // next hop not resolvable
+)
+
+// BGPPath represents a path to a prefix with its BGP attributes
+type BGPPath struct {
+	Prefix    string        `json:"prefix"`
+	NextHop   string        `json:"next_hop"` // empty for locally originated prefixes
+	ASPath    []uint32      `json:"as_path"`
+	LocalPref uint32        `json:"local_pref"`
+	MED       uint32        `json:"med"`
+	Origin    string        `json:"origin"`
+	Source    BGPPathSource `json:"source"`
+	Peer      string        `json:"peer,omitempty"`
+	Status    BGPPathStatus `json:"status,omitempty"`
+}
+
+// BGPAdjRIBIn represents the paths received from a neighbor
+type BGPAdjRIBIn struct {
+	NeighborID uint      `json:"neighbor_id"`
+	Peer       string    `json:"peer"`
+	Routes     []BGPPath `json:"routes"`
+}
@@ -20,22 +20,42 @@ const (
 	Speed10000 Speed = "10000"
 )
 
+// DeviceType discriminates the kinds of devices in the topology
+type DeviceType string
+
+const (
+	DeviceTypeRouter DeviceType = "router"
+	DeviceTypeSwitch DeviceType = "switch" // layer-2 device, ports carry no IP addresses
+	DeviceTypeHost   DeviceType = "host"   // end host, sends packets via its default gateway and does not forward
+)
+
 type Router struct {
-	ID        uint   `json:"id" gorm:"primaryKey"`
-	Name      string `json:"name"`
-	IPAddress string `json:"ip_address"`
-	Status    string `json:"status"`
-	Ports     []Port `json:"ports" gorm:"foreignKey:RouterID"`
-	Connected bool   `json:"connected" gorm:"default:false"`
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	Name       string      `json:"name"`
+	Type       DeviceType  `json:"type" gorm:"default:'router'"`
+	IPAddress  string      `json:"ip_address"` // a DHCP-only host takes the address of its lease, empty until bound
+	Status     string      `json:"status"`
+	Ports      []Port      `json:"ports" gorm:"foreignKey:RouterID"`
+	Interfaces []Interface `json:"interfaces" gorm:"foreignKey:RouterID"`
+	Routes     []Route     `json:"routes" gorm:"foreignKey:RouterID"`
+	Connected  bool        `json:"connected" gorm:"default:false"`
+	// DefaultGateway is the next hop of the default route of a host
+	DefaultGateway string `json:"default_gateway,omitempty"`
 }
 
+// Statuses of a TCP/UDP port. Packets are delivered only to open ports.
+const (
+	PortStatusOpen   = "open"
+	PortStatusClosed = "closed"
+)
+
 // Port represents a network port configuration
 type Port struct {
 	ID          uint       `json:"id" gorm:"primaryKey"`
 	RouterID    uint       `json:"router_id"`
 	Number      int        `json:"number" gorm:"check:number >= 1 AND number <= 65535"`
 	Protocol    string     `json:"protocol" gorm:"check:protocol IN ('tcp', 'udp')"`
-	Status      string     `json:"status" gorm:"default:'closed'"` // open, closed, filtered
+	Status      string     `json:"status" gorm:"default:'closed'"` // PortStatusOpen or PortStatusClosed
 	PortNumber  int        `json:"portNumber"`
 	Speed       Speed      `json:"speed"`
 	DuplexMode  DuplexMode `json:"duplexMode"`
@@ -43,8 +63,22 @@ type Port struct {
 }
 
 type CreateRouterRequest struct {
-	Name  string    `json:"name" binding:"required"`
-	Ports []PortReq `json:"ports"`
+	Name       string                   `json:"name" binding:"required"`
+	Type       DeviceType               `json:"type"`       // router when empty
+	IPAddress  string                   `json:"ip_address"` // fixed address, allocated from a pool when empty
+	PoolID     *uint                    `json:"pool_id"`    // pool to allocate from, default pool when empty
+	Ports      []PortReq                `json:"ports"`
+	Interfaces []CreateInterfaceRequest `json:"interfaces"` // default Ethernet interfaces when empty
+
+	// Host settings. Without interfaces in the request a host gets eth0
+	// addressed with ip_address and netmask.
+	Netmask        string `json:"netmask"` // 255.255.255.0 when empty
+	DefaultGateway string `json:"default_gateway"`
+}
+
+// UpdateGatewayRequest represents the request to change the default gateway of a host
+type UpdateGatewayRequest struct {
+	DefaultGateway string `json:"default_gateway"` // removes the default route when empty
 }
 
 type ConnectRouterRequest struct {
@@ -65,14 +99,80 @@ type PortReq struct {
 	Protocol string `json:"protocol" binding:"required,oneof=tcp udp"`
 }
 
+// PingMode selects how a ping is answered
+type PingMode string
+
+const (
+	PingModeAuto      PingMode = "auto"      // simulated for routers in the topology, real otherwise
+	PingModeReal      PingMode = "real"      // ICMP echo from the host
+	PingModeSimulated PingMode = "simulated" // answered by the simulator over router connections
+)
+
 type PingRequest struct {
-	IPAddress string `json:"ip_address"`
+	IPAddress   string   `json:"ip_address"`
+	SourceIP    string   `json:"source_ip"` // source router of simulated pings, the connected router when empty
+	Mode        PingMode `json:"mode"`
+	Seed        *int64   `json:"seed,omitempty"`
+	Count       int      `json:"count"`        // number of echo requests, default 4
+	Interval    int      `json:"interval"`     // ms between probes, default 1000
+	Timeout     int      `json:"timeout"`      // ms to wait for each reply, default 2000
+	PayloadSize int      `json:"payload_size"` // bytes, default 56
+	TTL         int      `json:"ttl"`          // default 64
 }
 
 type PingResult struct {
-	IPAddress string  `json:"ip_address"`
-	Latency   float64 `json:"latency"`
-	Status    string  `json:"status"`
+	IPAddress   string      `json:"ip_address"`
+	SourceIP    string      `json:"source_ip,omitempty"` // router the simulated probes were sent from
+	Mode        PingMode    `json:"mode"`
+	Seed        *int64      `json:"seed,omitempty"` // set for simulated pings
+	Latency     float64     `json:"latency"`        // average RTT, ms
+	Status      string      `json:"status"`
+	Probes      []PingProbe `json:"probes"`
+	Sent        int         `json:"sent"`
+	Received    int         `json:"received"`
+	LossPercent float64     `json:"loss_percent"`
+	MinRTT      float64     `json:"min_rtt"`
+	AvgRTT      float64     `json:"avg_rtt"`
+	MaxRTT      float64     `json:"max_rtt"`
+	StdDevRTT   float64     `json:"stddev_rtt"`
+	Error       string      `json:"error,omitempty"`
+}
+
+// PingProbe represents the outcome of a single echo request
+type PingProbe struct {
+	Seq    int     `json:"seq"`
+	RTT    float64 `json:"rtt"` // ms
+	From   string  `json:"from,omitempty"`
+	Status string  `json:"status"` // success, timeout, ttl exceeded, unreachable
+}
+
+type TracerouteRequest struct {
+	IPAddress string   `json:"ip_address"`
+	SourceIP  string   `json:"source_ip"` // source router of simulated traceroute, the connected router when empty
+	Mode      PingMode `json:"mode"`
+	MaxHops   int      `json:"max_hops"` // default 30
+	Probes    int      `json:"probes"`   // probes per hop, default 3
+	Timeout   int      `json:"timeout"`  // ms to wait for each probe, default 2000
+	Seed      *int64   `json:"seed,omitempty"`
+}
+
+type TracerouteResult struct {
+	IPAddress string          `json:"ip_address"`
+	SourceIP  string          `json:"source_ip,omitempty"` // router the simulated probes were sent from
+	Mode      PingMode        `json:"mode"`
+	Seed      *int64          `json:"seed,omitempty"` // set for simulated traceroute
+	Status    string          `json:"status"`         // success when the destination replied
+	Hops      []TracerouteHop `json:"hops"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// TracerouteHop represents the replies received for a single TTL
+type TracerouteHop struct {
+	TTL       int        `json:"ttl"`
+	IPAddress string     `json:"ip_address,omitempty"` // empty when no probe was answered
+	Name      string     `json:"name,omitempty"`       // router name for simulated hops
+	RTT       []*float64 `json:"rtt"`                  // ms per probe, null on timeout
+	Status    string     `json:"status"`               // ttl exceeded, success, unreachable, timeout
 }
 
 type PacketRequest struct {
@@ -80,17 +180,40 @@ type PacketRequest struct {
 	DestinationIP string `json:"destination_ip"`
 	Protocol      string `json:"protocol" binding:"required,oneof=tcp udp"`
 	Port          int    `json:"port" binding:"required,min=1,max=65535"`
+	SourcePort    int    `json:"source_port,omitempty"` // ephemeral port when zero
+	Reply         bool   `json:"reply"`                 // simulate the response: TCP handshake or a UDP reply datagram
 	Data          string `json:"data"`
+	Seed          *int64 `json:"seed,omitempty"` // fixes the simulation random source
 }
 
 type PacketResponse struct {
-	SourceIP      string  `json:"source_ip"`
-	DestinationIP string  `json:"destination_ip"`
-	Protocol      string  `json:"protocol"`
-	Port          int     `json:"port"`
-	Status        string  `json:"status"`
-	Latency       float64 `json:"latency"`
-	Error         string  `json:"error,omitempty"`
+	SourceIP      string      `json:"source_ip"`
+	DestinationIP string      `json:"destination_ip"`
+	Protocol      string      `json:"protocol"`
+	Port          int         `json:"port"`
+	SourcePort    int         `json:"source_port"`
+	Status        string      `json:"status"`
+	Latency       float64     `json:"latency"`
+	Loss          float64     `json:"loss"` // end-to-end loss probability of the path
+	Seed          int64       `json:"seed"` // seed to replay this simulation
+	Hops          []PacketHop `json:"hops,omitempty"`
+	ReplyHops     []PacketHop `json:"reply_hops,omitempty"` // path of the response when reply is requested
+	Error         string      `json:"error,omitempty"`
+}
+
+// PacketHop represents a device traversed by a packet
+type PacketHop struct {
+	RouterID     uint           `json:"router_id"`
+	Name         string         `json:"name"`
+	IPAddress    string         `json:"ip_address"`
+	Type         DeviceType     `json:"type"`
+	ConnectionID uint           `json:"connection_id,omitempty"`
+	Action       FrameAction    `json:"action,omitempty"`    // set for switches
+	VLAN         int            `json:"vlan,omitempty"`      // VLAN of the frame on a switch
+	ACL          []ACLMatch     `json:"acl,omitempty"`       // access lists that matched the packet on the device
+	Conntrack    ConntrackState `json:"conntrack,omitempty"` // connection state on a device with a stateful firewall
+	NAT          []NATMatch     `json:"nat,omitempty"`       // addresses of the packet before and after translation on the device
+	Latency      float64        `json:"latency"`             // time since the packet left the source, ms
 }
 
 type ConfigureRouterRequest struct {
@@ -109,6 +232,13 @@ type ConfigurePortRequest struct {
 	Speed       Speed      `json:"speed"`
 	DuplexMode  DuplexMode `json:"duplexMode"`
 	Description string     `json:"description"`
+
+	// Switch port settings, applied to the interface of the switch named Interface
+	Interface    string         `json:"interface"`
+	Mode         SwitchportMode `json:"mode"`
+	AccessVLAN   *int           `json:"accessVlan"`
+	NativeVLAN   *int           `json:"nativeVlan"`
+	AllowedVLANs *string        `json:"allowedVlans"`
 }
 
 type ConfigureResponse struct {
@@ -117,32 +247,70 @@ type ConfigureResponse struct {
 }
 
 type RouterConnection struct {
-	ID           uint   `json:"id" gorm:"primaryKey"`
-	RouterFromID uint   `json:"router_from_id"`
-	RouterToID   uint   `json:"router_to_id"`
-	Status       string `json:"status" gorm:"default:'active'"`
-	CreatedAt    string `json:"created_at"`
+	ID              uint    `json:"id" gorm:"primaryKey"`
+	RouterFromID    uint    `json:"router_from_id"`
+	RouterToID      uint    `json:"router_to_id"`
+	FromInterfaceID uint    `json:"from_interface_id"` // 0 for links created before interfaces
+	ToInterfaceID   uint    `json:"to_interface_id"`
+	Status          string  `json:"status" gorm:"default:'active'"`
+	Delay           float64 `json:"delay" gorm:"default:1"`        // propagation delay, ms
+	Jitter          float64 `json:"jitter" gorm:"default:0"`       // max delay deviation, ms
+	PacketLoss      float64 `json:"packet_loss" gorm:"default:0"`  // loss probability, 0-1
+	Bandwidth       float64 `json:"bandwidth" gorm:"default:1000"` // Mbps
+	CreatedAt       string  `json:"created_at"`
+}
+
+// UpdateConnectionRequest represents the request to edit link status and properties
+type UpdateConnectionRequest struct {
+	Status     *string  `json:"status"` // active, inactive
+	Delay      *float64 `json:"delay"`
+	Jitter     *float64 `json:"jitter"`
+	PacketLoss *float64 `json:"packet_loss"`
+	Bandwidth  *float64 `json:"bandwidth"`
 }
 
 type CreateConnectionRequest struct {
-	RouterFromIP string `json:"router_from_ip" binding:"required"`
-	RouterToIP   string `json:"router_to_ip" binding:"required"`
+	RouterFromIP  string   `json:"router_from_ip"`
+	RouterToIP    string   `json:"router_to_ip"`
+	RouterFromID  uint     `json:"router_from_id"` // takes precedence over router_from_ip, e.g. for a DHCP host without an address
+	RouterToID    uint     `json:"router_to_id"`
+	FromInterface string   `json:"from_interface"` // interface name, first free one when empty
+	ToInterface   string   `json:"to_interface"`
+	AutoAddress   *bool    `json:"auto_address"` // allocate a transfer subnet, default true
+	LinkPoolID    *uint    `json:"link_pool_id"` // pool for the transfer subnet, first link pool when empty
+	Delay         *float64 `json:"delay"`
+	Jitter        *float64 `json:"jitter"`
+	PacketLoss    *float64 `json:"packet_loss"`
+	Bandwidth     *float64 `json:"bandwidth"`
 }
 
 type CreateConnectionResponse struct {
-	ID           uint   `json:"id"`
-	RouterFromIP string `json:"router_from_ip"`
-	RouterToIP   string `json:"router_to_ip"`
-	Status       string `json:"status"`
-	CreatedAt    string `json:"created_at"`
+	ID            uint    `json:"id"`
+	RouterFromIP  string  `json:"router_from_ip"`
+	RouterToIP    string  `json:"router_to_ip"`
+	FromInterface string  `json:"from_interface,omitempty"`
+	ToInterface   string  `json:"to_interface,omitempty"`
+	Subnet        string  `json:"subnet,omitempty"` // transfer subnet assigned to the link
+	Status        string  `json:"status"`
+	Delay         float64 `json:"delay"`
+	Jitter        float64 `json:"jitter"`
+	PacketLoss    float64 `json:"packet_loss"`
+	Bandwidth     float64 `json:"bandwidth"`
+	CreatedAt     string  `json:"created_at"`
 }
 
 type ConnectionInfo struct {
-	ID           uint   `json:"id"`
-	RouterFromIP string `json:"router_from_ip"`
-	RouterToIP   string `json:"router_to_ip"`
-	Status       string `json:"status"`
-	CreatedAt    string `json:"created_at"`
-	FromRouter   Router `json:"from_router"`
-	ToRouter     Router `json:"to_router"`
+	ID            uint    `json:"id"`
+	RouterFromIP  string  `json:"router_from_ip"`
+	RouterToIP    string  `json:"router_to_ip"`
+	FromInterface string  `json:"from_interface,omitempty"`
+	ToInterface   string  `json:"to_interface,omitempty"`
+	Status        string  `json:"status"`
+	Delay         float64 `json:"delay"`
+	Jitter        float64 `json:"jitter"`
+	PacketLoss    float64 `json:"packet_loss"`
+	Bandwidth     float64 `json:"bandwidth"`
+	CreatedAt     string  `json:"created_at"`
+	FromRouter    Router  `json:"from_router"`
+	ToRouter      Router  `json:"to_router"`
 }
@@ -0,0 +1,84 @@
+package models
+
+// DHCPPool represents an address pool served by the DHCP server of a router.
+// The pool serves clients on the segment of the router interface whose subnet is Subnet.
+type DHCPPool struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	RouterID   uint   `json:"router_id"`
+	Name       string `json:"name"`
+	Subnet     string `json:"subnet"`
+	RangeStart string `json:"range_start"` // first leased address
+	RangeEnd   string `json:"range_end"`   // last leased address
+	Gateway    string `json:"gateway"`     // default router option, address of the serving interface when empty
+	DNSServers string `json:"dns_servers"` // comma-separated DNS server option
+	LeaseTime  int    `json:"lease_time"`  // seconds
+}
+
+type CreateDHCPPoolRequest struct {
+	Name       string `json:"name"`
+	Subnet     string `json:"subnet"`
+	RangeStart string `json:"range_start"` // first host address of the subnet when empty
+	RangeEnd   string `json:"range_end"`   // last host address of the subnet when empty
+	Gateway    string `json:"gateway"`
+	DNSServers string `json:"dns_servers"`
+	LeaseTime  int    `json:"lease_time"` // one day when zero
+}
+
+// DHCPLeaseState represents whether a lease still binds its address to the client
+type DHCPLeaseState string
+
+const (
+	DHCPLeaseActive  DHCPLeaseState = "active"
+	DHCPLeaseExpired DHCPLeaseState = "expired"
+)
+
+// DHCPLease represents an address bound by the DHCP server of a router to a client interface
+type DHCPLease struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	RouterID    uint           `json:"router_id"` // DHCP server
+	PoolID      uint           `json:"pool_id"`
+	IPAddress   string         `json:"ip_address"`
+	MACAddress  string         `json:"mac_address"`
+	ClientID    uint           `json:"client_id"`
+	InterfaceID uint           `json:"interface_id"` // client interface
+	Hostname    string         `json:"hostname"`
+	LeasedAt    float64        `json:"leased_at"`  // simulation time the lease was granted, ms
+	ExpiresAt   float64        `json:"expires_at"` // simulation time the lease expires, ms
+	State       DHCPLeaseState `json:"state" gorm:"-"`
+	Remaining   int            `json:"remaining" gorm:"-"` // virtual seconds until the lease expires
+}
+
+// DHCPMessageType represents the type of a DHCP message
+type DHCPMessageType string
+
+const (
+	DHCPDiscover DHCPMessageType = "discover"
+	DHCPOffer    DHCPMessageType = "offer"
+	DHCPRequest  DHCPMessageType = "request"
+	DHCPAck      DHCPMessageType = "ack"
+	DHCPRelease  DHCPMessageType = "release"
+)
+
+// DHCPMessage represents a DHCP message exchanged between a client interface and a server
+type DHCPMessage struct {
+	Type          DHCPMessageType `json:"type"`
+	SourceIP      string          `json:"source_ip"`
+	DestinationIP string          `json:"destination_ip"`
+	YourIP        string          `json:"your_ip,omitempty"` // address offered or assigned to the client
+	Hops          []PacketHop     `json:"hops"`
+}
+
+// DHCPExchange represents the messages a client interface exchanged with DHCP servers
+// and the configuration it obtained
+type DHCPExchange struct {
+	Interface    string        `json:"interface"`
+	MACAddress   string        `json:"mac_address"`
+	Status       string        `json:"status"`           // bound, released or failed
+	Server       string        `json:"server,omitempty"` // address of the server that acknowledged or released the lease
+	Messages     []DHCPMessage `json:"messages"`
+	Lease        *DHCPLease    `json:"lease,omitempty"`
+	PrefixLength int           `json:"prefix_length,omitempty"`
+	Gateway      string        `json:"gateway,omitempty"`
+	DNSServers   []string      `json:"dns_servers,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
@@ -0,0 +1,61 @@
+package models
+
+// FirewallConfig represents the stateful firewall of a router. When enabled, the router
+// tracks connections passing through it and drops unsolicited packets received on outside interfaces.
+type FirewallConfig struct {
+	ID          uint `json:"-" gorm:"primaryKey"`
+	RouterID    uint `json:"router_id" gorm:"uniqueIndex"`
+	Enabled     bool `json:"enabled"`
+	TCPTimeout  int  `json:"tcp_timeout"`  // seconds an established TCP connection is kept without packets
+	UDPTimeout  int  `json:"udp_timeout"`  // seconds a UDP flow is kept without packets
+	ICMPTimeout int  `json:"icmp_timeout"` // seconds an ICMP echo flow is kept without packets
+}
+
+// UpdateFirewallRequest represents the request to configure the stateful firewall of a router
+type UpdateFirewallRequest struct {
+	Enabled     *bool `json:"enabled"`
+	TCPTimeout  *int  `json:"tcp_timeout"`
+	UDPTimeout  *int  `json:"udp_timeout"`
+	ICMPTimeout *int  `json:"icmp_timeout"`
+}
+
+// FirewallZone represents the side of the stateful firewall an interface is on
+type FirewallZone string
+
+const (
+	FirewallZoneInside  FirewallZone = "inside"
+	FirewallZoneOutside FirewallZone = "outside" // only replies and packets permitted by the inbound ACL enter
+)
+
+// ConntrackState represents the state of a tracked connection
+type ConntrackState string
+
+const (
+	ConntrackSynSent     ConntrackState = "syn_sent"
+	ConntrackSynRecv     ConntrackState = "syn_recv"
+	ConntrackEstablished ConntrackState = "established"
+	ConntrackFinWait     ConntrackState = "fin_wait"
+	ConntrackTimeWait    ConntrackState = "time_wait"
+	ConntrackClose       ConntrackState = "close"
+	ConntrackUnreplied   ConntrackState = "unreplied" // UDP or ICMP flow without a reply yet
+	ConntrackReplied     ConntrackState = "replied"
+)
+
+// ConntrackEntry represents a connection tracked by the stateful firewall of a router
+type ConntrackEntry struct {
+	Protocol        string           `json:"protocol"`
+	SourceIP        string           `json:"source_ip"`
+	SourcePort      int              `json:"source_port,omitempty"`
+	DestinationIP   string           `json:"destination_ip"`
+	DestinationPort int              `json:"destination_port,omitempty"`
+	State           ConntrackState   `json:"state"`
+	Packets         int64            `json:"packets"`
+	Reply           *PacketAddresses `json:"reply,omitempty"` // addresses of replies when NAT translated the connection
+	Timeout         int              `json:"timeout"`         // virtual seconds until the entry expires
+}
+
+// FirewallInfo represents the stateful firewall of a router with its connection tracking table
+type FirewallInfo struct {
+	FirewallConfig
+	Connections []ConntrackEntry `json:"connections"`
+}
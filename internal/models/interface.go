@@ -0,0 +1,103 @@
+package models
+
+// InterfaceStatus represents the administrative or operational state of an interface
+type InterfaceStatus string
+
+const (
+	InterfaceStatusUp   InterfaceStatus = "up"
+	InterfaceStatusDown InterfaceStatus = "down"
+)
+
+// InterfaceType represents the kind of a router interface
+type InterfaceType string
+
+const (
+	InterfaceTypeEthernet InterfaceType = "ethernet"
+	InterfaceTypeLoopback InterfaceType = "loopback"
+	// InterfaceTypeSubinterface is an 802.1Q subinterface of an Ethernet interface,
+	// named <parent>.<n> (e.g. Gi0/0.10)
+	InterfaceTypeSubinterface InterfaceType = "subinterface"
+)
+
+// Interface represents a layer-3 interface of a router
+type Interface struct {
+	ID               uint            `json:"id" gorm:"primaryKey"`
+	RouterID         uint            `json:"router_id"`
+	Name             string          `json:"name"` // e.g. Gi0/1
+	Type             InterfaceType   `json:"type" gorm:"default:'ethernet'"`
+	MACAddress       string          `json:"mac_address"`
+	IPv4Address      string          `json:"ipv4_address"`
+	IPv4PrefixLength int             `json:"ipv4_prefix_length"`
+	IPv6Address      string          `json:"ipv6_address"`
+	IPv6PrefixLength int             `json:"ipv6_prefix_length"`
+	MTU              int             `json:"mtu" gorm:"default:1500"`
+	Speed            Speed           `json:"speed" gorm:"default:'auto'"`
+	DuplexMode       DuplexMode      `json:"duplex_mode" gorm:"default:'auto'"`
+	AdminStatus      InterfaceStatus `json:"admin_status" gorm:"default:'up'"`
+	OperStatus       InterfaceStatus `json:"oper_status" gorm:"default:'up'"`
+	Description      string          `json:"description"`
+	OSPFArea         string          `json:"ospf_area"`     // empty when OSPF is not enabled on the interface
+	OSPFCost         int             `json:"ospf_cost"`     // derived from speed when zero
+	VLAN             int             `json:"vlan"`          // 802.1Q tag of a subinterface, 0 otherwise
+	InboundACL       string          `json:"inbound_acl"`   // access list filtering received packets
+	OutboundACL      string          `json:"outbound_acl"`  // access list filtering sent packets
+	FirewallZone     FirewallZone    `json:"firewall_zone"` // side of the stateful firewall, empty when not assigned
+	DHCPClient       bool            `json:"dhcp_client"`   // the IPv4 address is obtained from a DHCP server
+
+	// Switch port settings
+	SwitchportMode SwitchportMode `json:"switchport_mode,omitempty"`
+	AccessVLAN     int            `json:"access_vlan,omitempty"`   // VLAN of an access port
+	NativeVLAN     int            `json:"native_vlan,omitempty"`   // VLAN of untagged frames on a trunk
+	AllowedVLANs   string         `json:"allowed_vlans,omitempty"` // VLANs carried by a trunk, e.g. 10,20,30-40; all when empty
+}
+
+type CreateInterfaceRequest struct {
+	Name             string          `json:"name"`
+	Type             InterfaceType   `json:"type"`
+	MACAddress       string          `json:"mac_address"`
+	IPv4Address      string          `json:"ipv4_address"`
+	IPv4PrefixLength int             `json:"ipv4_prefix_length"`
+	IPv6Address      string          `json:"ipv6_address"`
+	IPv6PrefixLength int             `json:"ipv6_prefix_length"`
+	MTU              int             `json:"mtu"`
+	Speed            Speed           `json:"speed"`
+	DuplexMode       DuplexMode      `json:"duplex_mode"`
+	AdminStatus      InterfaceStatus `json:"admin_status"`
+	Description      string          `json:"description"`
+	OSPFArea         string          `json:"ospf_area"`
+	OSPFCost         int             `json:"ospf_cost"`
+	VLAN             int             `json:"vlan"`
+	InboundACL       string          `json:"inbound_acl"`
+	OutboundACL      string          `json:"outbound_acl"`
+	FirewallZone     FirewallZone    `json:"firewall_zone"`
+	DHCPClient       bool            `json:"dhcp_client"`
+	SwitchportMode   SwitchportMode  `json:"switchport_mode"`
+	AccessVLAN       int             `json:"access_vlan"`
+	NativeVLAN       int             `json:"native_vlan"`
+	AllowedVLANs     string          `json:"allowed_vlans"`
+}
+
+type UpdateInterfaceRequest struct {
+	Name             *string          `json:"name"`
+	MACAddress       *string          `json:"mac_address"`
+	IPv4Address      *string          `json:"ipv4_address"`
+	IPv4PrefixLength *int             `json:"ipv4_prefix_length"`
+	IPv6Address      *string          `json:"ipv6_address"`
+	IPv6PrefixLength *int             `json:"ipv6_prefix_length"`
+	MTU              *int             `json:"mtu"`
+	Speed            *Speed           `json:"speed"`
+	DuplexMode       *DuplexMode      `json:"duplex_mode"`
+	AdminStatus      *InterfaceStatus `json:"admin_status"`
+	Description      *string          `json:"description"`
+	OSPFArea         *string          `json:"ospf_area"`
+	OSPFCost         *int             `json:"ospf_cost"`
+	VLAN             *int             `json:"vlan"`
+	InboundACL       *string          `json:"inbound_acl"`
+	OutboundACL      *string          `json:"outbound_acl"`
+	FirewallZone     *FirewallZone    `json:"firewall_zone"`
+	DHCPClient       *bool            `json:"dhcp_client"`
+	SwitchportMode   *SwitchportMode  `json:"switchport_mode"`
+	AccessVLAN       *int             `json:"access_vlan"`
+	NativeVLAN       *int             `json:"native_vlan"`
+	AllowedVLANs     *string          `json:"allowed_vlans"`
+}
@@ -0,0 +1,63 @@
+package models
+
+// AllocationMode represents how addresses are picked from a pool
+type AllocationMode string
+
+const (
+	AllocationSequential AllocationMode = "sequential"
+	AllocationRandom     AllocationMode = "random"
+)
+
+// PoolPurpose represents what addresses of a pool are used for
+type PoolPurpose string
+
+const (
+	PoolPurposeHost PoolPurpose = "host" // router addresses
+	PoolPurposeLink PoolPurpose = "link" // point-to-point transfer subnets
+)
+
+// IPPool represents a subnet addresses are allocated from
+type IPPool struct {
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	Name             string         `json:"name" gorm:"uniqueIndex"`
+	Subnet           string         `json:"subnet"` // CIDR, e.g. 192.168.0.0/16
+	Purpose          PoolPurpose    `json:"purpose" gorm:"default:'host'"`
+	Allocation       AllocationMode `json:"allocation" gorm:"default:'sequential'"`
+	LinkPrefixLength int            `json:"link_prefix_length,omitempty"` // 30 or 31 for link pools
+	Description      string         `json:"description"`
+}
+
+// IPAllocation represents an address taken from a pool
+type IPAllocation struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	PoolID       uint   `json:"pool_id"`
+	Address      string `json:"address" gorm:"uniqueIndex"`
+	RouterID     uint   `json:"router_id"`               // 0 for manual reservations
+	InterfaceID  uint   `json:"interface_id,omitempty"`  // set for link addresses
+	ConnectionID uint   `json:"connection_id,omitempty"` // set for link addresses
+	Description  string `json:"description"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type CreateIPPoolRequest struct {
+	Name             string         `json:"name"`
+	Subnet           string         `json:"subnet"`
+	Purpose          PoolPurpose    `json:"purpose"`
+	Allocation       AllocationMode `json:"allocation"`
+	LinkPrefixLength int            `json:"link_prefix_length"`
+	Description      string         `json:"description"`
+}
+
+type ReserveIPRequest struct {
+	IPAddress   string `json:"ip_address"` // empty to take the next free address
+	Description string `json:"description"`
+}
+
+// IPPoolInfo represents a pool with its utilization
+type IPPoolInfo struct {
+	IPPool
+	Size        int     `json:"size"`
+	Used        int     `json:"used"`
+	Free        int     `json:"free"`
+	Utilization float64 `json:"utilization"` // percent
+}
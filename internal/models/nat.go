@@ -0,0 +1,80 @@
+package models
+
+// NATType represents the kind of address translation performed by a NAT rule
+type NATType string
+
+const (
+	NATTypeStatic      NATType = "static"      // one-to-one mapping of an inside address in both directions
+	NATTypeDynamic     NATType = "dynamic"     // inside addresses borrow global addresses from a pool
+	NATTypePAT         NATType = "pat"         // inside addresses share the address of the outside interface (masquerade)
+	NATTypeDestination NATType = "destination" // port forwarding of an outside address to an inside address
+)
+
+// NATRule represents an address translation rule of a router. Source rules translate
+// packets leaving the outside interface Interface, destination rules translate packets
+// received on it. Static rules are applied before the others, then rules in ID order.
+type NATRule struct {
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	RouterID    uint    `json:"router_id"`
+	Type        NATType `json:"type"`
+	Interface   string  `json:"interface"` // outside interface
+	Inside      string  `json:"inside"`    // inside local address; CIDR of translated sources for dynamic and pat, any when empty
+	Global      string  `json:"global"`    // inside global address; for destination rules the outside interface address when empty
+	PoolStart   string  `json:"pool_start,omitempty"`
+	PoolEnd     string  `json:"pool_end,omitempty"`
+	Protocol    string  `json:"protocol,omitempty"`    // tcp or udp for destination rules with a port
+	Port        int     `json:"port,omitempty"`        // destination port forwarded by destination rules, every port when zero
+	InsidePort  int     `json:"inside_port,omitempty"` // port the destination rule forwards to
+	Description string  `json:"description"`
+}
+
+type CreateNATRuleRequest struct {
+	Type        NATType `json:"type"`
+	Interface   string  `json:"interface"`
+	Inside      string  `json:"inside"`
+	Global      string  `json:"global"`
+	PoolStart   string  `json:"pool_start"`
+	PoolEnd     string  `json:"pool_end"`
+	Protocol    string  `json:"protocol"`
+	Port        int     `json:"port"`
+	InsidePort  int     `json:"inside_port"` // equals port when zero
+	Description string  `json:"description"`
+}
+
+// NATTranslation represents an entry of the NAT translation table of a router.
+// Addresses of TCP and UDP translations include the port.
+type NATTranslation struct {
+	Protocol      string  `json:"protocol"`
+	InsideLocal   string  `json:"inside_local"`
+	InsideGlobal  string  `json:"inside_global"`
+	OutsideLocal  string  `json:"outside_local"`
+	OutsideGlobal string  `json:"outside_global"`
+	Type          NATType `json:"type"`
+	RuleID        uint    `json:"rule_id"`
+	Packets       int64   `json:"packets"`
+	Timeout       int     `json:"timeout,omitempty"` // virtual seconds until the entry expires, zero for static rules
+}
+
+// NATTable represents the NAT rules of a router with its translation table
+type NATTable struct {
+	Rules        []NATRule        `json:"rules"`
+	Translations []NATTranslation `json:"translations"`
+}
+
+// PacketAddresses represents the addresses and ports of a packet
+type PacketAddresses struct {
+	SourceIP        string `json:"source_ip"`
+	SourcePort      int    `json:"source_port,omitempty"`
+	DestinationIP   string `json:"destination_ip"`
+	DestinationPort int    `json:"destination_port,omitempty"`
+}
+
+// NATMatch represents an address translation applied to a packet on a device
+type NATMatch struct {
+	RuleID     uint            `json:"rule_id"`
+	Type       NATType         `json:"type"`
+	Reply      bool            `json:"reply,omitempty"` // the packet is a reply of a translated connection
+	Interface  string          `json:"interface"`
+	Original   PacketAddresses `json:"original"`
+	Translated PacketAddresses `json:"translated"`
+}
@@ -0,0 +1,99 @@
+package models
+
+// OSPFBackboneArea is the identifier of the backbone area
+const OSPFBackboneArea = "0.0.0.0"
+
+// OSPFProcess represents the OSPF routing process of a router.
+// Interfaces join the process by setting their OSPF area.
+type OSPFProcess struct {
+	ID                 uint   `json:"-" gorm:"primaryKey"`
+	RouterID           uint   `json:"router_id" gorm:"uniqueIndex"`
+	Enabled            bool   `json:"enabled"`
+	OSPFRouterID       string `json:"ospf_router_id"`                         // dotted-quad, router IP address when empty
+	ReferenceBandwidth int    `json:"reference_bandwidth" gorm:"default:100"` // Mbps, interface cost = reference / speed
+}
+
+// UpdateOSPFRequest represents the request to configure the OSPF process of a router
+type UpdateOSPFRequest struct {
+	Enabled            *bool   `json:"enabled"`
+	OSPFRouterID       *string `json:"ospf_router_id"`
+	ReferenceBandwidth *int    `json:"reference_bandwidth"`
+}
+
+// OSPFAdjacencyState represents the state of an OSPF interface towards its neighbor
+type OSPFAdjacencyState string
+
+const (
+	OSPFAdjacencyFull         OSPFAdjacencyState = "full"
+	OSPFAdjacencyDown         OSPFAdjacencyState = "down"
+	OSPFAdjacencyAreaMismatch OSPFAdjacencyState = "area-mismatch"
+	OSPFAdjacencyPassive      OSPFAdjacencyState = "passive" // no OSPF neighbor on the link
+)
+
+// OSPFInterfaceInfo represents an interface participating in OSPF
+type OSPFInterfaceInfo struct {
+	Interface string             `json:"interface"`
+	Area      string             `json:"area"`
+	Cost      int                `json:"cost"`
+	Neighbor  string             `json:"neighbor,omitempty"` // neighbor OSPF router ID
+	State     OSPFAdjacencyState `json:"state"`
+}
+
+// OSPFInfo represents the OSPF process of a router together with its interfaces
+type OSPFInfo struct {
+	OSPFProcess
+	Areas      []string            `json:"areas"`
+	ABR        bool                `json:"abr"` // area border router
+	Interfaces []OSPFInterfaceInfo `json:"interfaces"`
+}
+
+// OSPFLSAType represents the type of a link-state advertisement
+type OSPFLSAType string
+
+const (
+	OSPFLSARouter  OSPFLSAType = "router"
+	OSPFLSASummary OSPFLSAType = "summary"
+)
+
+// OSPFLinkType represents the type of a link described in a router LSA
+type OSPFLinkType string
+
+const (
+	OSPFLinkPointToPoint OSPFLinkType = "point-to-point"
+	OSPFLinkStub         OSPFLinkType = "stub"
+)
+
+// OSPFLink represents a link described in a router LSA
+type OSPFLink struct {
+	Type      OSPFLinkType `json:"type"`
+	LinkID    string       `json:"link_id"` // neighbor router ID or stub network prefix
+	Interface string       `json:"interface"`
+	Metric    int          `json:"metric"`
+}
+
+// OSPFLSA represents an entry of the link-state database
+type OSPFLSA struct {
+	Type              OSPFLSAType `json:"type"`
+	Area              string      `json:"area"`
+	LinkStateID       string      `json:"link_state_id"`
+	AdvertisingRouter string      `json:"advertising_router"`
+	Links             []OSPFLink  `json:"links,omitempty"`  // router LSA
+	Metric            int         `json:"metric,omitempty"` // summary LSA
+}
+
+// OSPFSPFNode represents a router in the shortest path tree
+type OSPFSPFNode struct {
+	OSPFRouterID string `json:"ospf_router_id"`
+	DeviceID     uint   `json:"device_id"`
+	Cost         int    `json:"cost"`
+	Parent       string `json:"parent,omitempty"` // empty for the root
+	NextHop      string `json:"next_hop,omitempty"`
+	Interface    string `json:"interface,omitempty"` // outgoing interface of the root
+}
+
+// OSPFSPFTree represents the shortest path tree computed by a router in an area
+type OSPFSPFTree struct {
+	Area  string        `json:"area"`
+	Root  string        `json:"root"`
+	Nodes []OSPFSPFNode `json:"nodes"`
+}
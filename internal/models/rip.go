@@ -0,0 +1,88 @@
+package models
+
+// RIPInfinity is the hop count that marks a network as unreachable
+const RIPInfinity = 16
+
+// RIPProcess represents the RIP routing process of a router.
+// RIP runs on all operational interfaces of the router.
+type RIPProcess struct {
+	ID             uint `json:"-" gorm:"primaryKey"`
+	RouterID       uint `json:"router_id" gorm:"uniqueIndex"`
+	Enabled        bool `json:"enabled"`
+	SplitHorizon   bool `json:"split_horizon"`
+	PoisonReverse  bool `json:"poison_reverse"`   // advertise routes back to their source as unreachable
+	HoldDownRounds int  `json:"hold_down_rounds"` // rounds an unreachable route ignores worse updates
+}
+
+// UpdateRIPRequest represents the request to configure the RIP process of a router
+type UpdateRIPRequest struct {
+	Enabled        *bool `json:"enabled"`
+	SplitHorizon   *bool `json:"split_horizon"`
+	PoisonReverse  *bool `json:"poison_reverse"`
+	HoldDownRounds *int  `json:"hold_down_rounds"`
+}
+
+// RIPRoute represents an entry of the RIP database of a router
+type RIPRoute struct {
+	ID             uint   `json:"-" gorm:"primaryKey"`
+	RouterID       uint   `json:"router_id"`
+	Prefix         string `json:"prefix"`
+	NextHop        string `json:"next_hop"`
+	Interface      string `json:"interface"`
+	NeighborID     uint   `json:"neighbor_id"` // router the route was learned from, 0 for connected networks
+	Metric         int    `json:"metric"`      // hop count
+	HoldDown       int    `json:"hold_down"`   // rounds left in hold-down
+	Flush          int    `json:"flush"`       // rounds left until an unreachable route is removed
+	PreviousMetric int    `json:"-"`           // metric before the route became unreachable
+}
+
+// RIPInfo represents the RIP process of a router together with its database
+type RIPInfo struct {
+	RIPProcess
+	Routes []RIPRoute `json:"routes"`
+}
+
+// RIPChangeAction represents a change of a RIP database entry
+type RIPChangeAction string
+
+const (
+	RIPChangeAdded       RIPChangeAction = "added"
+	RIPChangeUpdated     RIPChangeAction = "updated"
+	RIPChangeInvalidated RIPChangeAction = "invalidated"
+	RIPChangeFlushed     RIPChangeAction = "flushed"
+)
+
+// RIPConvergence represents a run of RIP update rounds triggered by a topology change
+type RIPConvergence struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	Rounds    int         `json:"rounds"`
+	Converged bool        `json:"converged"` // false when the round limit was reached
+	CreatedAt string      `json:"created_at"`
+	Changes   []RIPChange `json:"changes,omitempty" gorm:"foreignKey:ConvergenceID"`
+}
+
+// RIPChange represents a change of a router's RIP database during a round.
+// Round 0 holds changes caused directly by the topology change.
+type RIPChange struct {
+	ID            uint            `json:"-" gorm:"primaryKey"`
+	ConvergenceID uint            `json:"-"`
+	Round         int             `json:"round"`
+	RouterID      uint            `json:"router_id"`
+	Prefix        string          `json:"prefix"`
+	Action        RIPChangeAction `json:"action"`
+	OldMetric     int             `json:"old_metric"`
+	NewMetric     int             `json:"new_metric"`
+	NextHop       string          `json:"next_hop"`
+}
+
+// RIPRound represents the changes made during a single round
+type RIPRound struct {
+	Round   int         `json:"round"`
+	Changes []RIPChange `json:"changes"`
+}
+
+// RIPTimeline represents a convergence run grouped by rounds for replay
+type RIPTimeline struct {
+	RIPConvergence
+	Timeline []RIPRound `json:"timeline"`
+}
@@ -0,0 +1,42 @@
+package models
+
+// RouteProtocol represents the source a route was learned from
+type RouteProtocol string
+
+const (
+	RouteProtocolConnected RouteProtocol = "connected"
+	RouteProtocolStatic    RouteProtocol = "static"
+	RouteProtocolOSPF      RouteProtocol = "ospf"
+	RouteProtocolBGP       RouteProtocol = "bgp"
+	RouteProtocolRIP       RouteProtocol = "rip"
+)
+
+// Route represents an entry of a router's routing table
+type Route struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	RouterID      uint          `json:"router_id"`
+	Prefix        string        `json:"prefix"`   // CIDR, e.g. 10.0.0.0/24
+	NextHop       string        `json:"next_hop"` // empty for directly connected destinations
+	Interface     string        `json:"interface"`
+	Metric        int           `json:"metric"`
+	AdminDistance int           `json:"admin_distance"`
+	Protocol      RouteProtocol `json:"protocol" gorm:"default:'static'"`
+}
+
+// CreateRouteRequest represents the request to add a static route
+type CreateRouteRequest struct {
+	Prefix        string `json:"prefix"`
+	NextHop       string `json:"next_hop"`
+	Interface     string `json:"interface"`
+	Metric        int    `json:"metric"`
+	AdminDistance *int   `json:"admin_distance"`
+}
+
+// UpdateRouteRequest represents the request to modify a static route
+type UpdateRouteRequest struct {
+	Prefix        *string `json:"prefix"`
+	NextHop       *string `json:"next_hop"`
+	Interface     *string `json:"interface"`
+	Metric        *int    `json:"metric"`
+	AdminDistance *int    `json:"admin_distance"`
+}
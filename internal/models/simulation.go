@@ -0,0 +1,114 @@
+package models
+
+// SimulationConfig represents topology-wide simulation settings
+type SimulationConfig struct {
+	ID             uint     `json:"-" gorm:"primaryKey"`
+	Seed           *int64   `json:"seed"`            // nil means a time-based seed is picked per request
+	DetectionDelay *float64 `json:"detection_delay"` // virtual ms until routing reacts to a link change, default when nil
+}
+
+// UpdateSimulationConfigRequest represents the request to change simulation settings
+type UpdateSimulationConfigRequest struct {
+	Seed           *int64   `json:"seed"`
+	DetectionDelay *float64 `json:"detection_delay"`
+}
+
+// SimulationEventType represents the kind of a scheduled simulation event
+type SimulationEventType string
+
+const (
+	SimulationEventPacket   SimulationEventType = "packet"    // packet sent or arriving at a router
+	SimulationEventLinkDown SimulationEventType = "link-down" // connection failure
+	SimulationEventLinkUp   SimulationEventType = "link-up"   // connection restored
+	SimulationEventTimer    SimulationEventType = "timer"     // protocol timer expired
+)
+
+// SimulationTimer represents the protocol timer of a timer event
+type SimulationTimer string
+
+const (
+	SimulationTimerOSPFDead  SimulationTimer = "ospf-dead"  // OSPF neighbors declared down or up, SPF recomputed
+	SimulationTimerRIPUpdate SimulationTimer = "rip-update" // RIP update round: triggered or periodic updates, hold-down and flush
+	SimulationTimerBGPHold   SimulationTimer = "bgp-hold"   // BGP sessions over the changed connection reset, best paths recomputed
+)
+
+// SimulationEvent represents an event on the virtual timeline
+type SimulationEvent struct {
+	ID           uint64              `json:"id"`
+	Time         float64             `json:"time"` // virtual ms since reset
+	Type         SimulationEventType `json:"type"`
+	Timer        SimulationTimer     `json:"timer,omitempty"`
+	PacketID     uint                `json:"packet_id,omitempty"`
+	RouterID     uint                `json:"router_id,omitempty"`
+	ConnectionID uint                `json:"connection_id,omitempty"`
+	Result       string              `json:"result,omitempty"` // outcome, set once processed
+}
+
+// SimulationPacketStatus represents the state of a packet in the simulation
+type SimulationPacketStatus string
+
+const (
+	SimulationPacketScheduled SimulationPacketStatus = "scheduled"
+	SimulationPacketInFlight  SimulationPacketStatus = "in-flight"
+	SimulationPacketDelivered SimulationPacketStatus = "delivered"
+	SimulationPacketDropped   SimulationPacketStatus = "dropped"
+)
+
+// SimulationPacket represents a packet travelling through the topology over virtual time
+type SimulationPacket struct {
+	ID            uint                   `json:"id"`
+	SourceIP      string                 `json:"source_ip"`
+	DestinationIP string                 `json:"destination_ip"`
+	Protocol      string                 `json:"protocol"`
+	Port          int                    `json:"port"`
+	Size          int                    `json:"size"`    // bytes including headers
+	SentAt        float64                `json:"sent_at"` // virtual ms
+	Attempts      int                    `json:"attempts"`
+	Status        SimulationPacketStatus `json:"status"`
+	Latency       float64                `json:"latency"` // ms from sending to delivery or drop
+	Hops          []PacketHop            `json:"hops"`
+	Error         string                 `json:"error,omitempty"`
+}
+
+// SchedulePacketRequest represents the request to send packets at a virtual time
+type SchedulePacketRequest struct {
+	SourceIP      string   `json:"source_ip"`
+	DestinationIP string   `json:"destination_ip"`
+	Protocol      string   `json:"protocol"`
+	Port          int      `json:"port"`
+	Data          string   `json:"data"`
+	At            *float64 `json:"at"`       // virtual ms, current time when nil
+	Count         int      `json:"count"`    // number of packets, default 1
+	Interval      float64  `json:"interval"` // ms between packets, default 1000
+}
+
+// ScheduleFailureRequest represents the request to fail a connection at a virtual time
+type ScheduleFailureRequest struct {
+	ConnectionID uint     `json:"connection_id"`
+	At           *float64 `json:"at"`       // virtual ms, current time when nil
+	Duration     float64  `json:"duration"` // ms until the connection is restored, 0 keeps it down
+}
+
+type StepClockRequest struct {
+	Count int `json:"count"` // number of events to process, default 1
+}
+
+type RunClockRequest struct {
+	Time float64 `json:"time"` // virtual ms to advance the clock to
+}
+
+type StartClockRequest struct {
+	Speed float64 `json:"speed"` // virtual ms per wall ms, default 1
+}
+
+// SimulationClock represents the state of the virtual clock
+type SimulationClock struct {
+	Time      float64            `json:"time"` // virtual ms since reset
+	Running   bool               `json:"running"`
+	Speed     float64            `json:"speed"`
+	Seed      int64              `json:"seed"`
+	Processed int                `json:"processed"` // events processed since reset
+	Pending   []SimulationEvent  `json:"pending"`   // in order of processing
+	Log       []SimulationEvent  `json:"log"`       // most recent processed events
+	Packets   []SimulationPacket `json:"packets"`
+}
@@ -0,0 +1,119 @@
+package models
+
+// SwitchportMode represents the 802.1Q mode of a switch port
+type SwitchportMode string
+
+const (
+	SwitchportAccess SwitchportMode = "access" // untagged frames of a single VLAN
+	SwitchportTrunk  SwitchportMode = "trunk"  // tagged frames of the allowed VLANs
+)
+
+// SwitchConfig represents the layer-2 settings of a switch
+type SwitchConfig struct {
+	ID           uint `json:"-" gorm:"primaryKey"`
+	RouterID     uint `json:"switch_id" gorm:"uniqueIndex"`
+	MACAgingTime int  `json:"mac_aging_time"` // seconds, 0 disables aging
+}
+
+// UpdateMACTableRequest represents the request to configure the MAC address table
+type UpdateMACTableRequest struct {
+	MACAgingTime *int `json:"mac_aging_time"`
+}
+
+// MACEntry represents a MAC address learned on a switch port
+type MACEntry struct {
+	VLAN        int    `json:"vlan"`
+	MACAddress  string `json:"mac_address"`
+	InterfaceID uint   `json:"interface_id"`
+	Interface   string `json:"interface"`
+	Age         int    `json:"age"` // virtual seconds since the address was last seen
+}
+
+// MACTable represents the MAC address table of a switch
+type MACTable struct {
+	SwitchConfig
+	Entries []MACEntry `json:"entries"`
+}
+
+// VLANInfo represents a VLAN of a switch and the ports carrying it
+type VLANInfo struct {
+	VLAN        int      `json:"vlan"`
+	AccessPorts []string `json:"access_ports"`
+	TrunkPorts  []string `json:"trunk_ports"`
+}
+
+// FrameAction represents how a switch handled a frame
+type FrameAction string
+
+const (
+	FrameForwarded FrameAction = "forwarded" // destination MAC known, sent out of the learned port
+	FrameFlooded   FrameAction = "flooded"   // unknown unicast, sent out of all other ports of the VLAN
+)
+
+// STPMode represents the spanning tree protocol version run by a switch
+type STPMode string
+
+const (
+	STPModeSTP  STPMode = "stp"  // 802.1D, ports pass listening and learning before forwarding
+	STPModeRSTP STPMode = "rstp" // 802.1w, ports forward as soon as their role is known
+)
+
+// STPConfig represents the spanning tree settings of a switch
+type STPConfig struct {
+	ID             uint    `json:"-" gorm:"primaryKey"`
+	RouterID       uint    `json:"switch_id" gorm:"uniqueIndex"`
+	Mode           STPMode `json:"mode"`
+	BridgePriority int     `json:"bridge_priority"` // multiple of 4096, lower wins the root election
+	ForwardDelay   int     `json:"forward_delay"`   // seconds spent in each of the listening and learning states
+}
+
+// UpdateSTPRequest represents the request to configure spanning tree on a switch
+type UpdateSTPRequest struct {
+	Mode           *STPMode `json:"mode"`
+	BridgePriority *int     `json:"bridge_priority"`
+	ForwardDelay   *int     `json:"forward_delay"`
+}
+
+// STPPortRole represents the spanning tree role of a switch port
+type STPPortRole string
+
+const (
+	STPRoleRoot       STPPortRole = "root"       // best path to the root bridge
+	STPRoleDesignated STPPortRole = "designated" // best path from the segment to the root bridge
+	STPRoleAlternate  STPPortRole = "alternate"  // redundant path to the root bridge
+	STPRoleDisabled   STPPortRole = "disabled"   // port is down or not cabled
+)
+
+// STPPortState represents the spanning tree state of a switch port
+type STPPortState string
+
+const (
+	STPStateBlocking   STPPortState = "blocking"   // stp: drops frames
+	STPStateDiscarding STPPortState = "discarding" // rstp: drops frames
+	STPStateListening  STPPortState = "listening"  // stp: drops frames while the topology settles
+	STPStateLearning   STPPortState = "learning"   // stp: drops frames
+	STPStateForwarding STPPortState = "forwarding"
+	STPStateDisabled   STPPortState = "disabled"
+)
+
+// STPPort represents the spanning tree state of a switch port
+type STPPort struct {
+	InterfaceID uint         `json:"interface_id"`
+	Interface   string       `json:"interface"`
+	PortID      string       `json:"port_id"` // priority.number
+	Role        STPPortRole  `json:"role"`
+	State       STPPortState `json:"state"`
+	Cost        int          `json:"cost"`
+	NeighborID  uint         `json:"neighbor_id,omitempty"`
+}
+
+// STPInfo represents the spanning tree state of a switch
+type STPInfo struct {
+	STPConfig
+	BridgeID     string    `json:"bridge_id"` // priority.mac
+	RootID       string    `json:"root_id"`
+	RootPathCost int       `json:"root_path_cost"`
+	IsRoot       bool      `json:"is_root"`
+	RootPort     string    `json:"root_port,omitempty"`
+	Ports        []STPPort `json:"ports"`
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"fmt"
+	"network/internal/models"
+)
+
+func (r *DeviceRepository) GetACLEntries() ([]models.ACLEntry, error) {
+	var entries []models.ACLEntry
+	err := r.db.Order("router_id, name, sequence").Find(&entries).Error
+	return entries, err
+}
+
+func (r *DeviceRepository) GetACLEntriesByRouterID(routerID uint) ([]models.ACLEntry, error) {
+	var entries []models.ACLEntry
+	err := r.db.Where("router_id = ?", routerID).Order("name, sequence").Find(&entries).Error
+	return entries, err
+}
+
+func (r *DeviceRepository) CreateACLEntry(entry *models.ACLEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *DeviceRepository) DeleteACLEntry(routerID, id uint) error {
+	result := r.db.Where("router_id = ?", routerID).Delete(&models.ACLEntry{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("ACL entry %d not found", id)
+	}
+	return nil
+}
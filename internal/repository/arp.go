@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"network/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// GetARPConfig возвращает настройки ARP роутера; для роутера без настроек — запись с ID 0
+func (r *DeviceRepository) GetARPConfig(routerID uint) (*models.ARPConfig, error) {
+	var config models.ARPConfig
+	err := r.db.Where("router_id = ?", routerID).First(&config).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.ARPConfig{RouterID: routerID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (r *DeviceRepository) SaveARPConfig(config *models.ARPConfig) error {
+	return r.db.Save(config).Error
+}
+
+// GetStaticARPEntries возвращает статические записи ARP всех роутеров
+func (r *DeviceRepository) GetStaticARPEntries() ([]models.ARPEntry, error) {
+	var entries []models.ARPEntry
+	err := r.db.Order("id").Find(&entries).Error
+	return entries, err
+}
+
+func (r *DeviceRepository) GetStaticARPEntriesByRouterID(routerID uint) ([]models.ARPEntry, error) {
+	var entries []models.ARPEntry
+	err := r.db.Where("router_id = ?", routerID).Order("id").Find(&entries).Error
+	return entries, err
+}
+
+func (r *DeviceRepository) CreateARPEntry(entry *models.ARPEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *DeviceRepository) DeleteARPEntry(routerID, id uint) error {
+	result := r.db.Where("router_id = ?", routerID).Delete(&models.ARPEntry{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("ARP entry %d not found", id)
+	}
+	return nil
+}
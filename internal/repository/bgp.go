@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"network/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func (r *DeviceRepository) GetBGPProcesses() ([]models.BGPProcess, error) {
+	var processes []models.BGPProcess
+	err := r.db.Order("router_id").Find(&processes).Error
+	return processes, err
+}
+
+// GetBGPProcess возвращает BGP роутера; для роутера без настроек — выключенный процесс
+func (r *DeviceRepository) GetBGPProcess(routerID uint) (*models.BGPProcess, error) {
+	var process models.BGPProcess
+	err := r.db.Where("router_id = ?", routerID).First(&process).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.BGPProcess{RouterID: routerID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &process, nil
+}
+
+func (r *DeviceRepository) SaveBGPProcess(process *models.BGPProcess) error {
+	return r.db.Save(process).Error
+}
+
+func (r *DeviceRepository) BGPRouterIDExists(id string, excludeRouterID uint) bool {
+	var count int64
+	r.db.Model(&models.BGPProcess{}).Where("bgp_router_id = ? AND router_id != ?", id, excludeRouterID).Count(&count)
+	return count > 0
+}
+
+func (r *DeviceRepository) GetBGPNeighbors() ([]models.BGPNeighbor, error) {
+	var neighbors []models.BGPNeighbor
+	err := r.db.Order("id").Find(&neighbors).Error
+	return neighbors, err
+}
+
+func (r *DeviceRepository) GetBGPNeighborsByRouterID(routerID uint) ([]models.BGPNeighbor, error) {
+	var neighbors []models.BGPNeighbor
+	err := r.db.Where("router_id = ?", routerID).Order("id").Find(&neighbors).Error
+	return neighbors, err
+}
+
+func (r *DeviceRepository) CreateBGPNeighbor(neighbor *models.BGPNeighbor) error {
+	return r.db.Create(neighbor).Error
+}
+
+func (r *DeviceRepository) BGPNeighborExists(routerID, connectionID uint) bool {
+	var count int64
+	r.db.Model(&models.BGPNeighbor{}).Where("router_id = ? AND connection_id = ?", routerID, connectionID).Count(&count)
+	return count > 0
+}
+
+func (r *DeviceRepository) DeleteBGPNeighbor(routerID, id uint) error {
+	result := r.db.Where("router_id = ?", routerID).Delete(&models.BGPNeighbor{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("neighbor %d not found", id)
+	}
+	return nil
+}
+
+func (r *DeviceRepository) GetBGPNetworks() ([]models.BGPNetwork, error) {
+	var networks []models.BGPNetwork
+	err := r.db.Order("id").Find(&networks).Error
+	return networks, err
+}
+
+func (r *DeviceRepository) GetBGPNetworksByRouterID(routerID uint) ([]models.BGPNetwork, error) {
+	var networks []models.BGPNetwork
+	err := r.db.Where("router_id = ?", routerID).Order("id").Find(&networks).Error
+	return networks, err
+}
+
+func (r *DeviceRepository) CreateBGPNetwork(network *models.BGPNetwork) error {
+	return r.db.Create(network).Error
+}
+
+func (r *DeviceRepository) BGPNetworkExists(routerID uint, prefix string) bool {
+	var count int64
+	r.db.Model(&models.BGPNetwork{}).Where("router_id = ? AND prefix = ?", routerID, prefix).Count(&count)
+	return count > 0
+}
+
+func (r *DeviceRepository) DeleteBGPNetwork(routerID, id uint) error {
+	result := r.db.Where("router_id = ?", routerID).Delete(&models.BGPNetwork{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("network %d not found", id)
+	}
+	return nil
+}
+
+func (r *DeviceRepository) GetBGPRouteMaps() ([]models.BGPRouteMapEntry, error) {
+	var entries []models.BGPRouteMapEntry
+	err := r.db.Order("router_id, name, sequence").Find(&entries).Error
+	return entries, err
+}
+
+func (r *DeviceRepository) GetBGPRouteMapsByRouterID(routerID uint) ([]models.BGPRouteMapEntry, error) {
+	var entries []models.BGPRouteMapEntry
+	err := r.db.Where("router_id = ?", routerID).Order("name, sequence").Find(&entries).Error
+	return entries, err
+}
+
+func (r *DeviceRepository) CreateBGPRouteMapEntry(entry *models.BGPRouteMapEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *DeviceRepository) DeleteBGPRouteMapEntry(routerID, id uint) error {
+	result := r.db.Where("router_id = ?", routerID).Delete(&models.BGPRouteMapEntry{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("route-map entry %d not found", id)
+	}
+	return nil
+}
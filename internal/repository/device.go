@@ -23,40 +23,65 @@ func (r *DeviceRepository) CreateRouter(router *models.Router) error {
 
 func (r *DeviceRepository) GetRouterByID(id uint) (*models.Router, error) {
 	var router models.Router
-	if err := r.db.Preload("Ports").First(&router, id).Error; err != nil {
+	if err := r.db.Preload("Ports").Preload("Routes").Preload("Interfaces").First(&router, id).Error; err != nil {
 		return nil, err
 	}
 	return &router, nil
 }
 
+// GetRouterByIP ищет роутер по адресу управления или адресу одного из его интерфейсов.
+// Хост, получающий адрес только по DHCP, до аренды не имеет адреса и по пустому адресу не находится
 func (r *DeviceRepository) GetRouterByIP(ip string) (*models.Router, error) {
+	if ip == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
 	var router models.Router
-	if err := r.db.Preload("Ports").Where("ip_address = ?", ip).First(&router).Error; err != nil {
+	err := r.db.Preload("Ports").Preload("Routes").Preload("Interfaces").
+		Where("ip_address = ?", ip).
+		Or("id IN (?)", r.db.Model(&models.Interface{}).Select("router_id").Where("ipv4_address = ? OR ipv6_address = ?", ip, ip)).
+		First(&router).Error
+	if err != nil {
 		return nil, err
 	}
 	return &router, nil
 }
 
+// IsIPTaken проверяет, назначен ли адрес роутеру или интерфейсу
 func (r *DeviceRepository) IsIPTaken(ip string) bool {
+	if ip == "" {
+		return false
+	}
 	var count int64
 	r.db.Model(&models.Router{}).Where("ip_address = ?", ip).Count(&count)
-	return count > 0
+	return count > 0 || r.InterfaceIPExists(ip, 0)
 }
 
 func (r *DeviceRepository) UpdatePortStatus(routerID uint, portNumber int, status string) error {
-	router, err := r.GetRouterByID(routerID)
-	if err != nil {
-		return err
+	result := r.db.Model(&models.Port{}).
+		Where("router_id = ? AND port_number = ?", routerID, portNumber).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
 	}
-
-	for i, port := range router.Ports {
-		if port.PortNumber == portNumber {
-			router.Ports[i].Status = status
-			return r.UpdateRouter(router)
-		}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("port %d not found", portNumber)
 	}
+	return nil
+}
+
+// SavePort сохраняет новый или измененный порт роутера.
+// Изменения портов в router.Ports при сохранении роутера не записываются.
+func (r *DeviceRepository) SavePort(port *models.Port) error {
+	return r.db.Save(port).Error
+}
 
-	return fmt.Errorf("port %d not found", portNumber)
+// GetConnectedRouter возвращает первый подключенный роутер
+func (r *DeviceRepository) GetConnectedRouter() (*models.Router, error) {
+	var router models.Router
+	if err := r.db.Where("connected = ?", true).Order("id").First(&router).Error; err != nil {
+		return nil, err
+	}
+	return &router, nil
 }
 
 func (r *DeviceRepository) ConnectRouter(routerID uint) error {
@@ -73,7 +98,7 @@ func (r *DeviceRepository) DisconnectRouter(routerID uint) error {
 
 func (r *DeviceRepository) GetAllRouters() ([]models.Router, error) {
 	var routers []models.Router
-	if err := r.db.Preload("Ports").Find(&routers).Error; err != nil {
+	if err := r.db.Preload("Ports").Preload("Routes").Preload("Interfaces").Order("id").Find(&routers).Error; err != nil {
 		return nil, err
 	}
 	return routers, nil
@@ -89,22 +114,78 @@ func (r *DeviceRepository) UpdateRouter(router *models.Router) error {
 	return r.db.Updates(router).Error
 }
 
-func (r *DeviceRepository) ConnectionExists(routerFromID, routerToID uint) bool {
-	var count int64
-	r.db.Model(&models.RouterConnection{}).
-		Where("(router_from_id = ? AND router_to_id = ?) OR (router_from_id = ? AND router_to_id = ?)",
-			routerFromID, routerToID, routerToID, routerFromID).
-		Count(&count)
-	return count > 0
+// DeleteRouter удаляет роутер вместе с его портами, интерфейсами, маршрутами и соединениями
+// и освобождает выделенные ему адреса
+func (r *DeviceRepository) DeleteRouter(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("router_id = ?", id).Delete(&models.IPAllocation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("router_id = ?", id).Delete(&models.Port{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("router_id = ?", id).Delete(&models.Route{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("router_id = ?", id).Delete(&models.Interface{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("router_id = ?", id).Delete(&models.OSPFProcess{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.BGPProcess{}, &models.BGPNetwork{}, &models.BGPRouteMapEntry{}, &models.RIPProcess{}, &models.RIPRoute{}, &models.SwitchConfig{}, &models.STPConfig{}, &models.ARPConfig{}, &models.ARPEntry{}, &models.ACLEntry{}, &models.FirewallConfig{}, &models.NATRule{}, &models.DHCPPool{}, &models.DHCPLease{}} {
+			if err := tx.Where("router_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		// Аренды, полученные роутером как DHCP клиентом, освобождаются
+		if err := tx.Where("client_id = ?", id).Delete(&models.DHCPLease{}).Error; err != nil {
+			return err
+		}
+		// Сессии BGP соседей через соединения роутера также удаляются
+		connections := tx.Model(&models.RouterConnection{}).Select("id").Where("router_from_id = ? OR router_to_id = ?", id, id)
+		if err := tx.Where("router_id = ? OR connection_id IN (?)", id, connections).Delete(&models.BGPNeighbor{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("router_from_id = ? OR router_to_id = ?", id, id).Delete(&models.RouterConnection{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Router{}, id).Error
+	})
+}
+
+func (r *DeviceRepository) DeletePort(routerID uint, number int) error {
+	result := r.db.Where("router_id = ? AND number = ?", routerID, number).Delete(&models.Port{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("port %d not found", number)
+	}
+	return nil
 }
 
 func (r *DeviceRepository) CreateConnection(connection *models.RouterConnection) error {
 	return r.db.Create(connection).Error
 }
 
+func (r *DeviceRepository) UpdateConnection(connection *models.RouterConnection) error {
+	return r.db.Save(connection).Error
+}
+
+// DeleteConnection удаляет соединение вместе с настроенными через него сессиями BGP
+func (r *DeviceRepository) DeleteConnection(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("connection_id = ?", id).Delete(&models.BGPNeighbor{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.RouterConnection{}, id).Error
+	})
+}
+
 func (r *DeviceRepository) GetAllConnections() ([]models.RouterConnection, error) {
 	var connections []models.RouterConnection
-	err := r.db.Find(&connections).Error
+	err := r.db.Order("id").Find(&connections).Error
 	return connections, err
 }
 
@@ -115,12 +196,19 @@ func (r *DeviceRepository) GetConnectionByID(id uint) (*models.RouterConnection,
 }
 
 func (r *DeviceRepository) GetConnectionsByRouterIP(ip string) ([]models.RouterConnection, error) {
+	if ip == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
 	var router models.Router
 	if err := r.db.Where("ip_address = ?", ip).First(&router).Error; err != nil {
 		return nil, err
 	}
 
+	return r.GetConnectionsByRouterID(router.ID)
+}
+
+func (r *DeviceRepository) GetConnectionsByRouterID(id uint) ([]models.RouterConnection, error) {
 	var connections []models.RouterConnection
-	err := r.db.Where("router_from_id = ? OR router_to_id = ?", router.ID, router.ID).Find(&connections).Error
+	err := r.db.Where("router_from_id = ? OR router_to_id = ?", id, id).Find(&connections).Error
 	return connections, err
 }
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"fmt"
+	"network/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func (r *DeviceRepository) GetDHCPPools() ([]models.DHCPPool, error) {
+	var pools []models.DHCPPool
+	err := r.db.Order("router_id, id").Find(&pools).Error
+	return pools, err
+}
+
+func (r *DeviceRepository) GetDHCPPoolsByRouterID(routerID uint) ([]models.DHCPPool, error) {
+	var pools []models.DHCPPool
+	err := r.db.Where("router_id = ?", routerID).Order("id").Find(&pools).Error
+	return pools, err
+}
+
+func (r *DeviceRepository) CreateDHCPPool(pool *models.DHCPPool) error {
+	return r.db.Create(pool).Error
+}
+
+// DeleteDHCPPool удаляет пул вместе с его арендами
+func (r *DeviceRepository) DeleteDHCPPool(routerID, id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("router_id = ?", routerID).Delete(&models.DHCPPool{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("DHCP pool %d not found", id)
+		}
+		return tx.Where("pool_id = ?", id).Delete(&models.DHCPLease{}).Error
+	})
+}
+
+func (r *DeviceRepository) GetDHCPLeasesByPoolID(poolID uint) ([]models.DHCPLease, error) {
+	var leases []models.DHCPLease
+	err := r.db.Where("pool_id = ?", poolID).Order("id").Find(&leases).Error
+	return leases, err
+}
+
+func (r *DeviceRepository) GetDHCPLeasesByRouterID(routerID uint) ([]models.DHCPLease, error) {
+	var leases []models.DHCPLease
+	err := r.db.Where("router_id = ?", routerID).Order("pool_id, id").Find(&leases).Error
+	return leases, err
+}
+
+func (r *DeviceRepository) GetDHCPLeasesByInterfaceID(interfaceID uint) ([]models.DHCPLease, error) {
+	var leases []models.DHCPLease
+	err := r.db.Where("interface_id = ?", interfaceID).Order("id").Find(&leases).Error
+	return leases, err
+}
+
+func (r *DeviceRepository) SaveDHCPLease(lease *models.DHCPLease) error {
+	return r.db.Save(lease).Error
+}
+
+func (r *DeviceRepository) DeleteDHCPLease(routerID, id uint) error {
+	result := r.db.Where("router_id = ?", routerID).Delete(&models.DHCPLease{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("DHCP lease %d not found", id)
+	}
+	return nil
+}
+
+// ShiftDHCPLeases сдвигает время выдачи и окончания всех аренд на offset мс
+func (r *DeviceRepository) ShiftDHCPLeases(offset float64) error {
+	return r.db.Model(&models.DHCPLease{}).Where("1 = 1").Updates(map[string]interface{}{
+		"leased_at":  gorm.Expr("leased_at + ?", offset),
+		"expires_at": gorm.Expr("expires_at + ?", offset),
+	}).Error
+}
+
+// DeleteDHCPLeasesByInterfaceID удаляет аренды клиентского интерфейса
+func (r *DeviceRepository) DeleteDHCPLeasesByInterfaceID(interfaceID uint) error {
+	return r.db.Where("interface_id = ?", interfaceID).Delete(&models.DHCPLease{}).Error
+}
+
+// GetUnaddressedDHCPClients возвращает интерфейсы DHCP клиентов, еще не получившие адрес
+func (r *DeviceRepository) GetUnaddressedDHCPClients() ([]models.Interface, error) {
+	var interfaces []models.Interface
+	err := r.db.Where("dhcp_client = ? AND ipv4_address = ?", true, "").Order("id").Find(&interfaces).Error
+	return interfaces, err
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"errors"
+	"network/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func (r *DeviceRepository) GetFirewallConfigs() ([]models.FirewallConfig, error) {
+	var configs []models.FirewallConfig
+	err := r.db.Order("router_id").Find(&configs).Error
+	return configs, err
+}
+
+// GetFirewallConfig возвращает настройки межсетевого экрана роутера; для роутера без настроек — запись с ID 0
+func (r *DeviceRepository) GetFirewallConfig(routerID uint) (*models.FirewallConfig, error) {
+	var config models.FirewallConfig
+	err := r.db.Where("router_id = ?", routerID).First(&config).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.FirewallConfig{RouterID: routerID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (r *DeviceRepository) SaveFirewallConfig(config *models.FirewallConfig) error {
+	return r.db.Save(config).Error
+}
@@ -0,0 +1,97 @@
+package repository
+
+import "network/internal/models"
+
+func (r *DeviceRepository) CreateInterface(iface *models.Interface) error {
+	return r.db.Create(iface).Error
+}
+
+func (r *DeviceRepository) GetInterfacesByRouterID(routerID uint) ([]models.Interface, error) {
+	var interfaces []models.Interface
+	err := r.db.Where("router_id = ?", routerID).Order("id").Find(&interfaces).Error
+	return interfaces, err
+}
+
+func (r *DeviceRepository) GetInterface(routerID, id uint) (*models.Interface, error) {
+	var iface models.Interface
+	if err := r.db.Where("router_id = ?", routerID).First(&iface, id).Error; err != nil {
+		return nil, err
+	}
+	return &iface, nil
+}
+
+func (r *DeviceRepository) GetInterfaceByID(id uint) (*models.Interface, error) {
+	var iface models.Interface
+	if err := r.db.First(&iface, id).Error; err != nil {
+		return nil, err
+	}
+	return &iface, nil
+}
+
+func (r *DeviceRepository) GetInterfaceByName(routerID uint, name string) (*models.Interface, error) {
+	var iface models.Interface
+	if err := r.db.Where("router_id = ? AND name = ?", routerID, name).First(&iface).Error; err != nil {
+		return nil, err
+	}
+	return &iface, nil
+}
+
+func (r *DeviceRepository) GetInterfaceByIP(ip string) (*models.Interface, error) {
+	var iface models.Interface
+	if err := r.db.Where("ipv4_address = ? OR ipv6_address = ?", ip, ip).First(&iface).Error; err != nil {
+		return nil, err
+	}
+	return &iface, nil
+}
+
+// InterfaceNameExists проверяет, есть ли у роутера другой интерфейс с таким именем
+func (r *DeviceRepository) InterfaceNameExists(routerID uint, name string, excludeID uint) bool {
+	var count int64
+	r.db.Model(&models.Interface{}).
+		Where("router_id = ? AND name = ? AND id <> ?", routerID, name, excludeID).
+		Count(&count)
+	return count > 0
+}
+
+// InterfaceIPExists проверяет, назначен ли адрес другому интерфейсу
+func (r *DeviceRepository) InterfaceIPExists(ip string, excludeID uint) bool {
+	var count int64
+	r.db.Model(&models.Interface{}).
+		Where("(ipv4_address = ? OR ipv6_address = ?) AND id <> ?", ip, ip, excludeID).
+		Count(&count)
+	return count > 0
+}
+
+func (r *DeviceRepository) UpdateInterface(iface *models.Interface) error {
+	return r.db.Save(iface).Error
+}
+
+func (r *DeviceRepository) DeleteInterface(routerID, id uint) error {
+	return r.db.Where("router_id = ?", routerID).Delete(&models.Interface{}, id).Error
+}
+
+// GetConnectionByInterface возвращает соединение, подключенное к интерфейсу, или nil
+func (r *DeviceRepository) GetConnectionByInterface(interfaceID uint) (*models.RouterConnection, error) {
+	var connections []models.RouterConnection
+	err := r.db.Where("from_interface_id = ? OR to_interface_id = ?", interfaceID, interfaceID).
+		Limit(1).
+		Find(&connections).Error
+	if err != nil || len(connections) == 0 {
+		return nil, err
+	}
+	return &connections[0], nil
+}
+
+func (r *DeviceRepository) UpdateInterfaceOperStatus(id uint, status models.InterfaceStatus) error {
+	return r.db.Model(&models.Interface{}).
+		Where("id = ?", id).
+		Update("oper_status", status).Error
+}
+
+// GetSubinterfaces возвращает подынтерфейсы <parent>.<n> интерфейса роутера
+func (r *DeviceRepository) GetSubinterfaces(routerID uint, parent string) ([]models.Interface, error) {
+	var interfaces []models.Interface
+	err := r.db.Where("router_id = ? AND type = ? AND name LIKE ?", routerID, models.InterfaceTypeSubinterface, parent+".%").
+		Order("id").Find(&interfaces).Error
+	return interfaces, err
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"network/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type IPAMRepository struct {
+	db *gorm.DB
+}
+
+func NewIPAMRepository(db *gorm.DB) *IPAMRepository {
+	return &IPAMRepository{
+		db: db,
+	}
+}
+
+func (r *IPAMRepository) CreatePool(pool *models.IPPool) error {
+	return r.db.Create(pool).Error
+}
+
+func (r *IPAMRepository) GetPoolByID(id uint) (*models.IPPool, error) {
+	var pool models.IPPool
+	if err := r.db.First(&pool, id).Error; err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+func (r *IPAMRepository) GetPoolByName(name string) (*models.IPPool, error) {
+	var pool models.IPPool
+	if err := r.db.Where("name = ?", name).First(&pool).Error; err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+func (r *IPAMRepository) GetPoolByPurpose(purpose models.PoolPurpose) (*models.IPPool, error) {
+	var pool models.IPPool
+	if err := r.db.Where("purpose = ?", purpose).Order("id").First(&pool).Error; err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+func (r *IPAMRepository) GetAllPools() ([]models.IPPool, error) {
+	var pools []models.IPPool
+	err := r.db.Order("id").Find(&pools).Error
+	return pools, err
+}
+
+func (r *IPAMRepository) DeletePool(id uint) error {
+	return r.db.Delete(&models.IPPool{}, id).Error
+}
+
+func (r *IPAMRepository) CreateAllocation(allocation *models.IPAllocation) error {
+	return r.db.Create(allocation).Error
+}
+
+func (r *IPAMRepository) GetAllocationByID(id uint) (*models.IPAllocation, error) {
+	var allocation models.IPAllocation
+	if err := r.db.First(&allocation, id).Error; err != nil {
+		return nil, err
+	}
+	return &allocation, nil
+}
+
+func (r *IPAMRepository) GetAllocationsByPool(poolID uint) ([]models.IPAllocation, error) {
+	var allocations []models.IPAllocation
+	err := r.db.Where("pool_id = ?", poolID).Order("id").Find(&allocations).Error
+	return allocations, err
+}
+
+func (r *IPAMRepository) CountAllocations(poolID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.IPAllocation{}).Where("pool_id = ?", poolID).Count(&count).Error
+	return count, err
+}
+
+func (r *IPAMRepository) IsAllocated(address string) bool {
+	var count int64
+	r.db.Model(&models.IPAllocation{}).Where("address = ?", address).Count(&count)
+	return count > 0
+}
+
+func (r *IPAMRepository) AssignAllocation(id, routerID uint) error {
+	return r.db.Model(&models.IPAllocation{}).
+		Where("id = ?", id).
+		Update("router_id", routerID).Error
+}
+
+func (r *IPAMRepository) DeleteAllocation(id uint) error {
+	return r.db.Delete(&models.IPAllocation{}, id).Error
+}
+
+func (r *IPAMRepository) GetAllocationsByConnection(connectionID uint) ([]models.IPAllocation, error) {
+	var allocations []models.IPAllocation
+	err := r.db.Where("connection_id = ?", connectionID).Find(&allocations).Error
+	return allocations, err
+}
+
+func (r *IPAMRepository) DeleteAllocationsByConnection(connectionID uint) error {
+	return r.db.Where("connection_id = ?", connectionID).Delete(&models.IPAllocation{}).Error
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"fmt"
+	"network/internal/models"
+)
+
+func (r *DeviceRepository) GetNATRules() ([]models.NATRule, error) {
+	var rules []models.NATRule
+	err := r.db.Order("router_id, id").Find(&rules).Error
+	return rules, err
+}
+
+func (r *DeviceRepository) GetNATRulesByRouterID(routerID uint) ([]models.NATRule, error) {
+	var rules []models.NATRule
+	err := r.db.Where("router_id = ?", routerID).Order("id").Find(&rules).Error
+	return rules, err
+}
+
+func (r *DeviceRepository) CreateNATRule(rule *models.NATRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *DeviceRepository) DeleteNATRule(routerID, id uint) error {
+	result := r.db.Where("router_id = ?", routerID).Delete(&models.NATRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("NAT rule %d not found", id)
+	}
+	return nil
+}
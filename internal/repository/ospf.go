@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"errors"
+	"network/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func (r *DeviceRepository) GetOSPFProcesses() ([]models.OSPFProcess, error) {
+	var processes []models.OSPFProcess
+	err := r.db.Order("router_id").Find(&processes).Error
+	return processes, err
+}
+
+// GetOSPFProcess возвращает процесс OSPF роутера; для роутера без процесса — выключенный процесс
+func (r *DeviceRepository) GetOSPFProcess(routerID uint) (*models.OSPFProcess, error) {
+	var process models.OSPFProcess
+	err := r.db.Where("router_id = ?", routerID).First(&process).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.OSPFProcess{RouterID: routerID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &process, nil
+}
+
+func (r *DeviceRepository) SaveOSPFProcess(process *models.OSPFProcess) error {
+	return r.db.Save(process).Error
+}
+
+func (r *DeviceRepository) OSPFRouterIDExists(id string, excludeRouterID uint) bool {
+	var count int64
+	r.db.Model(&models.OSPFProcess{}).Where("ospf_router_id = ? AND router_id != ?", id, excludeRouterID).Count(&count)
+	return count > 0
+}
+
+// ReplaceRoutes заменяет все маршруты, полученные по протоколу, новым набором
+func (r *DeviceRepository) ReplaceRoutes(protocol models.RouteProtocol, routes []models.Route) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("protocol = ?", protocol).Delete(&models.Route{}).Error; err != nil {
+			return err
+		}
+		if len(routes) == 0 {
+			return nil
+		}
+		return tx.Create(&routes).Error
+	})
+}
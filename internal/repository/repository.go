@@ -4,10 +4,12 @@ import "gorm.io/gorm"
 
 type Repository struct {
 	Devices *DeviceRepository
+	IPAM    *IPAMRepository
 }
 
 func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{
 		Devices: NewDeviceRepository(db),
+		IPAM:    NewIPAMRepository(db),
 	}
 }
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"errors"
+	"network/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func (r *DeviceRepository) GetRIPProcesses() ([]models.RIPProcess, error) {
+	var processes []models.RIPProcess
+	err := r.db.Order("router_id").Find(&processes).Error
+	return processes, err
+}
+
+// GetRIPProcess возвращает процесс RIP роутера; для роутера без процесса — процесс с ID 0
+func (r *DeviceRepository) GetRIPProcess(routerID uint) (*models.RIPProcess, error) {
+	var process models.RIPProcess
+	err := r.db.Where("router_id = ?", routerID).First(&process).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.RIPProcess{RouterID: routerID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &process, nil
+}
+
+func (r *DeviceRepository) SaveRIPProcess(process *models.RIPProcess) error {
+	return r.db.Save(process).Error
+}
+
+func (r *DeviceRepository) GetRIPRoutes() ([]models.RIPRoute, error) {
+	var routes []models.RIPRoute
+	err := r.db.Order("router_id, prefix").Find(&routes).Error
+	return routes, err
+}
+
+func (r *DeviceRepository) GetRIPRoutesByRouterID(routerID uint) ([]models.RIPRoute, error) {
+	var routes []models.RIPRoute
+	err := r.db.Where("router_id = ?", routerID).Order("prefix").Find(&routes).Error
+	return routes, err
+}
+
+// SaveRIPState заменяет базы RIP всех роутеров и сохраняет запись о сходимости, если она есть
+func (r *DeviceRepository) SaveRIPState(routes []models.RIPRoute, convergence *models.RIPConvergence) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.RIPRoute{}).Error; err != nil {
+			return err
+		}
+		if len(routes) > 0 {
+			if err := tx.Create(&routes).Error; err != nil {
+				return err
+			}
+		}
+		if convergence != nil {
+			return tx.Create(convergence).Error
+		}
+		return nil
+	})
+}
+
+func (r *DeviceRepository) GetRIPConvergences() ([]models.RIPConvergence, error) {
+	var convergences []models.RIPConvergence
+	err := r.db.Order("id desc").Find(&convergences).Error
+	return convergences, err
+}
+
+func (r *DeviceRepository) GetRIPConvergence(id uint) (*models.RIPConvergence, error) {
+	var convergence models.RIPConvergence
+	if err := r.db.Preload("Changes").First(&convergence, id).Error; err != nil {
+		return nil, err
+	}
+	return &convergence, nil
+}
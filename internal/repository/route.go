@@ -0,0 +1,29 @@
+package repository
+
+import "network/internal/models"
+
+func (r *DeviceRepository) CreateRoute(route *models.Route) error {
+	return r.db.Create(route).Error
+}
+
+func (r *DeviceRepository) GetRoutesByRouterID(routerID uint) ([]models.Route, error) {
+	var routes []models.Route
+	err := r.db.Where("router_id = ?", routerID).Find(&routes).Error
+	return routes, err
+}
+
+func (r *DeviceRepository) GetRoute(routerID, routeID uint) (*models.Route, error) {
+	var route models.Route
+	if err := r.db.Where("router_id = ?", routerID).First(&route, routeID).Error; err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+func (r *DeviceRepository) UpdateRoute(route *models.Route) error {
+	return r.db.Save(route).Error
+}
+
+func (r *DeviceRepository) DeleteRoute(routerID, routeID uint) error {
+	return r.db.Where("router_id = ?", routerID).Delete(&models.Route{}, routeID).Error
+}
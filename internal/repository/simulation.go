@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"errors"
+	"network/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// simulationConfigID — идентификатор единственной записи настроек симуляции
+const simulationConfigID = 1
+
+func (r *DeviceRepository) GetSimulationConfig() (*models.SimulationConfig, error) {
+	var config models.SimulationConfig
+	err := r.db.First(&config, simulationConfigID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.SimulationConfig{ID: simulationConfigID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (r *DeviceRepository) SaveSimulationConfig(config *models.SimulationConfig) error {
+	config.ID = simulationConfigID
+	return r.db.Save(config).Error
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"errors"
+	"network/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func (r *DeviceRepository) GetSwitchConfigs() ([]models.SwitchConfig, error) {
+	var configs []models.SwitchConfig
+	err := r.db.Find(&configs).Error
+	return configs, err
+}
+
+// GetSwitchConfig возвращает настройки коммутатора; для коммутатора без настроек — запись с ID 0
+func (r *DeviceRepository) GetSwitchConfig(routerID uint) (*models.SwitchConfig, error) {
+	var config models.SwitchConfig
+	err := r.db.Where("router_id = ?", routerID).First(&config).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.SwitchConfig{RouterID: routerID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (r *DeviceRepository) SaveSwitchConfig(config *models.SwitchConfig) error {
+	return r.db.Save(config).Error
+}
+
+func (r *DeviceRepository) GetSTPConfigs() ([]models.STPConfig, error) {
+	var configs []models.STPConfig
+	err := r.db.Find(&configs).Error
+	return configs, err
+}
+
+// GetSTPConfig возвращает настройки STP коммутатора; для коммутатора без настроек — запись с ID 0
+func (r *DeviceRepository) GetSTPConfig(routerID uint) (*models.STPConfig, error) {
+	var config models.STPConfig
+	err := r.db.Where("router_id = ?", routerID).First(&config).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.STPConfig{RouterID: routerID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (r *DeviceRepository) SaveSTPConfig(config *models.STPConfig) error {
+	return r.db.Save(config).Error
+}
@@ -0,0 +1,391 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"network/internal/models"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultSourcePort — порт отправителя пакета, если он не задан (первый динамический порт)
+const defaultSourcePort = 49152
+
+// packetHeader — поля пакета, по которым выбираются записи ACL, соединения межсетевого экрана и трансляции NAT
+type packetHeader struct {
+	srcIP    string
+	dstIP    string
+	protocol string // tcp, udp или icmp
+	srcPort  int
+	dstPort  int
+	flags    string        // флаги сегмента TCP или тип сообщения ICMP echo
+	inner    *packetHeader // заголовок пакета, вызвавшего ошибку ICMP
+}
+
+// aclCounters — счетчики совпадений записей ACL. Как и на настоящих роутерах,
+// хранятся только в памяти и сбрасываются при перезапуске.
+type aclCounters struct {
+	mu   sync.Mutex
+	hits map[uint]int64
+}
+
+func newACLCounters() *aclCounters {
+	return &aclCounters{hits: make(map[uint]int64)}
+}
+
+func (c *aclCounters) hit(entryID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hits[entryID]++
+}
+
+func (c *aclCounters) get(entryID uint) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits[entryID]
+}
+
+func (c *aclCounters) reset(entryIDs ...uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range entryIDs {
+		delete(c.hits, id)
+	}
+}
+
+// aclAddress приводит адрес записи ACL к CIDR: одиночный адрес становится префиксом /32 или /128,
+// any — пустой строкой
+func aclAddress(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" || addr == "any" {
+		return "", nil
+	}
+	if !strings.Contains(addr, "/") {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return "", fmt.Errorf("invalid address: %s", addr)
+		}
+		if ip.To4() != nil {
+			return ip.String() + "/32", nil
+		}
+		return ip.String() + "/128", nil
+	}
+	return normalizePrefix(addr)
+}
+
+// validatePortRange проверяет диапазон портов записи ACL; верхняя граница по умолчанию равна нижней
+func validatePortRange(from, to *int) error {
+	if *to == 0 {
+		*to = *from
+	}
+	if *from < 0 || *to > 65535 || *from > *to {
+		return fmt.Errorf("invalid port range: %d-%d", *from, *to)
+	}
+	return nil
+}
+
+// validateACLEntry проверяет запись ACL и приводит ее поля к каноническому виду
+func validateACLEntry(entry *models.ACLEntry) error {
+	if entry.Name == "" {
+		return fmt.Errorf("ACL name is required")
+	}
+	if entry.Sequence <= 0 {
+		return fmt.Errorf("invalid sequence: %d", entry.Sequence)
+	}
+	switch entry.Action {
+	case models.ACLPermit, models.ACLDeny:
+	default:
+		return fmt.Errorf("invalid ACL action: %s", entry.Action)
+	}
+
+	entry.Protocol = strings.ToLower(entry.Protocol)
+	switch entry.Protocol {
+	case "":
+		entry.Protocol = "ip"
+	case "ip", "tcp", "udp", "icmp":
+	default:
+		return fmt.Errorf("invalid ACL protocol: %s (must be ip, tcp, udp or icmp)", entry.Protocol)
+	}
+
+	var err error
+	if entry.Source, err = aclAddress(entry.Source); err != nil {
+		return fmt.Errorf("invalid source: %w", err)
+	}
+	if entry.Destination, err = aclAddress(entry.Destination); err != nil {
+		return fmt.Errorf("invalid destination: %w", err)
+	}
+
+	if err := validatePortRange(&entry.SrcPortFrom, &entry.SrcPortTo); err != nil {
+		return fmt.Errorf("invalid source ports: %w", err)
+	}
+	if err := validatePortRange(&entry.DstPortFrom, &entry.DstPortTo); err != nil {
+		return fmt.Errorf("invalid destination ports: %w", err)
+	}
+	hasPorts := entry.SrcPortTo != 0 || entry.DstPortTo != 0
+	if hasPorts && entry.Protocol != "tcp" && entry.Protocol != "udp" {
+		return fmt.Errorf("ports can only be matched for tcp and udp")
+	}
+	return nil
+}
+
+// prefixContains проверяет, входит ли адрес в префикс записи ACL; пустой префикс совпадает с любым адресом
+func prefixContains(prefix, addr string) bool {
+	if prefix == "" {
+		return true
+	}
+	_, subnet, err := net.ParseCIDR(prefix)
+	ip := net.ParseIP(addr)
+	return err == nil && ip != nil && subnet.Contains(ip)
+}
+
+// portInRange проверяет порт по диапазону записи ACL; нулевой диапазон совпадает с любым портом
+func portInRange(port, from, to int) bool {
+	return to == 0 || port >= from && port <= to
+}
+
+// aclEntryMatches проверяет, совпадает ли пакет с записью ACL
+func aclEntryMatches(entry *models.ACLEntry, pkt packetHeader) bool {
+	if entry.Protocol != "ip" && entry.Protocol != pkt.protocol {
+		return false
+	}
+	if !prefixContains(entry.Source, pkt.srcIP) || !prefixContains(entry.Destination, pkt.dstIP) {
+		return false
+	}
+	return portInRange(pkt.srcPort, entry.SrcPortFrom, entry.SrcPortTo) &&
+		portInRange(pkt.dstPort, entry.DstPortFrom, entry.DstPortTo)
+}
+
+// checkACL проверяет пакет списком, примененным к интерфейсу iface устройства в направлении dir.
+// Возвращает совпавшую запись (nil, если список не применен) и ошибку, если пакет запрещен.
+// Пакет, не совпавший ни с одной записью, запрещается неявным правилом в конце списка.
+func (t *topology) checkACL(device *models.Router, iface *models.Interface, dir models.ACLDirection, pkt packetHeader) (*models.ACLMatch, error) {
+	if iface == nil {
+		return nil, nil
+	}
+	name := iface.InboundACL
+	if dir == models.ACLOutbound {
+		name = iface.OutboundACL
+	}
+	entries := t.acls[device.ID][name]
+	if name == "" || len(entries) == 0 {
+		return nil, nil
+	}
+
+	match := &models.ACLMatch{ACL: name, Interface: iface.Name, Direction: dir, Action: models.ACLDeny}
+	for i := range entries {
+		entry := &entries[i]
+		if !aclEntryMatches(entry, pkt) {
+			continue
+		}
+		if t.aclHits != nil {
+			t.aclHits.hit(entry.ID)
+		}
+		match.Sequence = entry.Sequence
+		match.Action = entry.Action
+		break
+	}
+	if match.Action == models.ACLPermit {
+		return match, nil
+	}
+	if match.Sequence == 0 {
+		return match, fmt.Errorf("packet denied by implicit deny of ACL %s %s on %s of %s", name, dir, iface.Name, device.IPAddress)
+	}
+	return match, fmt.Errorf("packet denied by ACL %s sequence %d %s on %s of %s", name, match.Sequence, dir, iface.Name, device.IPAddress)
+}
+
+// filterPacket проверяет пакет списком ACL интерфейса устройства на соединении conn
+// и добавляет совпавшую запись в переход hop этого устройства
+func (t *topology) filterPacket(hop *models.PacketHop, device *models.Router, conn *models.RouterConnection, dir models.ACLDirection, pkt packetHeader) error {
+	match, err := t.checkACL(device, connectionInterface(device, conn), dir, pkt)
+	if match != nil {
+		hop.ACL = append(hop.ACL, *match)
+	}
+	return err
+}
+
+// checkInterfaceACLs проверяет, что списки, применяемые к интерфейсу, существуют на устройстве
+func (s *DeviceService) checkInterfaceACLs(iface *models.Interface) error {
+	if iface.InboundACL == "" && iface.OutboundACL == "" {
+		return nil
+	}
+	entries, err := s.repo.GetACLEntriesByRouterID(iface.RouterID)
+	if err != nil {
+		return fmt.Errorf("failed to get ACLs: %w", err)
+	}
+	for _, name := range []string{iface.InboundACL, iface.OutboundACL} {
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, entry := range entries {
+			found = found || entry.Name == name
+		}
+		if !found {
+			return fmt.Errorf("ACL %s not found", name)
+		}
+	}
+	return nil
+}
+
+// getFilterDevice возвращает устройство, которое фильтрует пакеты: коммутаторы пакеты уровня 3 не проверяют
+func (s *DeviceService) getFilterDevice(routerID uint) (*models.Router, error) {
+	device, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	if device.Type == models.DeviceTypeSwitch {
+		return nil, fmt.Errorf("device %s is a switch and does not filter packets", device.IPAddress)
+	}
+	return device, nil
+}
+
+// GetACLs возвращает списки доступа роутера со счетчиками совпадений и интерфейсами, к которым они применены
+func (s *DeviceService) GetACLs(routerID uint) ([]models.ACL, error) {
+	device, err := s.getFilterDevice(routerID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.GetACLEntriesByRouterID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ACLs: %w", err)
+	}
+
+	acls := make(map[string]*models.ACL)
+	get := func(name string) *models.ACL {
+		if acls[name] == nil {
+			acls[name] = &models.ACL{Name: name, Entries: []models.ACLEntry{}, Interfaces: []models.ACLBinding{}}
+		}
+		return acls[name]
+	}
+	for _, entry := range entries {
+		entry.Hits = s.aclHits.get(entry.ID)
+		acl := get(entry.Name)
+		acl.Entries = append(acl.Entries, entry)
+	}
+	for _, iface := range device.Interfaces {
+		if acl := acls[iface.InboundACL]; acl != nil {
+			acl.Interfaces = append(acl.Interfaces, models.ACLBinding{Interface: iface.Name, Direction: models.ACLInbound})
+		}
+		if acl := acls[iface.OutboundACL]; acl != nil {
+			acl.Interfaces = append(acl.Interfaces, models.ACLBinding{Interface: iface.Name, Direction: models.ACLOutbound})
+		}
+	}
+
+	result := make([]models.ACL, 0, len(acls))
+	for _, acl := range acls {
+		result = append(result, *acl)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// CreateACLEntry добавляет запись в список доступа роутера; список создается с первой записью
+func (s *DeviceService) CreateACLEntry(routerID uint, req *models.CreateACLEntryRequest) (*models.ACLEntry, error) {
+	if _, err := s.getFilterDevice(routerID); err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.GetACLEntriesByRouterID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ACLs: %w", err)
+	}
+
+	entry := &models.ACLEntry{
+		RouterID:    routerID,
+		Name:        req.Name,
+		Sequence:    req.Sequence,
+		Action:      req.Action,
+		Protocol:    req.Protocol,
+		Source:      req.Source,
+		Destination: req.Destination,
+		SrcPortFrom: req.SrcPortFrom,
+		SrcPortTo:   req.SrcPortTo,
+		DstPortFrom: req.DstPortFrom,
+		DstPortTo:   req.DstPortTo,
+		Description: req.Description,
+	}
+	if entry.Action == "" {
+		entry.Action = models.ACLPermit
+	}
+
+	// Номер записи по умолчанию — следующий кратный 10
+	last := 0
+	for _, existing := range entries {
+		if existing.Name != entry.Name {
+			continue
+		}
+		if existing.Sequence == entry.Sequence {
+			return nil, fmt.Errorf("ACL %s sequence %d already exists", entry.Name, entry.Sequence)
+		}
+		if existing.Sequence > last {
+			last = existing.Sequence
+		}
+	}
+	if entry.Sequence == 0 {
+		entry.Sequence = last/10*10 + 10
+	}
+
+	if err := validateACLEntry(entry); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateACLEntry(entry); err != nil {
+		return nil, fmt.Errorf("failed to create ACL entry: %w", err)
+	}
+	return entry, nil
+}
+
+// DeleteACLEntry удаляет запись списка доступа. Последнюю запись списка,
+// примененного к интерфейсу, удалить нельзя.
+func (s *DeviceService) DeleteACLEntry(routerID, id uint) error {
+	device, err := s.getFilterDevice(routerID)
+	if err != nil {
+		return err
+	}
+	entries, err := s.repo.GetACLEntriesByRouterID(routerID)
+	if err != nil {
+		return fmt.Errorf("failed to get ACLs: %w", err)
+	}
+
+	var name string
+	count := 0
+	for _, entry := range entries {
+		if entry.ID == id {
+			name = entry.Name
+		}
+	}
+	for _, entry := range entries {
+		if name != "" && entry.Name == name {
+			count++
+		}
+	}
+	if count == 1 {
+		for _, iface := range device.Interfaces {
+			if iface.InboundACL == name || iface.OutboundACL == name {
+				return fmt.Errorf("ACL %s is applied to interface %s, remove it from the interface first", name, iface.Name)
+			}
+		}
+	}
+
+	if err := s.repo.DeleteACLEntry(routerID, id); err != nil {
+		return err
+	}
+	s.aclHits.reset(id)
+	return nil
+}
+
+// ClearACLCounters сбрасывает счетчики совпадений всех записей ACL роутера
+func (s *DeviceService) ClearACLCounters(routerID uint) error {
+	if _, err := s.getFilterDevice(routerID); err != nil {
+		return err
+	}
+	entries, err := s.repo.GetACLEntriesByRouterID(routerID)
+	if err != nil {
+		return fmt.Errorf("failed to get ACLs: %w", err)
+	}
+	ids := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	s.aclHits.reset(ids...)
+	return nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"network/internal/models"
+)
+
+// edgeTopology — внутренний роутер R1 (192.168.1.2), пограничный роутер R2 с внутренним
+// интерфейсом Gi0/0 192.168.1.1 и внешним Gi0/1 203.0.113.1 и внешний роутер R3 (203.0.113.3)
+type edgeTopology struct {
+	inside, edge, outside *models.Router
+}
+
+// mustCreateEdgeTopology создает пограничную топологию со статическими маршрутами через R2
+func mustCreateEdgeTopology(t *testing.T, s *DeviceService) edgeTopology {
+	t.Helper()
+	edge, err := s.CreateRouter(&models.CreateRouterRequest{
+		Name:      "R2",
+		IPAddress: "10.0.0.2",
+		Interfaces: []models.CreateInterfaceRequest{
+			{Name: "Gi0/0", IPv4Address: "192.168.1.1", IPv4PrefixLength: 24},
+			{Name: "Gi0/1", IPv4Address: "203.0.113.1", IPv4PrefixLength: 24},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create R2: %v", err)
+	}
+	inside := mustCreateLANRouter(t, s, "R1", "10.0.0.1", "192.168.1.2")
+	outside := mustCreateLANRouter(t, s, "R3", "10.0.0.3", "203.0.113.3")
+	mustCable(t, s, inside, "Gi0/0", edge, "Gi0/0")
+	mustCable(t, s, outside, "Gi0/0", edge, "Gi0/1")
+
+	for _, route := range []struct {
+		router  *models.Router
+		prefix  string
+		nextHop string
+	}{
+		{inside, "0.0.0.0/0", "192.168.1.1"},
+		{outside, "192.168.1.0/24", "203.0.113.1"},
+	} {
+		if _, err := s.CreateRoute(route.router.ID, &models.CreateRouteRequest{Prefix: route.prefix, NextHop: route.nextHop}); err != nil {
+			t.Fatalf("create route %s on %s: %v", route.prefix, route.router.Name, err)
+		}
+	}
+	for _, router := range []*models.Router{inside, outside} {
+		if err := s.repo.ConnectRouter(router.ID); err != nil {
+			t.Fatalf("connect router %s: %v", router.Name, err)
+		}
+	}
+
+	edge, err = s.repo.GetRouterByID(edge.ID)
+	if err != nil {
+		t.Fatalf("get R2: %v", err)
+	}
+	return edgeTopology{inside: inside, edge: edge, outside: outside}
+}
+
+// mustUpdateEdgeInterface изменяет интерфейс пограничного роутера
+func mustUpdateEdgeInterface(t *testing.T, s *DeviceService, edge *models.Router, name string, req *models.UpdateInterfaceRequest) {
+	t.Helper()
+	for _, iface := range edge.Interfaces {
+		if iface.Name == name {
+			if _, err := s.UpdateInterface(edge.ID, iface.ID, req); err != nil {
+				t.Fatalf("update %s of %s: %v", name, edge.Name, err)
+			}
+			return
+		}
+	}
+	t.Fatalf("interface %s of %s not found", name, edge.Name)
+}
+
+// mustSendTCP отправляет сегмент TCP на порт 80 и, если reply, моделирует ответ получателя
+func mustSendTCP(t *testing.T, s *DeviceService, source, dest string, reply bool) *models.PacketResponse {
+	t.Helper()
+	seed := int64(1)
+	response, err := s.SendPacket(&models.PacketRequest{
+		SourceIP:      source,
+		DestinationIP: dest,
+		Protocol:      "tcp",
+		Port:          80,
+		Reply:         reply,
+		Seed:          &seed,
+	})
+	if err != nil {
+		t.Fatalf("send %s -> %s: %v", source, dest, err)
+	}
+	return response
+}
+
+// hopOf возвращает переход пакета через устройство
+func hopOf(t *testing.T, hops []models.PacketHop, device *models.Router) models.PacketHop {
+	t.Helper()
+	for _, hop := range hops {
+		if hop.RouterID == device.ID {
+			return hop
+		}
+	}
+	t.Fatalf("path %+v does not cross %s", hops, device.Name)
+	return models.PacketHop{}
+}
+
+func TestInboundACLFiltersInOrder(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	topo := mustCreateEdgeTopology(t, s)
+
+	// Первое совпадение решает судьбу пакета: запрет для R3 стоит раньше общего разрешения
+	for _, req := range []models.CreateACLEntryRequest{
+		{Name: "EDGE-IN", Sequence: 20, Action: models.ACLPermit, Protocol: "tcp", Destination: "192.168.1.0/24", DstPortFrom: 80},
+		{Name: "EDGE-IN", Sequence: 10, Action: models.ACLDeny, Protocol: "ip", Source: "203.0.113.3/32", Destination: "192.168.1.2/32"},
+	} {
+		if _, err := s.CreateACLEntry(topo.edge.ID, &req); err != nil {
+			t.Fatalf("create ACL entry %d: %v", req.Sequence, err)
+		}
+	}
+	name := "EDGE-IN"
+	mustUpdateEdgeInterface(t, s, topo.edge, "Gi0/1", &models.UpdateInterfaceRequest{InboundACL: &name})
+
+	denied := mustSendTCP(t, s, "203.0.113.3", "192.168.1.2", false)
+	if denied.Status != "failed" || !strings.Contains(denied.Error, "sequence 10") {
+		t.Errorf("R3 -> R1 = %s (%s), want denied by sequence 10", denied.Status, denied.Error)
+	}
+	match := hopOf(t, denied.Hops, topo.edge).ACL
+	if len(match) != 1 || match[0].Sequence != 10 || match[0].Interface != "Gi0/1" || match[0].Direction != models.ACLInbound {
+		t.Errorf("ACL match on R2 = %+v, want sequence 10 inbound on Gi0/1", match)
+	}
+
+	// Пакет с другого адреса R3 проходит по второй записи, а не совпавший ни с одной — по неявному запрету
+	permitted := mustSendTCP(t, s, topo.outside.IPAddress, "192.168.1.1", false)
+	if permitted.Status != "success" {
+		t.Errorf("R3 10.0.0.3 -> R2 inside = %s (%s), want success", permitted.Status, permitted.Error)
+	}
+	if match := hopOf(t, permitted.Hops, topo.edge).ACL; len(match) != 1 || match[0].Sequence != 20 || match[0].Action != models.ACLPermit {
+		t.Errorf("ACL match on R2 = %+v, want permit by sequence 20", match)
+	}
+	implicit := mustSendTCP(t, s, topo.outside.IPAddress, "203.0.113.1", false)
+	if implicit.Status != "failed" || !strings.Contains(implicit.Error, "implicit deny") {
+		t.Errorf("R3 -> R2 outside = %s (%s), want the implicit deny", implicit.Status, implicit.Error)
+	}
+
+	// Счетчики считают совпадения каждой записи и сбрасываются
+	hits := func() map[int]int64 {
+		acls, err := s.GetACLs(topo.edge.ID)
+		if err != nil {
+			t.Fatalf("get ACLs: %v", err)
+		}
+		counts := make(map[int]int64)
+		for _, entry := range acls[0].Entries {
+			counts[entry.Sequence] = entry.Hits
+		}
+		return counts
+	}
+	if got := hits(); got[10] != 1 || got[20] != 1 {
+		t.Errorf("ACL hits = %v, want one per entry", got)
+	}
+	if err := s.ClearACLCounters(topo.edge.ID); err != nil {
+		t.Fatalf("clear counters: %v", err)
+	}
+	if got := hits(); got[10] != 0 || got[20] != 0 {
+		t.Errorf("ACL hits after clearing = %v, want none", got)
+	}
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"network/internal/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Время хранения динамических записей ARP и таблицы соседей
+const (
+	defaultARPTimeout = 14400 // с
+	minARPTimeout     = 10
+	maxARPTimeout     = 86400
+)
+
+// arpKey — адрес в кэше роутера
+type arpKey struct {
+	routerID uint
+	ip       string
+}
+
+// arpEntry — адрес, разрешенный роутером при пересылке пакета
+type arpEntry struct {
+	mac   string // пустой у незавершенной записи
+	iface string
+	seen  float64 // виртуальное время, мс
+}
+
+// arpCaches — кэши ARP (IPv4) и таблицы соседей (IPv6) роутеров.
+// Динамические записи, как и таблицы MAC-адресов, хранятся только в памяти
+// и устаревают по виртуальным часам симуляции.
+type arpCaches struct {
+	mu      sync.Mutex
+	clock   *simClock
+	entries map[arpKey]arpEntry
+}
+
+func newARPCaches(clock *simClock) *arpCaches {
+	return &arpCaches{clock: clock, entries: make(map[arpKey]arpEntry)}
+}
+
+// learn запоминает MAC-адрес соседа, ответившего на запрос
+func (c *arpCaches) learn(routerID uint, ip, mac, iface string) {
+	if ip == "" || mac == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[arpKey{routerID, ip}] = arpEntry{mac: mac, iface: iface, seen: c.clock.time()}
+}
+
+// incomplete отмечает адрес, на запрос которого никто не ответил
+func (c *arpCaches) incomplete(routerID uint, ip, iface string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[arpKey{routerID, ip}] = arpEntry{iface: iface, seen: c.clock.time()}
+}
+
+// list возвращает действующие записи роутера одного семейства адресов; устаревшие записи удаляются
+func (c *arpCaches) list(routerID uint, ipv6 bool, aging time.Duration) []models.ARPEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.time()
+	var entries []models.ARPEntry
+	for key, entry := range c.entries {
+		if key.routerID != routerID || isIPv6(key.ip) != ipv6 {
+			continue
+		}
+		age := now - entry.seen
+		if aging > 0 && age > float64(aging.Milliseconds()) {
+			delete(c.entries, key)
+			continue
+		}
+		typ := models.ARPEntryDynamic
+		if entry.mac == "" {
+			typ = models.ARPEntryIncomplete
+		}
+		entries = append(entries, models.ARPEntry{
+			RouterID:   routerID,
+			IPAddress:  key.ip,
+			MACAddress: entry.mac,
+			Interface:  entry.iface,
+			Type:       typ,
+			Age:        int(age / 1000),
+		})
+	}
+	return entries
+}
+
+// flush удаляет динамические записи роутера одного семейства адресов
+func (c *arpCaches) flush(routerID uint, ipv6 bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key.routerID == routerID && isIPv6(key.ip) == ipv6 {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// reset удаляет динамические записи всех роутеров при сбросе часов
+func (c *arpCaches) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[arpKey]arpEntry)
+}
+
+// flushRouter удаляет все записи роутера
+func (c *arpCaches) flushRouter(routerID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key.routerID == routerID {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func isIPv6(ip string) bool {
+	return strings.Contains(ip, ":")
+}
+
+// onLinkInterface возвращает работающий интерфейс, в подсети которого находится адрес.
+// Loopback не участвует в разрешении адресов.
+func onLinkInterface(device *models.Router, ip string) *models.Interface {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil
+	}
+	for i := range device.Interfaces {
+		iface := &device.Interfaces[i]
+		if iface.OperStatus != models.InterfaceStatusUp || iface.Type == models.InterfaceTypeLoopback {
+			continue
+		}
+		for _, prefix := range interfacePrefixes(iface) {
+			if _, subnet, err := net.ParseCIDR(prefix); err == nil && subnet.Contains(addr) {
+				return iface
+			}
+		}
+	}
+	return nil
+}
+
+// interfaceMAC возвращает MAC-адрес интерфейса; подынтерфейс использует адрес родительского интерфейса
+func interfaceMAC(device *models.Router, iface *models.Interface) string {
+	if iface == nil {
+		return ""
+	}
+	if iface.Type != models.InterfaceTypeSubinterface {
+		return iface.MACAddress
+	}
+	parent := parentInterfaceName(iface.Name)
+	for i := range device.Interfaces {
+		if device.Interfaces[i].Name == parent {
+			return device.Interfaces[i].MACAddress
+		}
+	}
+	return ""
+}
+
+// staticARP возвращает статическую запись роутера для адреса
+func (t *topology) staticARP(routerID uint, ip string) *models.ARPEntry {
+	for i := range t.staticEntries[routerID] {
+		if entry := &t.staticEntries[routerID][i]; entry.IPAddress == ip {
+			return entry
+		}
+	}
+	return nil
+}
+
+// resolveARP разрешает MAC-адрес next hop перед отправкой кадра через соединение conn.
+// Роутер запоминает MAC-адрес соседа, а сосед — адрес отправителя запроса.
+// Кадр, отправленный по статической записи с чужим MAC-адресом, до соседа не доходит.
+func (t *topology) resolveARP(current, next *models.Router, conn *models.RouterConnection, ip string) error {
+	local := connectionInterface(current, conn)
+	remote := connectionInterface(next, conn)
+	mac := interfaceMAC(next, remote)
+	if mac == "" {
+		// Соединения без интерфейсов не требуют разрешения адресов
+		return nil
+	}
+
+	if static := t.staticARP(current.ID, ip); static != nil {
+		if static.MACAddress != mac {
+			return fmt.Errorf("next hop %s unreachable: static ARP entry %s does not match %s", ip, static.MACAddress, mac)
+		}
+		return nil
+	}
+	if t.arp == nil || local == nil {
+		return nil
+	}
+	t.arp.learn(current.ID, ip, mac, local.Name)
+
+	// Запрос несет адрес отправителя того же семейства
+	sender := local.IPv4Address
+	if isIPv6(ip) {
+		sender = local.IPv6Address
+	}
+	if remote != nil && t.staticARP(next.ID, sender) == nil {
+		t.arp.learn(next.ID, sender, interfaceMAC(current, local), remote.Name)
+	}
+	return nil
+}
+
+// arpIncomplete записывает незавершенную запись для next hop в подсети интерфейса,
+// на запрос которого никто не ответил
+func (t *topology) arpIncomplete(current *models.Router, ip string) error {
+	iface := onLinkInterface(current, ip)
+	if iface == nil {
+		return nil
+	}
+	if t.arp != nil && t.staticARP(current.ID, ip) == nil {
+		t.arp.incomplete(current.ID, ip, iface.Name)
+	}
+	if isIPv6(ip) {
+		return fmt.Errorf("neighbor discovery incomplete for next hop %s on %s", ip, iface.Name)
+	}
+	return fmt.Errorf("ARP incomplete for next hop %s on %s", ip, iface.Name)
+}
+
+// arpConfig возвращает настройки ARP роутера; новый роутер получает значения по умолчанию
+func (s *DeviceService) arpConfig(routerID uint) (*models.ARPConfig, error) {
+	config, err := s.repo.GetARPConfig(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ARP config: %w", err)
+	}
+	if config.ID == 0 {
+		config.Timeout = defaultARPTimeout
+	}
+	return config, nil
+}
+
+// getARPDevice возвращает устройство с кэшем ARP: коммутаторы адреса уровня 3 не разрешают
+func (s *DeviceService) getARPDevice(routerID uint) (*models.Router, error) {
+	device, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	if err := requireRoutingTable(device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// GetARPTable возвращает кэш ARP (ipv6 = false) или таблицу соседей (ipv6 = true) роутера
+func (s *DeviceService) GetARPTable(routerID uint, ipv6 bool) (*models.ARPTable, error) {
+	if _, err := s.getARPDevice(routerID); err != nil {
+		return nil, err
+	}
+	config, err := s.arpConfig(routerID)
+	if err != nil {
+		return nil, err
+	}
+	static, err := s.repo.GetStaticARPEntriesByRouterID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get static ARP entries: %w", err)
+	}
+
+	table := &models.ARPTable{ARPConfig: *config, Entries: []models.ARPEntry{}}
+	staticIPs := make(map[string]bool, len(static))
+	for _, entry := range static {
+		if isIPv6(entry.IPAddress) == ipv6 {
+			table.Entries = append(table.Entries, entry)
+			staticIPs[entry.IPAddress] = true
+		}
+	}
+	aging := time.Duration(config.Timeout) * time.Second
+	for _, entry := range s.arp.list(routerID, ipv6, aging) {
+		if !staticIPs[entry.IPAddress] {
+			table.Entries = append(table.Entries, entry)
+		}
+	}
+	sort.SliceStable(table.Entries, func(i, j int) bool {
+		return bytes.Compare(net.ParseIP(table.Entries[i].IPAddress), net.ParseIP(table.Entries[j].IPAddress)) < 0
+	})
+	return table, nil
+}
+
+// UpdateARPConfig изменяет время хранения динамических записей ARP и таблицы соседей роутера
+func (s *DeviceService) UpdateARPConfig(routerID uint, req *models.UpdateARPConfigRequest) (*models.ARPTable, error) {
+	if _, err := s.getARPDevice(routerID); err != nil {
+		return nil, err
+	}
+	config, err := s.arpConfig(routerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Timeout != nil {
+		config.Timeout = *req.Timeout
+	}
+	if config.Timeout != 0 && (config.Timeout < minARPTimeout || config.Timeout > maxARPTimeout) {
+		return nil, fmt.Errorf("invalid ARP timeout: %d (must be 0 or %d-%d)", config.Timeout, minARPTimeout, maxARPTimeout)
+	}
+
+	if err := s.repo.SaveARPConfig(config); err != nil {
+		return nil, fmt.Errorf("failed to save ARP config: %w", err)
+	}
+	return s.GetARPTable(routerID, false)
+}
+
+// CreateARPEntry добавляет статическую запись в кэш ARP (ipv6 = false) или таблицу соседей (ipv6 = true)
+func (s *DeviceService) CreateARPEntry(routerID uint, ipv6 bool, req *models.CreateARPEntryRequest) (*models.ARPEntry, error) {
+	device, err := s.getARPDevice(routerID)
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(req.IPAddress)
+	if ip == nil || (ip.To4() == nil) != ipv6 {
+		if ipv6 {
+			return nil, fmt.Errorf("invalid IPv6 address: %s", req.IPAddress)
+		}
+		return nil, fmt.Errorf("invalid IPv4 address: %s", req.IPAddress)
+	}
+	mac, err := net.ParseMAC(req.MACAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAC address: %s", req.MACAddress)
+	}
+
+	// Интерфейс по умолчанию — тот, в подсети которого находится адрес
+	var iface *models.Interface
+	for i := range device.Interfaces {
+		if device.Interfaces[i].Name == req.Interface {
+			iface = &device.Interfaces[i]
+		}
+	}
+	if req.Interface == "" {
+		iface = onLinkInterface(device, ip.String())
+		if iface == nil {
+			return nil, fmt.Errorf("address %s is not in a subnet of the router interfaces", ip)
+		}
+	}
+	if iface == nil {
+		return nil, fmt.Errorf("interface %s not found", req.Interface)
+	}
+
+	existing, err := s.repo.GetStaticARPEntriesByRouterID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get static ARP entries: %w", err)
+	}
+	for _, entry := range existing {
+		if entry.IPAddress == ip.String() {
+			return nil, fmt.Errorf("static entry for %s already exists", ip)
+		}
+	}
+
+	entry := &models.ARPEntry{
+		RouterID:   routerID,
+		IPAddress:  ip.String(),
+		MACAddress: mac.String(),
+		Interface:  iface.Name,
+		Type:       models.ARPEntryStatic,
+	}
+	if err := s.repo.CreateARPEntry(entry); err != nil {
+		return nil, fmt.Errorf("failed to create ARP entry: %w", err)
+	}
+	return entry, nil
+}
+
+// DeleteARPEntry удаляет статическую запись ARP или таблицы соседей
+func (s *DeviceService) DeleteARPEntry(routerID, id uint) error {
+	if _, err := s.getARPDevice(routerID); err != nil {
+		return err
+	}
+	return s.repo.DeleteARPEntry(routerID, id)
+}
+
+// FlushARPTable удаляет динамические записи кэша ARP (ipv6 = false) или таблицы соседей (ipv6 = true).
+// Статические записи сохраняются.
+func (s *DeviceService) FlushARPTable(routerID uint, ipv6 bool) error {
+	if _, err := s.getARPDevice(routerID); err != nil {
+		return err
+	}
+	s.arp.flush(routerID, ipv6)
+	return nil
+}
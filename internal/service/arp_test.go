@@ -0,0 +1,85 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"network/internal/models"
+)
+
+// arpEntries возвращает записи кэша ARP роутера по адресу
+func arpEntries(t *testing.T, s *DeviceService, router *models.Router) map[string]models.ARPEntry {
+	t.Helper()
+	table, err := s.GetARPTable(router.ID, false)
+	if err != nil {
+		t.Fatalf("get ARP table of %s: %v", router.Name, err)
+	}
+	entries := make(map[string]models.ARPEntry, len(table.Entries))
+	for _, entry := range table.Entries {
+		entries[entry.IPAddress] = entry
+	}
+	return entries
+}
+
+func TestARPResolvesAndAgesNeighbors(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	r1 := mustCreateLANRouter(t, s, "R1", "10.0.0.1", "192.168.10.1")
+	r2 := mustCreateLANRouter(t, s, "R2", "10.0.0.2", "192.168.10.2")
+	mustCable(t, s, r1, "Gi0/0", r2, "Gi0/0")
+
+	// Отправитель разрешает адрес соседа, а сосед запоминает отправителя запроса.
+	// На адрес без устройства никто не отвечает, и запись остается незавершенной.
+	delivered := mustSchedulePacket(t, s, r1.IPAddress, "192.168.10.2", 0)
+	unanswered := mustSchedulePacket(t, s, r1.IPAddress, "192.168.10.9", 0)
+	mustRunClock(t, s, 1000)
+	if got := clockPacket(t, s, delivered.ID); got.Status != models.SimulationPacketDelivered {
+		t.Fatalf("packet to R2 = %s (%s), want delivered", got.Status, got.Error)
+	}
+	if got := clockPacket(t, s, unanswered.ID); got.Status != models.SimulationPacketDropped || !strings.Contains(got.Error, "ARP incomplete") {
+		t.Errorf("packet to 192.168.10.9 = %s (%s), want dropped with ARP incomplete", got.Status, got.Error)
+	}
+
+	r1MAC, r2MAC := r1.Interfaces[0].MACAddress, r2.Interfaces[0].MACAddress
+	entries := arpEntries(t, s, r1)
+	if entry := entries["192.168.10.2"]; entry.Type != models.ARPEntryDynamic || entry.MACAddress != r2MAC || entry.Interface != "Gi0/0" {
+		t.Errorf("R1 entry for 192.168.10.2 = %+v, want dynamic %s on Gi0/0", entry, r2MAC)
+	}
+	if entry := entries["192.168.10.9"]; entry.Type != models.ARPEntryIncomplete {
+		t.Errorf("R1 entry for 192.168.10.9 = %+v, want incomplete", entry)
+	}
+	if entry := arpEntries(t, s, r2)["192.168.10.1"]; entry.MACAddress != r1MAC {
+		t.Errorf("R2 entry for 192.168.10.1 = %+v, want %s", entry, r1MAC)
+	}
+
+	// Записи R1 устаревают по виртуальным часам, записи R2 с временем по умолчанию остаются
+	timeout := minARPTimeout
+	if _, err := s.UpdateARPConfig(r1.ID, &models.UpdateARPConfigRequest{Timeout: &timeout}); err != nil {
+		t.Fatalf("set ARP timeout: %v", err)
+	}
+	mustRunClock(t, s, float64(timeout*1000)-1)
+	if entries := arpEntries(t, s, r1); len(entries) != 2 {
+		t.Errorf("R1 entries before the timeout = %+v, want 2", entries)
+	}
+	// Незавершенная запись обновлялась повторными передачами TCP до 600 мс
+	mustRunClock(t, s, float64(timeout*1000)+1000)
+	if entries := arpEntries(t, s, r1); len(entries) != 0 {
+		t.Errorf("R1 entries after the timeout = %+v, want none", entries)
+	}
+	if entries := arpEntries(t, s, r2); len(entries) != 1 {
+		t.Errorf("R2 entries = %+v, want the entry for R1", entries)
+	}
+
+	// Статическая запись с чужим MAC-адресом не дает кадру дойти до соседа
+	if _, err := s.CreateARPEntry(r1.ID, false, &models.CreateARPEntryRequest{
+		IPAddress:  "192.168.10.2",
+		MACAddress: "02:aa:bb:cc:dd:ee",
+	}); err != nil {
+		t.Fatalf("create static entry: %v", err)
+	}
+	misdirected := mustSchedulePacket(t, s, r1.IPAddress, "192.168.10.2", float64(timeout*1000)+1100)
+	mustRunClock(t, s, float64(timeout*1000)+3000)
+	if got := clockPacket(t, s, misdirected.ID); got.Status != models.SimulationPacketDropped || !strings.Contains(got.Error, "static ARP entry") {
+		t.Errorf("packet over a wrong static entry = %s (%s), want dropped by the static entry", got.Status, got.Error)
+	}
+}
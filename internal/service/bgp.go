@@ -0,0 +1,603 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"network/internal/models"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Параметры BGP
+const (
+	ebgpDistance        = 20
+	ibgpDistance        = 200
+	defaultLocalPref    = 100
+	bgpOriginIGP        = "igp"
+	bgpOriginEGP        = "egp"
+	bgpOriginIncomplete = "incomplete"
+	maxASPathPrepend    = 10
+	// maxBGPRounds ограничивает число раундов обмена анонсами при расходящейся политике
+	maxBGPRounds = 64
+)
+
+// bgpRoute — путь BGP вместе с сессией, через которую он получен
+type bgpRoute struct {
+	models.BGPPath
+	session *bgpSession // nil для собственных анонсов
+}
+
+// bgpSpeaker — роутер с включенным BGP
+type bgpSpeaker struct {
+	router    *models.Router
+	process   *models.BGPProcess
+	id        string // BGP router ID
+	sessions  []*bgpSession
+	networks  []string
+	routeMaps map[string][]models.BGPRouteMapEntry
+	igp       []models.Route // маршруты для проверки достижимости next hop
+	adjRIBIn  map[uint][]models.BGPPath
+	locRIB    map[string]*bgpRoute
+}
+
+// bgpSession — установленная сессия со стороны одного из соседей
+type bgpSession struct {
+	config   *models.BGPNeighbor
+	local    *bgpSpeaker
+	peer     *bgpSpeaker
+	reverse  *bgpSession
+	localIP  string
+	peerIP   string
+	iface    string // локальный интерфейс соединения
+	external bool
+}
+
+// bgpDomain — состояние BGP во всей топологии
+type bgpDomain struct {
+	t         *topology
+	speakers  map[uint]*bgpSpeaker
+	order     []uint
+	neighbors map[uint][]models.BGPNeighborInfo
+}
+
+// connectionEnd возвращает адрес и интерфейс роутера на соединении.
+// Если интерфейс не адресован, используется адрес роутера.
+func connectionEnd(router *models.Router, conn *models.RouterConnection) (string, string) {
+	ifaceID := conn.FromInterfaceID
+	if conn.RouterToID == router.ID {
+		ifaceID = conn.ToInterfaceID
+	}
+	iface := findInterface(router, ifaceID)
+	if iface == nil {
+		return router.IPAddress, ""
+	}
+	if iface.IPv4Address == "" {
+		return router.IPAddress, iface.Name
+	}
+	return iface.IPv4Address, iface.Name
+}
+
+// asPathString возвращает AS path в виде "65001 65002"
+func asPathString(path []uint32) string {
+	parts := make([]string, len(path))
+	for i, as := range path {
+		parts[i] = fmt.Sprint(as)
+	}
+	return strings.Join(parts, " ")
+}
+
+// routeMapMatches проверяет условия записи route-map; запись без условий совпадает с любым путем
+func routeMapMatches(entry *models.BGPRouteMapEntry, path *models.BGPPath) bool {
+	if entry.MatchPrefix != "" {
+		_, match, err := net.ParseCIDR(entry.MatchPrefix)
+		if err != nil {
+			return false
+		}
+		_, prefix, err := net.ParseCIDR(path.Prefix)
+		if err != nil {
+			return false
+		}
+		matchLen, _ := match.Mask.Size()
+		prefixLen, _ := prefix.Mask.Size()
+		if !match.Contains(prefix.IP) || prefixLen < matchLen {
+			return false
+		}
+	}
+	if entry.MatchASPath != "" {
+		matched, err := regexp.MatchString(entry.MatchASPath, asPathString(path.ASPath))
+		if err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// applyRouteMap применяет route-map к пути. Без route-map путь принимается без изменений;
+// путь, не совпавший ни с одной записью, отбрасывается.
+func (sp *bgpSpeaker) applyRouteMap(name string, path *models.BGPPath) bool {
+	if name == "" {
+		return true
+	}
+	for i := range sp.routeMaps[name] {
+		entry := &sp.routeMaps[name][i]
+		if !routeMapMatches(entry, path) {
+			continue
+		}
+		if entry.Action == models.RouteMapDeny {
+			return false
+		}
+		if entry.SetLocalPref != nil {
+			path.LocalPref = *entry.SetLocalPref
+		}
+		if entry.SetMED != nil {
+			path.MED = *entry.SetMED
+		}
+		if entry.SetASPathPrepend > 0 {
+			// Повторяется первая AS пути, для собственных анонсов — своя AS
+			as := sp.process.ASN
+			if len(path.ASPath) > 0 {
+				as = path.ASPath[0]
+			}
+			prepend := make([]uint32, entry.SetASPathPrepend, entry.SetASPathPrepend+len(path.ASPath))
+			for j := range prepend {
+				prepend[j] = as
+			}
+			path.ASPath = append(prepend, path.ASPath...)
+		}
+		return true
+	}
+	return false
+}
+
+// originated возвращает пути к собственным анонсируемым сетям
+func (sp *bgpSpeaker) originated() map[string]*bgpRoute {
+	routes := make(map[string]*bgpRoute, len(sp.networks))
+	for _, prefix := range sp.networks {
+		routes[prefix] = &bgpRoute{BGPPath: models.BGPPath{
+			Prefix:    prefix,
+			ASPath:    []uint32{},
+			LocalPref: defaultLocalPref,
+			Origin:    bgpOriginIGP,
+			Source:    models.BGPPathLocal,
+		}}
+	}
+	return routes
+}
+
+// nextHopReachable проверяет, достижим ли next hop пути без учета маршрутов BGP
+func (d *bgpDomain) nextHopReachable(sp *bgpSpeaker, nextHop string) bool {
+	if next := d.t.byIP[nextHop]; next != nil && d.t.linkBetween(sp.router.ID, next.ID) != nil {
+		return true
+	}
+	ip := net.ParseIP(nextHop)
+	return ip != nil && lookupRoute(sp.igp, ip) != nil
+}
+
+// advertise возвращает пути из Loc-RIB отправителя, анонсируемые соседу по сессии
+func (s *bgpSession) advertise() []models.BGPPath {
+	sender := s.local
+	prefixes := make([]string, 0, len(sender.locRIB))
+	for prefix := range sender.locRIB {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	var paths []models.BGPPath
+	for _, prefix := range prefixes {
+		route := sender.locRIB[prefix]
+		if route.session != nil {
+			// Путь не возвращается соседу, от которого получен
+			if route.session.peer == s.peer {
+				continue
+			}
+			// Пути iBGP не передаются другим соседям iBGP
+			if !route.session.external && !s.external {
+				continue
+			}
+		}
+
+		path := route.BGPPath
+		path.ASPath = append([]uint32(nil), route.ASPath...)
+		path.Status = ""
+		if s.external {
+			path.ASPath = append([]uint32{sender.process.ASN}, path.ASPath...)
+			path.NextHop = s.localIP
+			// Local preference не передается в другую AS, MED — дальше соседней AS
+			path.LocalPref = 0
+			path.MED = 0
+		} else if route.session == nil || s.config.NextHopSelf {
+			path.NextHop = s.localIP
+		}
+
+		if sender.applyRouteMap(s.config.RouteMapOut, &path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// receive обрабатывает полученный по сессии путь: проверку петли AS, входящую
+// route-map и достижимость next hop. Возвращает принятый путь или nil.
+func (d *bgpDomain) receive(s *bgpSession, path *models.BGPPath) *bgpRoute {
+	sp := s.local
+	path.Peer = s.peerIP
+	path.Source = models.BGPPathInternal
+	if s.external {
+		path.Source = models.BGPPathExternal
+		path.LocalPref = defaultLocalPref
+		for _, as := range path.ASPath {
+			if as == sp.process.ASN {
+				path.Status = models.BGPPathASLoop
+				return nil
+			}
+		}
+	}
+
+	accepted := *path
+	accepted.ASPath = append([]uint32(nil), path.ASPath...)
+	if !sp.applyRouteMap(s.config.RouteMapIn, &accepted) {
+		path.Status = models.BGPPathDenied
+		return nil
+	}
+	if !d.nextHopReachable(sp, accepted.NextHop) {
+		path.Status = models.BGPPathNextHopUnreachable
+		return nil
+	}
+	path.Status = models.BGPPathAccepted
+	return &bgpRoute{BGPPath: accepted, session: s}
+}
+
+// originRank возвращает предпочтение атрибута origin: IGP, затем EGP, затем incomplete
+func originRank(origin string) int {
+	switch origin {
+	case bgpOriginIGP:
+		return 0
+	case bgpOriginEGP:
+		return 1
+	}
+	return 2
+}
+
+// betterPath сравнивает пути по процессу выбора лучшего пути BGP: local preference,
+// собственный анонс, длина AS path, origin, MED (для путей из одной соседней AS),
+// eBGP перед iBGP, меньший BGP router ID соседа
+func betterPath(a, b *bgpRoute) bool {
+	if a.LocalPref != b.LocalPref {
+		return a.LocalPref > b.LocalPref
+	}
+	if (a.session == nil) != (b.session == nil) {
+		return a.session == nil
+	}
+	if len(a.ASPath) != len(b.ASPath) {
+		return len(a.ASPath) < len(b.ASPath)
+	}
+	if ra, rb := originRank(a.Origin), originRank(b.Origin); ra != rb {
+		return ra < rb
+	}
+	if len(a.ASPath) > 0 && a.ASPath[0] == b.ASPath[0] && a.MED != b.MED {
+		return a.MED < b.MED
+	}
+	if a.Source != b.Source {
+		return a.Source == models.BGPPathExternal
+	}
+	if a.session == nil || b.session == nil {
+		return false
+	}
+	if cmp := bytes.Compare(net.ParseIP(a.session.peer.id).To16(), net.ParseIP(b.session.peer.id).To16()); cmp != 0 {
+		return cmp < 0
+	}
+	return a.Peer < b.Peer
+}
+
+// pathKey возвращает строковое представление пути для обнаружения изменений Loc-RIB
+func pathKey(route *bgpRoute) string {
+	return fmt.Sprintf("%s|%v|%d|%d|%s|%s", route.NextHop, route.ASPath, route.LocalPref, route.MED, route.Source, route.Peer)
+}
+
+func sameRIB(a, b map[string]*bgpRoute) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for prefix, route := range a {
+		other, ok := b[prefix]
+		if !ok || pathKey(route) != pathKey(other) {
+			return false
+		}
+	}
+	return true
+}
+
+// buildBGPDomain определяет состояние сессий BGP и вычисляет лучшие пути всех роутеров.
+// В BGP участвуют включенные процессы активных роутеров с заданным номером AS.
+func buildBGPDomain(t *topology, connections []models.RouterConnection, processes []models.BGPProcess,
+	neighbors []models.BGPNeighbor, networks []models.BGPNetwork, routeMaps []models.BGPRouteMapEntry) *bgpDomain {
+	d := &bgpDomain{
+		t:         t,
+		speakers:  make(map[uint]*bgpSpeaker),
+		neighbors: make(map[uint][]models.BGPNeighborInfo),
+	}
+
+	for i := range processes {
+		process := &processes[i]
+		router := t.routers[process.RouterID]
+		if !process.Enabled || process.ASN == 0 || router == nil || router.Status != "active" {
+			continue
+		}
+		sp := &bgpSpeaker{
+			router:    router,
+			process:   process,
+			id:        process.BGPRouterID,
+			routeMaps: make(map[string][]models.BGPRouteMapEntry),
+			adjRIBIn:  make(map[uint][]models.BGPPath),
+		}
+		if sp.id == "" {
+			sp.id = router.IPAddress
+		}
+		for _, route := range t.routesOf(router.ID) {
+			if route.Protocol != models.RouteProtocolBGP {
+				sp.igp = append(sp.igp, route)
+			}
+		}
+		d.speakers[router.ID] = sp
+		d.order = append(d.order, router.ID)
+	}
+	sort.Slice(d.order, func(i, j int) bool { return d.order[i] < d.order[j] })
+
+	for _, network := range networks {
+		if sp, ok := d.speakers[network.RouterID]; ok {
+			sp.networks = append(sp.networks, network.Prefix)
+		}
+	}
+	for _, entry := range routeMaps {
+		if sp, ok := d.speakers[entry.RouterID]; ok {
+			sp.routeMaps[entry.Name] = append(sp.routeMaps[entry.Name], entry)
+		}
+	}
+
+	d.connect(connections, neighbors)
+	d.converge()
+	return d
+}
+
+// connect определяет состояние настроенных соседств и создает установленные сессии
+func (d *bgpDomain) connect(connections []models.RouterConnection, neighbors []models.BGPNeighbor) {
+	byConnection := make(map[uint]*models.RouterConnection, len(connections))
+	for i := range connections {
+		byConnection[connections[i].ID] = &connections[i]
+	}
+	configured := make(map[[2]uint]*models.BGPNeighbor, len(neighbors))
+	for i := range neighbors {
+		configured[[2]uint{neighbors[i].RouterID, neighbors[i].ConnectionID}] = &neighbors[i]
+	}
+	sessions := make(map[[2]uint]*bgpSession)
+
+	for i := range neighbors {
+		config := &neighbors[i]
+		conn := byConnection[config.ConnectionID]
+		router := d.t.routers[config.RouterID]
+		if conn == nil || router == nil {
+			continue
+		}
+		peerID := conn.RouterToID
+		if peerID == config.RouterID {
+			peerID = conn.RouterFromID
+		}
+
+		info := models.BGPNeighborInfo{
+			BGPNeighbor:  *config,
+			PeerDeviceID: peerID,
+			Type:         models.BGPSessionExternal,
+			State:        models.BGPSessionIdle,
+		}
+		sp, peer := d.speakers[config.RouterID], d.speakers[peerID]
+		if sp != nil && config.RemoteAS == sp.process.ASN {
+			info.Type = models.BGPSessionInternal
+		}
+		peerRouter := d.t.routers[peerID]
+		if peerRouter != nil {
+			info.PeerIP, _ = connectionEnd(peerRouter, conn)
+		}
+
+		reverse := configured[[2]uint{peerID, config.ConnectionID}]
+		switch {
+		case sp == nil || peer == nil || d.t.connections[conn.ID] == nil:
+		case reverse == nil || config.RemoteAS != peer.process.ASN || reverse.RemoteAS != sp.process.ASN:
+			info.State = models.BGPSessionActive
+		default:
+			info.State = models.BGPSessionEstablished
+			session := &bgpSession{
+				config:   config,
+				local:    sp,
+				peer:     peer,
+				peerIP:   info.PeerIP,
+				external: sp.process.ASN != peer.process.ASN,
+			}
+			session.localIP, session.iface = connectionEnd(router, conn)
+			sp.sessions = append(sp.sessions, session)
+			sessions[[2]uint{config.RouterID, conn.ID}] = session
+			if other, ok := sessions[[2]uint{peerID, conn.ID}]; ok {
+				session.reverse, other.reverse = other, session
+			}
+		}
+		d.neighbors[config.RouterID] = append(d.neighbors[config.RouterID], info)
+	}
+}
+
+// converge обменивается анонсами между соседями раундами до тех пор,
+// пока Loc-RIB всех роутеров не перестанут меняться
+func (d *bgpDomain) converge() {
+	for _, id := range d.order {
+		d.speakers[id].locRIB = d.speakers[id].originated()
+	}
+
+	for round := 0; round < maxBGPRounds; round++ {
+		// Все роутеры получают анонсы, отправленные по Loc-RIB предыдущего раунда
+		received := make(map[*bgpSession][]models.BGPPath)
+		for _, id := range d.order {
+			for _, session := range d.speakers[id].sessions {
+				received[session] = session.reverse.advertise()
+			}
+		}
+
+		changed := false
+		for _, id := range d.order {
+			sp := d.speakers[id]
+			locRIB := sp.originated()
+			for _, session := range sp.sessions {
+				paths := received[session]
+				for i := range paths {
+					route := d.receive(session, &paths[i])
+					if route == nil {
+						continue
+					}
+					if best, ok := locRIB[route.Prefix]; !ok || betterPath(route, best) {
+						locRIB[route.Prefix] = route
+					}
+				}
+				sp.adjRIBIn[session.config.ID] = paths
+			}
+			if !sameRIB(sp.locRIB, locRIB) {
+				changed = true
+			}
+			sp.locRIB = locRIB
+		}
+		if !changed {
+			return
+		}
+	}
+}
+
+// routes возвращает лучшие пути роутера для установки в таблицу маршрутизации
+func (d *bgpDomain) routes(routerID uint) []models.Route {
+	sp := d.speakers[routerID]
+	connected := make(map[string]bool)
+	for _, route := range connectedRoutes(sp.router) {
+		connected[route.Prefix] = true
+	}
+
+	var routes []models.Route
+	for _, path := range d.locRIB(routerID) {
+		route := sp.locRIB[path.Prefix]
+		if route.session == nil || connected[path.Prefix] {
+			continue
+		}
+		installed := models.Route{
+			RouterID:      routerID,
+			Prefix:        path.Prefix,
+			NextHop:       path.NextHop,
+			Metric:        int(path.MED),
+			AdminDistance: ibgpDistance,
+			Protocol:      models.RouteProtocolBGP,
+		}
+		if route.session.external {
+			installed.Interface = route.session.iface
+			installed.AdminDistance = ebgpDistance
+		}
+		routes = append(routes, installed)
+	}
+	return routes
+}
+
+// locRIB возвращает лучшие пути роутера, упорядоченные по префиксу
+func (d *bgpDomain) locRIB(routerID uint) []models.BGPPath {
+	sp, ok := d.speakers[routerID]
+	if !ok {
+		return []models.BGPPath{}
+	}
+	paths := make([]models.BGPPath, 0, len(sp.locRIB))
+	for _, route := range sp.locRIB {
+		paths = append(paths, route.BGPPath)
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].Prefix < paths[j].Prefix })
+	return paths
+}
+
+// adjRIBIn возвращает пути, полученные роутером от каждого соседа, с результатом их обработки
+func (d *bgpDomain) adjRIBIn(routerID uint) []models.BGPAdjRIBIn {
+	sp, ok := d.speakers[routerID]
+	if !ok {
+		return []models.BGPAdjRIBIn{}
+	}
+	ribs := make([]models.BGPAdjRIBIn, 0, len(sp.sessions))
+	for _, session := range sp.sessions {
+		routes := sp.adjRIBIn[session.config.ID]
+		if routes == nil {
+			routes = []models.BGPPath{}
+		}
+		ribs = append(ribs, models.BGPAdjRIBIn{
+			NeighborID: session.config.ID,
+			Peer:       session.peerIP,
+			Routes:     routes,
+		})
+	}
+	return ribs
+}
+
+// bgpDomain вычисляет состояние BGP по текущей топологии
+func (s *DeviceService) bgpDomain() (*bgpDomain, error) {
+	t, err := s.loadTopology()
+	if err != nil {
+		return nil, err
+	}
+	connections, err := s.repo.GetAllConnections()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connections: %w", err)
+	}
+	processes, err := s.repo.GetBGPProcesses()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get BGP processes: %w", err)
+	}
+	neighbors, err := s.repo.GetBGPNeighbors()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get BGP neighbors: %w", err)
+	}
+	networks, err := s.repo.GetBGPNetworks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get BGP networks: %w", err)
+	}
+	routeMaps, err := s.repo.GetBGPRouteMaps()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get route-maps: %w", err)
+	}
+	return buildBGPDomain(t, connections, processes, neighbors, networks, routeMaps), nil
+}
+
+// recomputeBGP пересчитывает лучшие пути BGP и заменяет ими ранее установленные маршруты
+func (s *DeviceService) recomputeBGP() error {
+	domain, err := s.bgpDomain()
+	if err != nil {
+		return err
+	}
+
+	var routes []models.Route
+	for _, id := range domain.order {
+		routes = append(routes, domain.routes(id)...)
+	}
+	return s.repo.ReplaceRoutes(models.RouteProtocolBGP, routes)
+}
+
+// GetBGPLocRIB возвращает лучшие пути роутера
+func (s *DeviceService) GetBGPLocRIB(routerID uint) ([]models.BGPPath, error) {
+	if _, err := s.repo.GetRouterByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	domain, err := s.bgpDomain()
+	if err != nil {
+		return nil, err
+	}
+	return domain.locRIB(routerID), nil
+}
+
+// GetBGPAdjRIBIn возвращает пути, полученные роутером от соседей
+func (s *DeviceService) GetBGPAdjRIBIn(routerID uint) ([]models.BGPAdjRIBIn, error) {
+	if _, err := s.repo.GetRouterByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	domain, err := s.bgpDomain()
+	if err != nil {
+		return nil, err
+	}
+	return domain.adjRIBIn(routerID), nil
+}
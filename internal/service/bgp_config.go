@@ -0,0 +1,274 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"network/internal/models"
+	"regexp"
+)
+
+// GetBGP возвращает настройки BGP роутера и состояние его сессий
+func (s *DeviceService) GetBGP(routerID uint) (*models.BGPInfo, error) {
+	router, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	process, err := s.repo.GetBGPProcess(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get BGP process: %w", err)
+	}
+	networks, err := s.repo.GetBGPNetworksByRouterID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get BGP networks: %w", err)
+	}
+	routeMaps, err := s.repo.GetBGPRouteMapsByRouterID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get route-maps: %w", err)
+	}
+	domain, err := s.bgpDomain()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &models.BGPInfo{
+		BGPProcess: *process,
+		Neighbors:  domain.neighbors[routerID],
+		Networks:   networks,
+		RouteMaps:  routeMaps,
+	}
+	if info.BGPRouterID == "" {
+		info.BGPRouterID = router.IPAddress
+	}
+	ribs := domain.adjRIBIn(routerID)
+	for i := range info.Neighbors {
+		neighbor := &info.Neighbors[i]
+		for _, rib := range ribs {
+			if rib.NeighborID != neighbor.ID {
+				continue
+			}
+			neighbor.PrefixesReceived = len(rib.Routes)
+			for _, path := range rib.Routes {
+				if path.Status == models.BGPPathAccepted {
+					neighbor.PrefixesAccepted++
+				}
+			}
+		}
+	}
+	if info.Neighbors == nil {
+		info.Neighbors = []models.BGPNeighborInfo{}
+	}
+	return info, nil
+}
+
+// UpdateBGP изменяет номер AS и настройки BGP роутера
+func (s *DeviceService) UpdateBGP(routerID uint, req *models.UpdateBGPRequest) (*models.BGPInfo, error) {
+	router, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	if err := requireRouter(router); err != nil {
+		return nil, err
+	}
+	process, err := s.repo.GetBGPProcess(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get BGP process: %w", err)
+	}
+
+	if req.Enabled != nil {
+		process.Enabled = *req.Enabled
+	}
+	if req.ASN != nil {
+		process.ASN = *req.ASN
+	}
+	if req.BGPRouterID != nil {
+		process.BGPRouterID = *req.BGPRouterID
+	}
+
+	if process.Enabled && process.ASN == 0 {
+		return nil, fmt.Errorf("AS number is required to enable BGP")
+	}
+	if process.BGPRouterID != "" {
+		ip := net.ParseIP(process.BGPRouterID).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("invalid BGP router ID: %s", process.BGPRouterID)
+		}
+		process.BGPRouterID = ip.String()
+		if s.repo.BGPRouterIDExists(process.BGPRouterID, routerID) {
+			return nil, fmt.Errorf("BGP router ID %s is already in use", process.BGPRouterID)
+		}
+	}
+
+	if err := s.repo.SaveBGPProcess(process); err != nil {
+		return nil, fmt.Errorf("failed to save BGP process: %w", err)
+	}
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
+	return s.GetBGP(routerID)
+}
+
+// CreateBGPNeighbor настраивает сессию BGP с соседом на другом конце соединения
+func (s *DeviceService) CreateBGPNeighbor(routerID uint, req *models.CreateBGPNeighborRequest) (*models.BGPNeighbor, error) {
+	if _, err := s.repo.GetRouterByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	conn, err := s.repo.GetConnectionByID(req.ConnectionID)
+	if err != nil {
+		return nil, fmt.Errorf("connection not found: %w", err)
+	}
+	if conn.RouterFromID != routerID && conn.RouterToID != routerID {
+		return nil, fmt.Errorf("connection %d does not belong to router %d", conn.ID, routerID)
+	}
+	if req.RemoteAS == 0 {
+		return nil, fmt.Errorf("remote_as is required")
+	}
+	if s.repo.BGPNeighborExists(routerID, conn.ID) {
+		return nil, fmt.Errorf("neighbor over connection %d already exists", conn.ID)
+	}
+
+	neighbor := &models.BGPNeighbor{
+		RouterID:     routerID,
+		ConnectionID: conn.ID,
+		RemoteAS:     req.RemoteAS,
+		NextHopSelf:  req.NextHopSelf,
+		RouteMapIn:   req.RouteMapIn,
+		RouteMapOut:  req.RouteMapOut,
+		Description:  req.Description,
+	}
+	if err := s.repo.CreateBGPNeighbor(neighbor); err != nil {
+		return nil, fmt.Errorf("failed to create neighbor: %w", err)
+	}
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
+	return neighbor, nil
+}
+
+func (s *DeviceService) DeleteBGPNeighbor(routerID, id uint) error {
+	if err := s.repo.DeleteBGPNeighbor(routerID, id); err != nil {
+		return err
+	}
+	return s.reconverge()
+}
+
+// CreateBGPNetwork добавляет префикс, анонсируемый роутером
+func (s *DeviceService) CreateBGPNetwork(routerID uint, req *models.CreateBGPNetworkRequest) (*models.BGPNetwork, error) {
+	if _, err := s.repo.GetRouterByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	prefix, err := normalizePrefix(req.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	if s.repo.BGPNetworkExists(routerID, prefix) {
+		return nil, fmt.Errorf("network %s is already announced", prefix)
+	}
+
+	network := &models.BGPNetwork{RouterID: routerID, Prefix: prefix}
+	if err := s.repo.CreateBGPNetwork(network); err != nil {
+		return nil, fmt.Errorf("failed to create network: %w", err)
+	}
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
+	return network, nil
+}
+
+func (s *DeviceService) DeleteBGPNetwork(routerID, id uint) error {
+	if err := s.repo.DeleteBGPNetwork(routerID, id); err != nil {
+		return err
+	}
+	return s.reconverge()
+}
+
+// validateRouteMapEntry проверяет запись route-map
+func validateRouteMapEntry(entry *models.BGPRouteMapEntry) error {
+	if entry.Name == "" {
+		return fmt.Errorf("route-map name is required")
+	}
+	if entry.Sequence < 1 {
+		return fmt.Errorf("invalid sequence: %d", entry.Sequence)
+	}
+	switch entry.Action {
+	case models.RouteMapPermit, models.RouteMapDeny:
+	default:
+		return fmt.Errorf("invalid action: %s", entry.Action)
+	}
+	if entry.MatchPrefix != "" {
+		prefix, err := normalizePrefix(entry.MatchPrefix)
+		if err != nil {
+			return err
+		}
+		entry.MatchPrefix = prefix
+	}
+	if entry.MatchASPath != "" {
+		if _, err := regexp.Compile(entry.MatchASPath); err != nil {
+			return fmt.Errorf("invalid AS path expression: %w", err)
+		}
+	}
+	if entry.SetASPathPrepend < 0 || entry.SetASPathPrepend > maxASPathPrepend {
+		return fmt.Errorf("invalid AS path prepend: %d (must be 0-%d)", entry.SetASPathPrepend, maxASPathPrepend)
+	}
+	return nil
+}
+
+// CreateBGPRouteMapEntry добавляет запись в route-map роутера
+func (s *DeviceService) CreateBGPRouteMapEntry(routerID uint, req *models.CreateBGPRouteMapEntryRequest) (*models.BGPRouteMapEntry, error) {
+	if _, err := s.repo.GetRouterByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	entries, err := s.repo.GetBGPRouteMapsByRouterID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get route-maps: %w", err)
+	}
+
+	entry := &models.BGPRouteMapEntry{
+		RouterID:         routerID,
+		Name:             req.Name,
+		Sequence:         req.Sequence,
+		Action:           req.Action,
+		MatchPrefix:      req.MatchPrefix,
+		MatchASPath:      req.MatchASPath,
+		SetLocalPref:     req.SetLocalPref,
+		SetMED:           req.SetMED,
+		SetASPathPrepend: req.SetASPathPrepend,
+	}
+	if entry.Action == "" {
+		entry.Action = models.RouteMapPermit
+	}
+
+	// Номер записи по умолчанию — следующий кратный 10
+	last := 0
+	for _, existing := range entries {
+		if existing.Name != entry.Name {
+			continue
+		}
+		if existing.Sequence == entry.Sequence {
+			return nil, fmt.Errorf("route-map %s sequence %d already exists", entry.Name, entry.Sequence)
+		}
+		if existing.Sequence > last {
+			last = existing.Sequence
+		}
+	}
+	if entry.Sequence == 0 {
+		entry.Sequence = last/10*10 + 10
+	}
+
+	if err := validateRouteMapEntry(entry); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateBGPRouteMapEntry(entry); err != nil {
+		return nil, fmt.Errorf("failed to create route-map entry: %w", err)
+	}
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *DeviceService) DeleteBGPRouteMapEntry(routerID, id uint) error {
+	if err := s.repo.DeleteBGPRouteMapEntry(routerID, id); err != nil {
+		return err
+	}
+	return s.reconverge()
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"network/internal/models"
+)
+
+// bgpTestRoute возвращает путь, полученный от соседа с BGP router ID peerID
+func bgpTestRoute(peerID string, external bool, path models.BGPPath) *bgpRoute {
+	path.Source = models.BGPPathInternal
+	if external {
+		path.Source = models.BGPPathExternal
+	}
+	peer := &bgpSpeaker{id: peerID}
+	return &bgpRoute{BGPPath: path, session: &bgpSession{peer: peer, peerIP: peerID, external: external}}
+}
+
+func TestBetterPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		best, worse *bgpRoute
+	}{
+		{
+			name:  "higher local preference beats shorter AS path",
+			best:  bgpTestRoute("2.2.2.2", true, models.BGPPath{LocalPref: 200, ASPath: []uint32{65002, 65010, 65020}, Origin: bgpOriginIGP}),
+			worse: bgpTestRoute("1.1.1.1", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65003}, Origin: bgpOriginIGP}),
+		},
+		{
+			name:  "shorter AS path beats better origin",
+			best:  bgpTestRoute("2.2.2.2", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65002}, Origin: bgpOriginIncomplete}),
+			worse: bgpTestRoute("1.1.1.1", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65003, 65010}, Origin: bgpOriginIGP}),
+		},
+		{
+			name:  "IGP origin beats lower MED",
+			best:  bgpTestRoute("2.2.2.2", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65002}, Origin: bgpOriginIGP, MED: 100}),
+			worse: bgpTestRoute("1.1.1.1", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65002}, Origin: bgpOriginEGP, MED: 0}),
+		},
+		{
+			name:  "EGP origin beats incomplete",
+			best:  bgpTestRoute("2.2.2.2", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65002}, Origin: bgpOriginEGP}),
+			worse: bgpTestRoute("1.1.1.1", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65002}, Origin: bgpOriginIncomplete}),
+		},
+		{
+			name:  "lower MED from the same AS beats lower router ID",
+			best:  bgpTestRoute("2.2.2.2", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65002, 65010}, Origin: bgpOriginIGP, MED: 10}),
+			worse: bgpTestRoute("1.1.1.1", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65002, 65020}, Origin: bgpOriginIGP, MED: 20}),
+		},
+		{
+			name:  "MED from different AS is not compared",
+			best:  bgpTestRoute("1.1.1.1", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65002}, Origin: bgpOriginIGP, MED: 50}),
+			worse: bgpTestRoute("2.2.2.2", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65003}, Origin: bgpOriginIGP, MED: 10}),
+		},
+		{
+			name:  "lower router ID",
+			best:  bgpTestRoute("9.0.0.1", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65002}, Origin: bgpOriginIGP}),
+			worse: bgpTestRoute("10.0.0.1", true, models.BGPPath{LocalPref: 100, ASPath: []uint32{65003}, Origin: bgpOriginIGP}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !betterPath(tt.best, tt.worse) {
+				t.Errorf("path from %s is not better than path from %s", tt.best.session.peer.id, tt.worse.session.peer.id)
+			}
+			if betterPath(tt.worse, tt.best) {
+				t.Errorf("path from %s is better than path from %s", tt.worse.session.peer.id, tt.best.session.peer.id)
+			}
+		})
+	}
+}
+
+func TestApplyRouteMap(t *testing.T) {
+	localPref := uint32(200)
+	med := uint32(50)
+	sp := &bgpSpeaker{
+		process: &models.BGPProcess{ASN: 65001},
+		routeMaps: map[string][]models.BGPRouteMapEntry{
+			"FILTER": {
+				{Sequence: 10, Action: models.RouteMapDeny, MatchPrefix: "10.1.0.0/16"},
+				{Sequence: 20, Action: models.RouteMapPermit, MatchPrefix: "10.0.0.0/8", SetLocalPref: &localPref},
+			},
+			"TRANSIT": {
+				{Sequence: 10, Action: models.RouteMapPermit, MatchASPath: "^65002 ", SetMED: &med},
+			},
+			"PREPEND": {
+				{Sequence: 10, Action: models.RouteMapPermit, SetASPathPrepend: 2},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		routeMap string
+		path     models.BGPPath
+		permit   bool
+		want     models.BGPPath
+	}{
+		{
+			name:   "no route-map",
+			path:   models.BGPPath{Prefix: "10.1.1.0/24", LocalPref: 100},
+			permit: true,
+			want:   models.BGPPath{Prefix: "10.1.1.0/24", LocalPref: 100},
+		},
+		{
+			name:     "first matching entry denies",
+			routeMap: "FILTER",
+			path:     models.BGPPath{Prefix: "10.1.1.0/24", LocalPref: 100},
+		},
+		{
+			name:     "later entry sets local preference",
+			routeMap: "FILTER",
+			path:     models.BGPPath{Prefix: "10.2.0.0/16", LocalPref: 100},
+			permit:   true,
+			want:     models.BGPPath{Prefix: "10.2.0.0/16", LocalPref: 200},
+		},
+		{
+			name:     "shorter prefix does not match",
+			routeMap: "FILTER",
+			path:     models.BGPPath{Prefix: "0.0.0.0/0", LocalPref: 100},
+		},
+		{
+			name:     "AS path match sets MED",
+			routeMap: "TRANSIT",
+			path:     models.BGPPath{Prefix: "172.16.0.0/16", ASPath: []uint32{65002, 65010}},
+			permit:   true,
+			want:     models.BGPPath{Prefix: "172.16.0.0/16", ASPath: []uint32{65002, 65010}, MED: 50},
+		},
+		{
+			name:     "AS path mismatch is denied",
+			routeMap: "TRANSIT",
+			path:     models.BGPPath{Prefix: "172.16.0.0/16", ASPath: []uint32{65003, 65002}},
+		},
+		{
+			name:     "prepend repeats the first AS",
+			routeMap: "PREPEND",
+			path:     models.BGPPath{Prefix: "172.16.0.0/16", ASPath: []uint32{65002, 65010}},
+			permit:   true,
+			want:     models.BGPPath{Prefix: "172.16.0.0/16", ASPath: []uint32{65002, 65002, 65002, 65010}},
+		},
+		{
+			name:     "prepend to own announcement uses own AS",
+			routeMap: "PREPEND",
+			path:     models.BGPPath{Prefix: "172.16.0.0/16", ASPath: []uint32{}},
+			permit:   true,
+			want:     models.BGPPath{Prefix: "172.16.0.0/16", ASPath: []uint32{65001, 65001}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.path
+			permit := sp.applyRouteMap(tt.routeMap, &path)
+			if permit != tt.permit {
+				t.Fatalf("route-map %q permit = %v, want %v", tt.routeMap, permit, tt.permit)
+			}
+			if permit && !reflect.DeepEqual(path, tt.want) {
+				t.Errorf("route-map %q changed path to %+v, want %+v", tt.routeMap, path, tt.want)
+			}
+		})
+	}
+}
+
+func TestBGPRouteMapChangesBestPath(t *testing.T) {
+	// R1 (AS 65001) получает 172.16.0.0/16 от R4 (AS 65004) через R2 (AS 65002) и R3 (AS 65003)
+	r1 := &models.Router{ID: 1, Name: "R1", IPAddress: "10.0.0.1", Status: "active", Interfaces: []models.Interface{
+		testInterface(11, "Gi0/0", "192.168.12.1/30"),
+		testInterface(12, "Gi0/1", "192.168.13.1/30"),
+	}}
+	r2 := &models.Router{ID: 2, Name: "R2", IPAddress: "10.0.0.2", Status: "active", Interfaces: []models.Interface{
+		testInterface(21, "Gi0/0", "192.168.12.2/30"),
+		testInterface(22, "Gi0/1", "192.168.24.1/30"),
+	}}
+	r3 := &models.Router{ID: 3, Name: "R3", IPAddress: "10.0.0.3", Status: "active", Interfaces: []models.Interface{
+		testInterface(31, "Gi0/0", "192.168.13.2/30"),
+		testInterface(32, "Gi0/1", "192.168.34.1/30"),
+	}}
+	r4 := &models.Router{ID: 4, Name: "R4", IPAddress: "10.0.0.4", Status: "active", Interfaces: []models.Interface{
+		testInterface(41, "Gi0/0", "192.168.24.2/30"),
+		testInterface(42, "Gi0/1", "192.168.34.2/30"),
+	}}
+	conns := []*models.RouterConnection{
+		testLink(1, r1, 11, r2, 21),
+		testLink(2, r1, 12, r3, 31),
+		testLink(3, r2, 22, r4, 41),
+		testLink(4, r3, 32, r4, 42),
+	}
+	topo := newTestTopology([]*models.Router{r1, r2, r3, r4}, conns)
+	connections := make([]models.RouterConnection, len(conns))
+	for i, conn := range conns {
+		connections[i] = *conn
+	}
+
+	processes := []models.BGPProcess{
+		{RouterID: 1, Enabled: true, ASN: 65001},
+		{RouterID: 2, Enabled: true, ASN: 65002},
+		{RouterID: 3, Enabled: true, ASN: 65003},
+		{RouterID: 4, Enabled: true, ASN: 65004},
+	}
+	neighbors := func(routeMapIn string) []models.BGPNeighbor {
+		return []models.BGPNeighbor{
+			{ID: 1, RouterID: 1, ConnectionID: 1, RemoteAS: 65002},
+			{ID: 2, RouterID: 2, ConnectionID: 1, RemoteAS: 65001},
+			{ID: 3, RouterID: 1, ConnectionID: 2, RemoteAS: 65003, RouteMapIn: routeMapIn},
+			{ID: 4, RouterID: 3, ConnectionID: 2, RemoteAS: 65001},
+			{ID: 5, RouterID: 2, ConnectionID: 3, RemoteAS: 65004},
+			{ID: 6, RouterID: 4, ConnectionID: 3, RemoteAS: 65002},
+			{ID: 7, RouterID: 3, ConnectionID: 4, RemoteAS: 65004},
+			{ID: 8, RouterID: 4, ConnectionID: 4, RemoteAS: 65003},
+		}
+	}
+	networks := []models.BGPNetwork{{RouterID: 4, Prefix: "172.16.0.0/16"}}
+	localPref := uint32(200)
+	routeMaps := []models.BGPRouteMapEntry{
+		{RouterID: 1, Name: "PREFER-R3", Sequence: 10, Action: models.RouteMapPermit, SetLocalPref: &localPref},
+	}
+
+	tests := []struct {
+		name       string
+		routeMapIn string
+		nextHop    string
+		localPref  uint32
+		asPath     []uint32
+	}{
+		{name: "lower router ID without policy", nextHop: "192.168.12.2", localPref: defaultLocalPref, asPath: []uint32{65002, 65004}},
+		{name: "inbound route-map raises local preference", routeMapIn: "PREFER-R3", nextHop: "192.168.13.2", localPref: 200, asPath: []uint32{65003, 65004}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := buildBGPDomain(topo, connections, processes, neighbors(tt.routeMapIn), networks, routeMaps)
+			route, ok := d.speakers[r1.ID].locRIB["172.16.0.0/16"]
+			if !ok {
+				t.Fatalf("R1 has no path to 172.16.0.0/16")
+			}
+			if route.NextHop != tt.nextHop || route.LocalPref != tt.localPref {
+				t.Errorf("R1 best path via %s local-pref %d, want via %s local-pref %d", route.NextHop, route.LocalPref, tt.nextHop, tt.localPref)
+			}
+			if !reflect.DeepEqual(route.ASPath, tt.asPath) {
+				t.Errorf("R1 best path AS path %v, want %v", route.ASPath, tt.asPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"network/internal/models"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// maxSimulationLog ограничивает число хранимых обработанных событий
+const maxSimulationLog = 1000
+
+// simEvent — запланированное событие и его обработчик.
+// Обработчик возвращает описание результата для журнала.
+type simEvent struct {
+	models.SimulationEvent
+	fire func() string
+}
+
+// eventQueue — очередь событий по возрастанию времени; одновременные события
+// обрабатываются в порядке планирования
+type eventQueue []*simEvent
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].Time != q[j].Time {
+		return q[i].Time < q[j].Time
+	}
+	return q[i].ID < q[j].ID
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*simEvent)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	event := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return event
+}
+
+// simClock — виртуальные часы дискретно-событийной симуляции.
+// Время идет только при обработке событий и не зависит от реального времени.
+// По этому времени устаревают записи таблиц MAC, ARP, conntrack, NAT и аренды DHCP.
+type simClock struct {
+	mu  sync.Mutex
+	now float64 // мс с момента сброса
+	// elapsed — копия now для таблиц с устареванием записей, читаемая без блокировки часов
+	elapsed   atomic.Uint64
+	nextID    uint64
+	queue     eventQueue
+	log       []models.SimulationEvent
+	processed int
+
+	rng  *rand.Rand
+	seed int64
+
+	packets []*simPacket
+	// links — исходные статусы соединений, измененных симуляцией, для восстановления при сбросе
+	links map[uint]string
+	// topo — топология на текущий момент виртуального времени; nil, если ее нужно перечитать
+	topo *topology
+
+	// ripTimer — событие следующего раунда обновлений RIP в момент ripAt, 0 — раунды не запланированы
+	ripTimer uint64
+	ripAt    float64
+
+	running bool
+	speed   float64
+	stop    chan struct{}
+}
+
+func newSimClock() *simClock {
+	return &simClock{links: make(map[uint]string)}
+}
+
+// setNow переводит часы на момент now
+func (c *simClock) setNow(now float64) {
+	c.now = now
+	c.elapsed.Store(math.Float64bits(now))
+}
+
+// time возвращает текущее виртуальное время, мс. В отличие от now, доступно
+// без блокировки часов, в том числе обработчикам запросов вне симуляции.
+func (c *simClock) time() float64 {
+	return math.Float64frombits(c.elapsed.Load())
+}
+
+// reset очищает очередь, журнал и пакеты и возвращает часы к нулю
+func (c *simClock) reset() {
+	c.setNow(0)
+	c.nextID = 0
+	c.queue = nil
+	c.log = nil
+	c.processed = 0
+	c.packets = nil
+	c.links = make(map[uint]string)
+	c.topo = nil
+	c.ripTimer = 0
+	c.ripAt = 0
+	c.speed = 0
+}
+
+// schedule добавляет событие в очередь и возвращает его идентификатор
+func (c *simClock) schedule(at float64, event models.SimulationEvent, fire func() string) uint64 {
+	c.nextID++
+	event.ID = c.nextID
+	event.Time = at
+	heap.Push(&c.queue, &simEvent{SimulationEvent: event, fire: fire})
+	return event.ID
+}
+
+// advance обрабатывает события со временем не позже until, но не больше limit событий
+// (без ограничения, если limit не положителен). Возвращает число обработанных событий.
+func (c *simClock) advance(until float64, limit int) int {
+	n := 0
+	for len(c.queue) > 0 && c.queue[0].Time <= until && (limit <= 0 || n < limit) {
+		event := heap.Pop(&c.queue).(*simEvent)
+		c.setNow(event.Time)
+		event.Result = event.fire()
+
+		c.log = append(c.log, event.SimulationEvent)
+		if len(c.log) > maxSimulationLog {
+			c.log = c.log[len(c.log)-maxSimulationLog:]
+		}
+		c.processed++
+		n++
+	}
+	return n
+}
+
+// state возвращает снимок состояния часов
+func (c *simClock) state() *models.SimulationClock {
+	state := &models.SimulationClock{
+		Time:      c.now,
+		Running:   c.running,
+		Speed:     c.speed,
+		Seed:      c.seed,
+		Processed: c.processed,
+		Pending:   make([]models.SimulationEvent, 0, len(c.queue)),
+		Log:       append([]models.SimulationEvent{}, c.log...),
+		Packets:   make([]models.SimulationPacket, 0, len(c.packets)),
+	}
+
+	pending := append(eventQueue{}, c.queue...)
+	sort.Sort(pending)
+	for _, event := range pending {
+		state.Pending = append(state.Pending, event.SimulationEvent)
+	}
+	for _, packet := range c.packets {
+		view := packet.SimulationPacket
+		view.Hops = append([]models.PacketHop{}, packet.Hops...)
+		state.Packets = append(state.Packets, view)
+	}
+	return state
+}
@@ -0,0 +1,468 @@
+package service
+
+import (
+	"fmt"
+	"network/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Время хранения соединений межсетевого экрана по умолчанию, с
+const (
+	defaultTCPTimeout   = 432000
+	defaultUDPTimeout   = 30
+	defaultICMPTimeout  = 30
+	maxConntrackTimeout = 604800
+)
+
+// Время хранения соединений TCP, которые устанавливаются или закрываются, с
+var tcpStateTimeouts = map[models.ConntrackState]int{
+	models.ConntrackSynSent:  120,
+	models.ConntrackSynRecv:  60,
+	models.ConntrackFinWait:  120,
+	models.ConntrackTimeWait: 120,
+	models.ConntrackClose:    10,
+}
+
+// Флаги сегментов TCP, которыми симулятор устанавливает и закрывает соединение
+const (
+	tcpSYN    = "syn"
+	tcpSYNACK = "syn-ack"
+	tcpACK    = "ack"
+	tcpFIN    = "fin"
+	tcpRST    = "rst"
+)
+
+// Типы сообщений ICMP echo; соединение ICMP открывает запрос, а ответ идет в обратном направлении
+const (
+	icmpEchoRequest = "echo-request"
+	icmpEchoReply   = "echo-reply"
+)
+
+// ctTuple — направление соединения: протокол, адреса, порты и тип сообщения ICMP
+type ctTuple struct {
+	protocol string
+	srcIP    string
+	srcPort  int
+	dstIP    string
+	dstPort  int
+	icmpType string
+}
+
+func (k ctTuple) reverse() ctTuple {
+	return ctTuple{protocol: k.protocol, srcIP: k.dstIP, srcPort: k.dstPort, dstIP: k.srcIP, dstPort: k.srcPort, icmpType: echoAnswer(k.icmpType)}
+}
+
+func (p packetHeader) tuple() ctTuple {
+	key := ctTuple{protocol: p.protocol, srcIP: p.srcIP, srcPort: p.srcPort, dstIP: p.dstIP, dstPort: p.dstPort}
+	if p.protocol == "icmp" {
+		key.icmpType = p.flags
+	}
+	return key
+}
+
+// reverse возвращает заголовок ответа на пакет
+func (p packetHeader) reverse() packetHeader {
+	answer := packetHeader{protocol: p.protocol, srcIP: p.dstIP, srcPort: p.dstPort, dstIP: p.srcIP, dstPort: p.srcPort}
+	if p.protocol == "icmp" {
+		answer.flags = echoAnswer(p.flags)
+	}
+	return answer
+}
+
+// echoAnswer возвращает тип ответного сообщения ICMP echo
+func echoAnswer(icmpType string) string {
+	switch icmpType {
+	case icmpEchoRequest:
+		return icmpEchoReply
+	case icmpEchoReply:
+		return icmpEchoRequest
+	}
+	return icmpType
+}
+
+// ctEntry — соединение в таблице роутера: исходное и ответное направления
+type ctEntry struct {
+	orig    ctTuple
+	reply   ctTuple
+	state   models.ConntrackState
+	packets int64
+	expires float64 // виртуальное время, мс
+}
+
+// conntrackTables — таблицы соединений межсетевых экранов роутеров.
+// Соединение доступно по кортежам обоих направлений; таблицы хранятся только в памяти,
+// а соединения истекают по виртуальным часам симуляции.
+type conntrackTables struct {
+	mu     sync.Mutex
+	clock  *simClock
+	tables map[uint]map[ctTuple]*ctEntry
+}
+
+func newConntrackTables(clock *simClock) *conntrackTables {
+	return &conntrackTables{clock: clock, tables: make(map[uint]map[ctTuple]*ctEntry)}
+}
+
+// lookup находит соединение по кортежу пакета; reply — пакет идет в ответном направлении.
+// Истекшие соединения удаляются.
+func (c *conntrackTables) lookup(routerID uint, key ctTuple) (*ctEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.tables[routerID][key]
+	if entry == nil {
+		return nil, false
+	}
+	if c.clock.time() > entry.expires {
+		delete(c.tables[routerID], entry.orig)
+		delete(c.tables[routerID], entry.reply)
+		return nil, false
+	}
+	return entry, key == entry.reply && key != entry.orig
+}
+
+// update записывает новое состояние соединения и продлевает его
+func (c *conntrackTables) update(routerID uint, entry *ctEntry, state models.ConntrackState, timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table := c.tables[routerID]
+	if table == nil {
+		table = make(map[ctTuple]*ctEntry)
+		c.tables[routerID] = table
+	}
+	entry.state = state
+	entry.packets++
+	entry.expires = c.clock.time() + float64(timeout.Milliseconds())
+	table[entry.orig] = entry
+	table[entry.reply] = entry
+}
+
+// entries возвращает действующие соединения роутера
+func (c *conntrackTables) entries(routerID uint) []models.ConntrackEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.time()
+	entries := []models.ConntrackEntry{}
+	for key, entry := range c.tables[routerID] {
+		if now > entry.expires {
+			delete(c.tables[routerID], key)
+			continue
+		}
+		if key != entry.orig {
+			continue
+		}
+		entries = append(entries, models.ConntrackEntry{
+			Protocol:        entry.orig.protocol,
+			SourceIP:        entry.orig.srcIP,
+			SourcePort:      entry.orig.srcPort,
+			DestinationIP:   entry.orig.dstIP,
+			DestinationPort: entry.orig.dstPort,
+			State:           entry.state,
+			Packets:         entry.packets,
+			Reply:           replyAddresses(entry),
+			Timeout:         int((entry.expires - now) / 1000),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.SourceIP != b.SourceIP {
+			return a.SourceIP < b.SourceIP
+		}
+		if a.DestinationIP != b.DestinationIP {
+			return a.DestinationIP < b.DestinationIP
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		return a.SourcePort < b.SourcePort
+	})
+	return entries
+}
+
+// replyAddresses возвращает адреса ответного направления соединения, если NAT изменил их
+func replyAddresses(entry *ctEntry) *models.PacketAddresses {
+	if entry.reply == entry.orig.reverse() {
+		return nil
+	}
+	reply := packetHeader{
+		protocol: entry.reply.protocol,
+		srcIP:    entry.reply.srcIP,
+		srcPort:  entry.reply.srcPort,
+		dstIP:    entry.reply.dstIP,
+		dstPort:  entry.reply.dstPort,
+	}.addresses()
+	return &reply
+}
+
+// flush очищает таблицу роутера
+func (c *conntrackTables) flush(routerID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tables, routerID)
+}
+
+// reset очищает таблицы всех роутеров при сбросе часов
+func (c *conntrackTables) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables = make(map[uint]map[ctTuple]*ctEntry)
+}
+
+// nextTCPState возвращает состояние соединения TCP после сегмента с флагами flags.
+// Сегмент, который не может относиться к соединению в текущем состоянии, недопустим.
+func nextTCPState(entry *ctEntry, flags string, reply bool) (models.ConntrackState, bool) {
+	if flags == tcpRST && entry != nil {
+		return models.ConntrackClose, true
+	}
+	// Новое соединение открывает только SYN, в том числе поверх закрытого
+	if entry == nil || entry.state == models.ConntrackClose || entry.state == models.ConntrackTimeWait {
+		if flags == tcpSYN && !reply {
+			return models.ConntrackSynSent, true
+		}
+		if entry != nil {
+			return entry.state, true
+		}
+		return "", false
+	}
+
+	switch entry.state {
+	case models.ConntrackSynSent:
+		switch {
+		case flags == tcpSYN && !reply:
+			return models.ConntrackSynSent, true
+		case flags == tcpSYNACK && reply:
+			return models.ConntrackSynRecv, true
+		}
+	case models.ConntrackSynRecv:
+		switch {
+		case flags == tcpSYNACK && reply:
+			return models.ConntrackSynRecv, true
+		case flags == tcpACK && !reply:
+			return models.ConntrackEstablished, true
+		}
+	case models.ConntrackEstablished:
+		if flags == tcpFIN {
+			return models.ConntrackFinWait, true
+		}
+		return models.ConntrackEstablished, true
+	case models.ConntrackFinWait:
+		if flags == tcpFIN || flags == tcpACK {
+			return models.ConntrackTimeWait, true
+		}
+		return models.ConntrackFinWait, true
+	}
+	return "", false
+}
+
+// ctFlow — результат проверки пакета межсетевым экраном устройства
+type ctFlow struct {
+	entry   *ctEntry
+	state   models.ConntrackState // состояние соединения после пакета
+	tracked bool                  // пакет принадлежит известному соединению и не проверяется ACL
+	related bool                  // ошибка ICMP, относящаяся к известному соединению
+}
+
+// firewall возвращает настройки межсетевого экрана устройства, если он включен
+func (t *topology) firewall(deviceID uint) (models.FirewallConfig, bool) {
+	config, ok := t.firewalls[deviceID]
+	return config, ok && config.Enabled && t.conntrack != nil
+}
+
+// classify сопоставляет пакет с таблицей соединений устройства
+func (t *topology) classify(device *models.Router, pkt packetHeader) (ctFlow, error) {
+	if pkt.inner != nil {
+		entry, _ := t.conntrack.lookup(device.ID, pkt.inner.tuple())
+		return ctFlow{entry: entry, tracked: entry != nil, related: entry != nil}, nil
+	}
+
+	entry, reply := t.conntrack.lookup(device.ID, pkt.tuple())
+	flow := ctFlow{entry: entry, tracked: entry != nil}
+	switch {
+	case pkt.protocol == "tcp":
+		state, ok := nextTCPState(entry, pkt.flags, reply)
+		if !ok {
+			return flow, fmt.Errorf("invalid TCP segment (%s) from %s dropped by stateful firewall of %s", pkt.flags, pkt.srcIP, device.IPAddress)
+		}
+		flow.state = state
+		// SYN, открывающий соединение заново, проверяется как новый
+		flow.tracked = entry != nil && !(pkt.flags == tcpSYN && !reply)
+	case entry == nil:
+		flow.state = models.ConntrackUnreplied
+	case reply:
+		flow.state = models.ConntrackReplied
+	default:
+		flow.state = entry.state
+	}
+	return flow, nil
+}
+
+// inspect проверяет пакет, принятый устройством через соединение in (nil — пакет создан самим устройством).
+// Пакеты известных соединений межсетевой экран пропускает без проверки ACL; новые проверяются
+// входящим ACL, а на внешнем интерфейсе пропускаются, только если ACL их явно разрешает.
+func (t *topology) inspect(hop *models.PacketHop, device *models.Router, in *models.RouterConnection, pkt packetHeader) (ctFlow, error) {
+	var flow ctFlow
+	_, stateful := t.firewall(device.ID)
+	if stateful {
+		var err error
+		if flow, err = t.classify(device, pkt); err != nil {
+			return flow, err
+		}
+		if flow.tracked {
+			return flow, nil
+		}
+	}
+	if in == nil {
+		return flow, nil
+	}
+
+	iface := connectionInterface(device, in)
+	match, err := t.checkACL(device, iface, models.ACLInbound, pkt)
+	if match != nil {
+		hop.ACL = append(hop.ACL, *match)
+	}
+	if err != nil {
+		return flow, err
+	}
+	if stateful && iface != nil && iface.FirewallZone == models.FirewallZoneOutside && match == nil {
+		return flow, fmt.Errorf("unsolicited packet from %s dropped by stateful firewall on %s of %s", pkt.srcIP, iface.Name, device.IPAddress)
+	}
+	return flow, nil
+}
+
+// commit записывает пакет, пропущенный устройством, в таблицу соединений
+// и отмечает состояние соединения в переходе. pkt — пакет, принятый устройством,
+// sent — тот же пакет после трансляции NAT: ответы на него приходят на транслированные адреса.
+func (t *topology) commit(hop *models.PacketHop, device *models.Router, pkt, sent packetHeader, flow ctFlow) {
+	config, stateful := t.firewall(device.ID)
+	// Ошибки ICMP не создают соединений и не меняют их состояние
+	if !stateful || pkt.inner != nil {
+		return
+	}
+	entry := flow.entry
+	if entry == nil || flow.state == models.ConntrackSynSent && entry.state != models.ConntrackSynSent {
+		entry = &ctEntry{orig: pkt.tuple(), reply: sent.tuple().reverse()}
+	}
+	t.conntrack.update(device.ID, entry, flow.state, conntrackTimeout(config, pkt.protocol, flow.state))
+	hop.Conntrack = flow.state
+}
+
+// conntrackTimeout возвращает время хранения соединения в состоянии state
+func conntrackTimeout(config models.FirewallConfig, protocol string, state models.ConntrackState) time.Duration {
+	seconds := config.ICMPTimeout
+	switch protocol {
+	case "tcp":
+		seconds = config.TCPTimeout
+		if timeout, ok := tcpStateTimeouts[state]; ok {
+			seconds = timeout
+		}
+	case "udp":
+		seconds = config.UDPTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// respond передает ответ получателя destID на пакет pkt от отправителя sourceID, который получатель
+// принял как delivered: для TCP — SYN-ACK и завершающий рукопожатие ACK, для UDP — ответную датаграмму.
+// Возвращает путь ответа.
+func (t *topology) respond(sourceID, destID uint, pkt, delivered packetHeader) ([]models.PacketHop, error) {
+	answer := delivered.reverse()
+	if pkt.protocol == "tcp" {
+		answer.flags = tcpSYNACK
+	}
+	hops, err := t.forward(destID, answer, defaultTTL)
+	if err != nil || pkt.protocol != "tcp" {
+		return hops, err
+	}
+
+	ack := pkt
+	ack.flags = tcpACK
+	if _, err := t.forward(sourceID, ack, defaultTTL); err != nil {
+		return hops, fmt.Errorf("handshake ACK not delivered: %w", err)
+	}
+	return hops, nil
+}
+
+// firewallConfig возвращает настройки межсетевого экрана; новый роутер получает значения по умолчанию
+func (s *DeviceService) firewallConfig(routerID uint) (*models.FirewallConfig, error) {
+	config, err := s.repo.GetFirewallConfig(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get firewall config: %w", err)
+	}
+	if config.ID == 0 {
+		config.TCPTimeout = defaultTCPTimeout
+		config.UDPTimeout = defaultUDPTimeout
+		config.ICMPTimeout = defaultICMPTimeout
+	}
+	return config, nil
+}
+
+// GetFirewall возвращает настройки межсетевого экрана роутера и его таблицу соединений
+func (s *DeviceService) GetFirewall(routerID uint) (*models.FirewallInfo, error) {
+	if _, err := s.getFilterDevice(routerID); err != nil {
+		return nil, err
+	}
+	config, err := s.firewallConfig(routerID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.FirewallInfo{FirewallConfig: *config, Connections: s.conntrack.entries(routerID)}, nil
+}
+
+// UpdateFirewall включает межсетевой экран роутера и меняет время хранения соединений.
+// Выключение очищает таблицу соединений.
+func (s *DeviceService) UpdateFirewall(routerID uint, req *models.UpdateFirewallRequest) (*models.FirewallInfo, error) {
+	if _, err := s.getFilterDevice(routerID); err != nil {
+		return nil, err
+	}
+	config, err := s.firewallConfig(routerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		config.Enabled = *req.Enabled
+	}
+	if req.TCPTimeout != nil {
+		config.TCPTimeout = *req.TCPTimeout
+	}
+	if req.UDPTimeout != nil {
+		config.UDPTimeout = *req.UDPTimeout
+	}
+	if req.ICMPTimeout != nil {
+		config.ICMPTimeout = *req.ICMPTimeout
+	}
+	for name, timeout := range map[string]int{"TCP": config.TCPTimeout, "UDP": config.UDPTimeout, "ICMP": config.ICMPTimeout} {
+		if timeout < 1 || timeout > maxConntrackTimeout {
+			return nil, fmt.Errorf("invalid %s timeout: %d (must be 1-%d)", name, timeout, maxConntrackTimeout)
+		}
+	}
+
+	if err := s.repo.SaveFirewallConfig(config); err != nil {
+		return nil, fmt.Errorf("failed to save firewall config: %w", err)
+	}
+	if !config.Enabled {
+		s.conntrack.flush(routerID)
+	}
+	return s.GetFirewall(routerID)
+}
+
+// GetConntrack возвращает таблицу соединений межсетевого экрана роутера
+func (s *DeviceService) GetConntrack(routerID uint) ([]models.ConntrackEntry, error) {
+	if _, err := s.getFilterDevice(routerID); err != nil {
+		return nil, err
+	}
+	return s.conntrack.entries(routerID), nil
+}
+
+// FlushConntrack очищает таблицу соединений роутера: ответы на ранее разрешенные
+// соединения после этого проверяются как новые пакеты
+func (s *DeviceService) FlushConntrack(routerID uint) error {
+	if _, err := s.getFilterDevice(routerID); err != nil {
+		return err
+	}
+	s.conntrack.flush(routerID)
+	return nil
+}
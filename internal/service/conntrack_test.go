@@ -0,0 +1,108 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"network/internal/models"
+)
+
+// mustEnableEdgeFirewall включает межсетевой экран на R2 с внутренним Gi0/0 и внешним Gi0/1
+func mustEnableEdgeFirewall(t *testing.T, s *DeviceService, topo edgeTopology, tcpTimeout int) {
+	t.Helper()
+	enabled := true
+	if _, err := s.UpdateFirewall(topo.edge.ID, &models.UpdateFirewallRequest{Enabled: &enabled, TCPTimeout: &tcpTimeout}); err != nil {
+		t.Fatalf("enable firewall: %v", err)
+	}
+	inside, outside := models.FirewallZoneInside, models.FirewallZoneOutside
+	mustUpdateEdgeInterface(t, s, topo.edge, "Gi0/0", &models.UpdateInterfaceRequest{FirewallZone: &inside})
+	mustUpdateEdgeInterface(t, s, topo.edge, "Gi0/1", &models.UpdateInterfaceRequest{FirewallZone: &outside})
+}
+
+func TestStatefulFirewallDropsUnsolicitedTraffic(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	topo := mustCreateEdgeTopology(t, s)
+	mustEnableEdgeFirewall(t, s, topo, 60)
+
+	// Соединение снаружи внутрь никто не запрашивал
+	unsolicited := mustSendTCP(t, s, "203.0.113.3", "192.168.1.2", false)
+	if unsolicited.Status != "failed" || !strings.Contains(unsolicited.Error, "unsolicited packet") {
+		t.Fatalf("R3 -> R1 = %s (%s), want dropped as unsolicited", unsolicited.Status, unsolicited.Error)
+	}
+	if entries, _ := s.GetConntrack(topo.edge.ID); len(entries) != 0 {
+		t.Errorf("conntrack after a dropped packet = %+v, want empty", entries)
+	}
+
+	// Соединение изнутри устанавливается, и ответы снаружи проходят как его часть
+	outbound := mustSendTCP(t, s, "192.168.1.2", "203.0.113.3", true)
+	if outbound.Status != "success" {
+		t.Fatalf("R1 -> R3 = %s (%s), want success", outbound.Status, outbound.Error)
+	}
+	if state := hopOf(t, outbound.Hops, topo.edge).Conntrack; state != models.ConntrackSynSent {
+		t.Errorf("SYN on R2 = %s, want syn_sent", state)
+	}
+	if state := hopOf(t, outbound.ReplyHops, topo.edge).Conntrack; state != models.ConntrackSynRecv {
+		t.Errorf("SYN-ACK on R2 = %s, want syn_recv", state)
+	}
+	entries, err := s.GetConntrack(topo.edge.ID)
+	if err != nil {
+		t.Fatalf("get conntrack: %v", err)
+	}
+	if len(entries) != 1 || entries[0].State != models.ConntrackEstablished || entries[0].SourceIP != "192.168.1.2" ||
+		entries[0].DestinationIP != "203.0.113.3" || entries[0].DestinationPort != 80 || entries[0].Packets != 3 {
+		t.Fatalf("conntrack = %+v, want one established connection to 203.0.113.3:80 after 3 packets", entries)
+	}
+
+	// Установленное соединение не открывает путь для новых соединений снаружи
+	if again := mustSendTCP(t, s, "203.0.113.3", "192.168.1.2", false); again.Status != "failed" {
+		t.Errorf("new connection R3 -> R1 = %s, want dropped", again.Status)
+	}
+
+	// Соединение без пакетов истекает по виртуальным часам
+	mustRunClock(t, s, 59000)
+	if entries, _ := s.GetConntrack(topo.edge.ID); len(entries) != 1 || entries[0].Timeout != 1 {
+		t.Errorf("conntrack at 59 s = %+v, want the connection with 1 s left", entries)
+	}
+	mustRunClock(t, s, 60001)
+	if entries, _ := s.GetConntrack(topo.edge.ID); len(entries) != 0 {
+		t.Errorf("conntrack at 60 s = %+v, want the connection expired", entries)
+	}
+}
+
+func TestStatefulFirewallAdmitsTrafficPermittedByACL(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	topo := mustCreateEdgeTopology(t, s)
+	mustEnableEdgeFirewall(t, s, topo, 60)
+
+	// Внешний интерфейс пропускает новые соединения, явно разрешенные входящим ACL
+	if _, err := s.CreateACLEntry(topo.edge.ID, &models.CreateACLEntryRequest{
+		Name: "WEB", Action: models.ACLPermit, Protocol: "tcp", Destination: "192.168.1.2/32", DstPortFrom: 80,
+	}); err != nil {
+		t.Fatalf("create ACL entry: %v", err)
+	}
+	name := "WEB"
+	mustUpdateEdgeInterface(t, s, topo.edge, "Gi0/1", &models.UpdateInterfaceRequest{InboundACL: &name})
+
+	inbound := mustSendTCP(t, s, "203.0.113.3", "192.168.1.2", true)
+	if inbound.Status != "success" {
+		t.Fatalf("R3 -> R1 = %s (%s), want success", inbound.Status, inbound.Error)
+	}
+	entries, err := s.GetConntrack(topo.edge.ID)
+	if err != nil {
+		t.Fatalf("get conntrack: %v", err)
+	}
+	if len(entries) != 1 || entries[0].SourceIP != "203.0.113.3" || entries[0].State != models.ConntrackEstablished {
+		t.Errorf("conntrack = %+v, want the established connection from 203.0.113.3", entries)
+	}
+
+	// Выключенный межсетевой экран забывает соединения
+	disabled := false
+	if _, err := s.UpdateFirewall(topo.edge.ID, &models.UpdateFirewallRequest{Enabled: &disabled}); err != nil {
+		t.Fatalf("disable firewall: %v", err)
+	}
+	if entries, _ := s.GetConntrack(topo.edge.ID); len(entries) != 0 {
+		t.Errorf("conntrack of a disabled firewall = %+v, want empty", entries)
+	}
+}
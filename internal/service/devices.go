@@ -9,40 +9,48 @@ import (
 	"network/internal/models"
 	"network/internal/repository"
 	"strconv"
+	"sync"
 	"time"
 )
 
 type DeviceService struct {
-	repo *repository.DeviceRepository
+	// mu упорядочивает изменения топологии и маршрутов: запросы API
+	// и события запущенных часов симуляции выполняются по одному
+	mu sync.Mutex
+
+	repo  *repository.DeviceRepository
+	ipam  *IPAMService
+	clock *simClock
+	mac   *macTables
+	stp   *stpTimers
+	arp   *arpCaches
+
+	aclHits   *aclCounters
+	conntrack *conntrackTables
+	nat       *natTables
 }
 
-func NewDeviceService(repo *repository.DeviceRepository) *DeviceService {
+func NewDeviceService(repo *repository.DeviceRepository, ipam *IPAMService) *DeviceService {
+	clock := newSimClock()
 	return &DeviceService{
-		repo: repo,
-	}
-}
-
-// generateIP генерирует случайный IP-адрес в сети 192.168.0.0/16
-func (s *DeviceService) generateIP() string {
-	for i := 0; i < 100; i++ { // Максимум 100 попыток
-		// Инициализируем генератор случайных чисел
-		rand.Seed(time.Now().UnixNano())
-
-		// Генерируем третий и четвертый октеты
-		thirdOctet := rand.Intn(256)  // 0-255
-		fourthOctet := rand.Intn(256) // 0-255
+		repo:  repo,
+		ipam:  ipam,
+		clock: clock,
+		mac:   newMACTables(clock),
+		stp:   newSTPTimers(),
+		arp:   newARPCaches(clock),
 
-		ip := fmt.Sprintf("192.168.%d.%d", thirdOctet, fourthOctet)
-		if s.validateIP(ip) {
-			return ip
-		}
+		aclHits:   newACLCounters(),
+		conntrack: newConntrackTables(clock),
+		nat:       newNATTables(clock),
 	}
-	return "192.168.0.1" // Fallback IP если все попытки неудачны
 }
 
-// validateIP проверяет, не занят ли IP-адрес
-func (s *DeviceService) validateIP(ip string) bool {
-	return !s.repo.IsIPTaken(ip)
+// Exclusive выполняет fn, пока другие изменения топологии и события часов ждут
+func (s *DeviceService) Exclusive(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn()
 }
 
 // getLocalIP получает локальный IP адрес
@@ -61,23 +69,93 @@ func (s *DeviceService) getLocalIP() (string, error) {
 	return "", fmt.Errorf("local IP not found")
 }
 
-// CreateRouter создает роутер с базовым IP
+// CreateRouter создает роутер с адресом, выделенным через IPAM
 func (s *DeviceService) CreateRouter(req *models.CreateRouterRequest) (*models.Router, error) {
-	// Создаем роутер с временным IP
-	ip := s.generateIP()
+	var err error
+	switch req.Type {
+	case "":
+		req.Type = models.DeviceTypeRouter
+	case models.DeviceTypeRouter, models.DeviceTypeSwitch, models.DeviceTypeHost:
+	default:
+		return nil, fmt.Errorf("invalid device type: %s", req.Type)
+	}
+
+	// Маска и шлюз по умолчанию задаются только хостам
+	prefixLength := 0
+	if req.Type == models.DeviceTypeHost {
+		if req.Netmask == "" {
+			req.Netmask = defaultHostNetmask
+		}
+		if prefixLength, err = parseNetmask(req.Netmask); err != nil {
+			return nil, err
+		}
+	} else if req.Netmask != "" || req.DefaultGateway != "" {
+		return nil, fmt.Errorf("netmask and default gateway can only be set on hosts")
+	}
+
+	// Интерфейсы из запроса проверяем до создания роутера
+	names := make(map[string]bool, len(req.Interfaces))
+	for i := range req.Interfaces {
+		iface := newInterface(0, &req.Interfaces[i])
+		if err := validateInterface(iface); err != nil {
+			return nil, err
+		}
+		if err := validateDeviceInterface(req.Type, iface); err != nil {
+			return nil, err
+		}
+		if names[req.Interfaces[i].Name] {
+			return nil, fmt.Errorf("interface %s already exists", req.Interfaces[i].Name)
+		}
+		names[req.Interfaces[i].Name] = true
+	}
+
+	// Фиксированный адрес из запроса или свободный адрес из пула.
+	// Хост, все интерфейсы которого получают адреса по DHCP, получает адрес аренды.
+	allocation := &models.IPAllocation{}
+	if !dhcpOnlyHost(req) {
+		if allocation, err = s.ipam.AllocateRouterIP(req.IPAddress, req.PoolID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Интерфейсы из запроса или интерфейсы по умолчанию
+	interfaces := req.Interfaces
+	if len(interfaces) == 0 {
+		switch req.Type {
+		case models.DeviceTypeSwitch:
+			interfaces = defaultSwitchInterfaces()
+		case models.DeviceTypeHost:
+			interfaces = defaultHostInterfaces(allocation.Address, prefixLength)
+		default:
+			interfaces = defaultInterfaces()
+		}
+	}
+
+	// Шлюз должен быть достижим непосредственно через интерфейсы хоста
+	gateway := ""
+	if req.DefaultGateway != "" {
+		ifaces := make([]models.Interface, 0, len(interfaces))
+		for i := range interfaces {
+			ifaces = append(ifaces, *newInterface(0, &interfaces[i]))
+		}
+		if err := validateGateway(req.DefaultGateway, ifaces); err != nil {
+			return nil, s.releaseRouterIP(allocation, err)
+		}
+		gateway = net.ParseIP(req.DefaultGateway).String()
+	}
 
 	// Создаем стандартные порты (80 и 443 TCP)
 	defaultPorts := []models.Port{
 		{
 			Number:     80,
 			Protocol:   "tcp",
-			Status:     "up",
+			Status:     models.PortStatusOpen,
 			PortNumber: 1,
 		},
 		{
 			Number:     443,
 			Protocol:   "tcp",
-			Status:     "down",
+			Status:     models.PortStatusClosed,
 			PortNumber: 2,
 		},
 	}
@@ -97,7 +175,7 @@ func (s *DeviceService) CreateRouter(req *models.CreateRouterRequest) (*models.R
 			port := models.Port{
 				Number:   portReq.Number,
 				Protocol: portReq.Protocol,
-				Status:   "down",
+				Status:   models.PortStatusClosed,
 			}
 			ports = append(ports, port)
 		}
@@ -105,19 +183,65 @@ func (s *DeviceService) CreateRouter(req *models.CreateRouterRequest) (*models.R
 
 	router := &models.Router{
 		Name:      req.Name,
-		IPAddress: ip,
+		Type:      req.Type,
+		IPAddress: allocation.Address,
 		Status:    "active",
 		Ports:     ports,
 		Connected: false,
+
+		DefaultGateway: gateway,
 	}
 
 	if err := s.repo.CreateRouter(router); err != nil {
-		return nil, err
+		return nil, s.releaseRouterIP(allocation, err)
+	}
+
+	if err := s.ipam.AssignAllocation(allocation, router.ID); err != nil {
+		return nil, s.discardRouter(router.ID, allocation, fmt.Errorf("failed to assign IP address: %w", err))
+	}
+
+	// Создаем интерфейсы
+	for i := range interfaces {
+		iface, err := s.createInterface(router, &interfaces[i])
+		if err != nil {
+			return nil, s.discardRouter(router.ID, allocation, fmt.Errorf("failed to create interface %s: %w", interfaces[i].Name, err))
+		}
+		router.Interfaces = append(router.Interfaces, *iface)
 	}
 
 	return router, nil
 }
 
+// dhcpOnlyHost проверяет, что хост создается без адреса и пула и все его интерфейсы — клиенты DHCP
+func dhcpOnlyHost(req *models.CreateRouterRequest) bool {
+	if req.Type != models.DeviceTypeHost || req.IPAddress != "" || req.PoolID != nil || len(req.Interfaces) == 0 {
+		return false
+	}
+	for _, iface := range req.Interfaces {
+		if !iface.DHCPClient {
+			return false
+		}
+	}
+	return true
+}
+
+// releaseRouterIP освобождает адрес роутера, который не удалось создать, и возвращает причину отказа
+func (s *DeviceService) releaseRouterIP(allocation *models.IPAllocation, cause error) error {
+	if err := s.ipam.ReleaseAllocation(allocation); err != nil {
+		return fmt.Errorf("%w (failed to release IP address: %v)", cause, err)
+	}
+	return cause
+}
+
+// discardRouter удаляет частично созданный роутер вместе с его портами и интерфейсами,
+// освобождает его адрес и возвращает причину отказа
+func (s *DeviceService) discardRouter(id uint, allocation *models.IPAllocation, cause error) error {
+	if err := s.repo.DeleteRouter(id); err != nil {
+		return fmt.Errorf("%w (failed to delete router: %v)", cause, err)
+	}
+	return s.releaseRouterIP(allocation, cause)
+}
+
 func (s *DeviceService) ConnectRouter(req *models.ConnectRouterRequest) (*models.ConnectRouterResponse, error) {
 	// Получаем роутер по IP
 	router, err := s.repo.GetRouterByIP(req.IPAddress)
@@ -151,29 +275,6 @@ func (s *DeviceService) ConnectRouter(req *models.ConnectRouterRequest) (*models
 	}, nil
 }
 
-func (s *DeviceService) PingIP(req *models.PingRequest) (*models.PingResult, error) {
-	// Создаем результат пинга
-	result := &models.PingResult{
-		IPAddress: req.IPAddress,
-		Status:    "failed",
-	}
-
-	// Пингуем IP-адрес
-	start := time.Now()
-	conn, err := net.DialTimeout("ip:icmp", req.IPAddress, 5*time.Second)
-	if err != nil {
-		result.Latency = 0
-		return result, nil
-	}
-	defer conn.Close()
-
-	// Вычисляем задержку
-	result.Latency = float64(time.Since(start).Milliseconds())
-	result.Status = "success"
-
-	return result, nil
-}
-
 func (s *DeviceService) SendPacket(req *models.PacketRequest) (*models.PacketResponse, error) {
 	// Если source_ip пустой, проверяем подключенный роутер
 	if req.SourceIP == "" {
@@ -191,93 +292,159 @@ func (s *DeviceService) SendPacket(req *models.PacketRequest) (*models.PacketRes
 		return nil, fmt.Errorf("source router is not connected")
 	}
 
-	// Проверяем существование роутера-получателя
-	destRouter, err := s.repo.GetRouterByIP(req.DestinationIP)
+	// Пересылаем пакет по таблицам маршрутизации роутеров
+	topo, err := s.loadTopology()
 	if err != nil {
-		return nil, fmt.Errorf("destination router with IP %s not found", req.DestinationIP)
+		return nil, err
 	}
 
-	// Проверяем, открыт ли порт на роутере-получателе
-	portFound := false
-	for _, port := range destRouter.Ports {
-		if port.Number == req.Port && port.Protocol == req.Protocol {
-			portFound = true
-			if port.Status != "open" {
-				return &models.PacketResponse{
-					SourceIP:      req.SourceIP,
-					DestinationIP: req.DestinationIP,
-					Protocol:      req.Protocol,
-					Port:          req.Port,
-					Status:        "failed",
-					Error:         fmt.Sprintf("port %d is %s", req.Port, port.Status),
-				}, nil
-			}
-			break
-		}
+	// Проверяем существование роутера-получателя; глобальные адреса NAT принадлежат роутерам, которые их транслируют
+	if _, err := s.repo.GetRouterByIP(req.DestinationIP); err != nil && topo.natGlobals[req.DestinationIP] == nil {
+		return nil, fmt.Errorf("destination router with IP %s not found", req.DestinationIP)
 	}
 
-	if !portFound {
+	if req.SourcePort == 0 {
+		req.SourcePort = defaultSourcePort
+	}
+	if req.SourcePort < 1 || req.SourcePort > 65535 {
+		return nil, fmt.Errorf("invalid source port: %d", req.SourcePort)
+	}
+	// TCP начинает соединение сегментом SYN
+	pkt := packetHeader{
+		srcIP:    req.SourceIP,
+		dstIP:    req.DestinationIP,
+		protocol: req.Protocol,
+		srcPort:  req.SourcePort,
+		dstPort:  req.Port,
+	}
+	if req.Protocol == "tcp" {
+		pkt.flags = tcpSYN
+	}
+	hops, delivered, err := topo.deliver(sourceRouter.ID, pkt, defaultTTL)
+	if err != nil {
 		return &models.PacketResponse{
 			SourceIP:      req.SourceIP,
 			DestinationIP: req.DestinationIP,
 			Protocol:      req.Protocol,
 			Port:          req.Port,
+			SourcePort:    req.SourcePort,
 			Status:        "failed",
-			Error:         fmt.Sprintf("port %d not found", req.Port),
+			Hops:          hops,
+			Error:         err.Error(),
 		}, nil
 	}
 
+	// Проверяем, открыт ли порт на роутере-получателе; после трансляции адреса назначения
+	// пакет принимает устройство за NAT
+	destRouter := topo.byIP[delivered.dstIP]
+	if err := checkPort(destRouter, req.Protocol, delivered.dstPort); err != nil {
+		response := &models.PacketResponse{
+			SourceIP:      req.SourceIP,
+			DestinationIP: req.DestinationIP,
+			Protocol:      req.Protocol,
+			Port:          req.Port,
+			SourcePort:    req.SourcePort,
+			Status:        "failed",
+			Hops:          hops,
+			Error:         err.Error(),
+		}
+		// Закрытый порт TCP отвечает сегментом RST, который закрывает соединение на межсетевых экранах
+		if req.Reply && req.Protocol == "tcp" {
+			rst := delivered.reverse()
+			rst.flags = tcpRST
+			response.ReplyHops, _ = topo.forward(destRouter.ID, rst, defaultTTL)
+		}
+		return response, nil
+	}
+
+	// Ответ получателя проходит обратный путь через те же межсетевые экраны
+	var replyHops []models.PacketHop
+	if req.Reply {
+		if replyHops, err = topo.respond(sourceRouter.ID, destRouter.ID, pkt, delivered); err != nil {
+			return &models.PacketResponse{
+				SourceIP:      req.SourceIP,
+				DestinationIP: req.DestinationIP,
+				Protocol:      req.Protocol,
+				Port:          req.Port,
+				SourcePort:    req.SourcePort,
+				Status:        "failed",
+				Hops:          hops,
+				ReplyHops:     replyHops,
+				Error:         "no reply: " + err.Error(),
+			}, nil
+		}
+	}
+
 	response := &models.PacketResponse{
 		SourceIP:      req.SourceIP,
 		DestinationIP: req.DestinationIP,
 		Protocol:      req.Protocol,
 		Port:          req.Port,
+		SourcePort:    req.SourcePort,
 		Status:        "failed",
+		Hops:          hops,
+		ReplyHops:     replyHops,
 	}
 
-	// Эмулируем задержку сети (от 10 до 100 мс)
-	rand.Seed(time.Now().UnixNano())
-	latency := float64(10+rand.Intn(90)) + rand.Float64()
-	response.Latency = latency
+	// Задержка и потери определяются характеристиками соединений на пути
+	links := topo.pathLinks(hops)
+	response.Loss = pathLoss(links)
+
+	rng, seed := s.newRand(req.Seed)
+	response.Seed = seed
 
 	// Эмулируем различное поведение для TCP и UDP
 	switch req.Protocol {
 	case "tcp":
-		return s.handleTCPPacket(req, response)
+		return s.handleTCPPacket(rng, req, response, links)
 	case "udp":
-		return s.handleUDPPacket(req, response)
+		return s.handleUDPPacket(rng, req, response, links)
 	default:
 		response.Error = "unsupported protocol"
 		return response, nil
 	}
 }
 
-func (s *DeviceService) handleTCPPacket(_ *models.PacketRequest, response *models.PacketResponse) (*models.PacketResponse, error) {
-	// Эмулируем TCP соединение (более надежное)
-	rand.Seed(time.Now().UnixNano())
-	successRate := 0.95 // 95% успешных соединений
+// checkPort проверяет, что на роутере-получателе настроен и открыт порт назначения
+func checkPort(router *models.Router, protocol string, number int) error {
+	for _, port := range router.Ports {
+		if port.Number == number && port.Protocol == protocol {
+			if port.Status != models.PortStatusOpen {
+				return fmt.Errorf("port %d is %s", number, port.Status)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("port %d not found", number)
+}
 
-	if rand.Float64() < successRate {
-		response.Status = "success"
-	} else {
-		response.Status = "failed"
-		response.Error = "connection refused"
-		// Увеличиваем латенцию при ошибке
-		response.Latency += float64(100 + rand.Intn(200))
+func (s *DeviceService) handleTCPPacket(rng *rand.Rand, req *models.PacketRequest, response *models.PacketResponse, links []*models.RouterConnection) (*models.PacketResponse, error) {
+	// Эмулируем TCP соединение: потерянный сегмент передается повторно по таймауту
+	size := tcpHeaderSize + len(req.Data)
+
+	for attempt := 0; attempt <= tcpMaxRetries; attempt++ {
+		latency, delivered := transmit(rng, response.Hops, links, size)
+		if delivered {
+			response.Status = "success"
+			response.Latency += latency
+			return response, nil
+		}
+		response.Latency += tcpRetransmitTimeout
 	}
 
+	response.Status = "failed"
+	response.Error = "connection timed out"
 	return response, nil
 }
 
-func (s *DeviceService) handleUDPPacket(_ *models.PacketRequest, response *models.PacketResponse) (*models.PacketResponse, error) {
-	// Эмулируем UDP соединение (менее надежное, но быстрее)
-	rand.Seed(time.Now().UnixNano())
-	successRate := 0.85 // 85% успешных пакетов
+func (s *DeviceService) handleUDPPacket(rng *rand.Rand, req *models.PacketRequest, response *models.PacketResponse, links []*models.RouterConnection) (*models.PacketResponse, error) {
+	// Эмулируем UDP: потерянная датаграмма не передается повторно
+	size := udpHeaderSize + len(req.Data)
 
-	if rand.Float64() < successRate {
+	latency, delivered := transmit(rng, response.Hops, links, size)
+	response.Latency = latency
+	if delivered {
 		response.Status = "success"
-		// UDP обычно быстрее TCP
-		response.Latency *= 0.8
 	} else {
 		response.Status = "failed"
 		response.Error = "packet lost"
@@ -286,8 +453,75 @@ func (s *DeviceService) handleUDPPacket(_ *models.PacketRequest, response *model
 	return response, nil
 }
 
-func (s *DeviceService) GetAllRouters() ([]models.Router, error) {
-	return s.repo.GetAllRouters()
+// GetAllRouters возвращает все устройства или только устройства заданного типа
+func (s *DeviceService) GetAllRouters(deviceType models.DeviceType) ([]models.Router, error) {
+	routers, err := s.repo.GetAllRouters()
+	if err != nil || deviceType == "" {
+		return routers, err
+	}
+
+	devices := make([]models.Router, 0, len(routers))
+	for _, router := range routers {
+		if router.Type == deviceType {
+			devices = append(devices, router)
+		}
+	}
+	return devices, nil
+}
+
+func (s *DeviceService) GetRouter(id uint) (*models.Router, error) {
+	router, err := s.repo.GetRouterByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	return router, nil
+}
+
+// DeleteRouter удаляет роутер; его порты, маршруты и соединения удаляются каскадно
+func (s *DeviceService) DeleteRouter(id uint) error {
+	if _, err := s.repo.GetRouterByID(id); err != nil {
+		return fmt.Errorf("router not found: %w", err)
+	}
+
+	// Интерфейсы соседей, которые останутся без соединения
+	connections, err := s.repo.GetConnectionsByRouterID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get connections: %w", err)
+	}
+	var peers []uint
+	for _, conn := range connections {
+		if conn.RouterFromID == id {
+			peers = append(peers, conn.ToInterfaceID)
+		} else {
+			peers = append(peers, conn.FromInterfaceID)
+		}
+	}
+
+	if err := s.repo.DeleteRouter(id); err != nil {
+		return fmt.Errorf("failed to delete router: %w", err)
+	}
+
+	// Соседи теряют адреса транзитных подсетей удаленных соединений
+	for _, conn := range connections {
+		if err := s.releaseLink(conn.ID); err != nil {
+			return err
+		}
+	}
+	if err := s.syncOperStatus(peers...); err != nil {
+		return err
+	}
+	s.mac.flush(id)
+	s.arp.flushRouter(id)
+	s.conntrack.flush(id)
+	s.nat.flush(id)
+	return s.reconverge()
+}
+
+func (s *DeviceService) DeletePort(routerID uint, number int) error {
+	if _, err := s.repo.GetRouterByID(routerID); err != nil {
+		return fmt.Errorf("router not found: %w", err)
+	}
+	return s.repo.DeletePort(routerID, number)
 }
 
 func (s *DeviceService) ConfigureRouter(req *models.ConfigureRouterRequest) (*models.ConfigureResponse, error) {
@@ -308,6 +542,10 @@ func (s *DeviceService) ConfigureRouter(req *models.ConfigureRouterRequest) (*mo
 	if err := s.repo.UpdateRouterConfig(router.ID, updates); err != nil {
 		return nil, err
 	}
+	// Выключенный роутер выходит из процессов динамической маршрутизации
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
 
 	return &models.ConfigureResponse{
 		Success: true,
@@ -315,6 +553,24 @@ func (s *DeviceService) ConfigureRouter(req *models.ConfigureRouterRequest) (*mo
 	}, nil
 }
 
+func validateSpeed(speed models.Speed) error {
+	switch speed {
+	case models.SpeedAuto, models.Speed10, models.Speed100, models.Speed1000, models.Speed10000:
+		return nil
+	default:
+		return fmt.Errorf("invalid speed value: %s", speed)
+	}
+}
+
+func validateDuplexMode(mode models.DuplexMode) error {
+	switch mode {
+	case models.DuplexModeAuto, models.DuplexModeFull, models.DuplexModeHalf:
+		return nil
+	default:
+		return fmt.Errorf("invalid duplex mode: %s", mode)
+	}
+}
+
 // ConfigurePort configures a port on a router
 func (s *DeviceService) ConfigurePort(ctx context.Context, req *models.ConfigurePortRequest) error {
 	routerID, err := strconv.ParseUint(req.RouterID, 10, 32)
@@ -322,30 +578,29 @@ func (s *DeviceService) ConfigurePort(ctx context.Context, req *models.Configure
 		return fmt.Errorf("invalid router ID: %w", err)
 	}
 
+	// Настройки VLAN относятся к порту коммутатора, а не к TCP/UDP порту
+	if req.Interface != "" {
+		return s.configureSwitchport(uint(routerID), req)
+	}
+
 	// Validate port number
 	if req.PortNumber < 1 || req.PortNumber > 65535 {
 		return fmt.Errorf("invalid port number: %d (must be 1-65535)", req.PortNumber)
 	}
 
 	// Validate speed
-	switch req.Speed {
-	case models.SpeedAuto, models.Speed10, models.Speed100, models.Speed1000, models.Speed10000:
-		// Valid speed
-	default:
-		return fmt.Errorf("invalid speed value: %s", req.Speed)
+	if err := validateSpeed(req.Speed); err != nil {
+		return err
 	}
 
 	// Validate duplex mode
-	switch req.DuplexMode {
-	case models.DuplexModeAuto, models.DuplexModeFull, models.DuplexModeHalf:
-		// Valid duplex mode
-	default:
-		return fmt.Errorf("invalid duplex mode: %s", req.DuplexMode)
+	if err := validateDuplexMode(req.DuplexMode); err != nil {
+		return err
 	}
 
 	// Validate status
-	if req.Status != "up" && req.Status != "down" {
-		return fmt.Errorf("invalid status: %s", req.Status)
+	if req.Status != models.PortStatusOpen && req.Status != models.PortStatusClosed {
+		return fmt.Errorf("invalid status: %s (must be %s or %s)", req.Status, models.PortStatusOpen, models.PortStatusClosed)
 	}
 
 	// Validate protocol
@@ -370,28 +625,20 @@ func (s *DeviceService) ConfigurePort(ctx context.Context, req *models.Configure
 
 	// Если порт не найден — создаем новый
 	if port == nil {
-		newPort := models.Port{
-			RouterID:    uint(routerID),
-			Number:      req.PortNumber, // Используем `Number`
-			Protocol:    req.Protocol,
-			Status:      req.Status,
-			Speed:       req.Speed,
-			DuplexMode:  req.DuplexMode,
-			Description: req.Description,
+		port = &models.Port{
+			RouterID: uint(routerID),
+			Number:   req.PortNumber, // Используем `Number`
 		}
-		router.Ports = append(router.Ports, newPort)
-	} else {
-		// Обновляем существующий порт
-		port.Status = req.Status
-		port.Protocol = req.Protocol
-		port.Speed = req.Speed
-		port.DuplexMode = req.DuplexMode
-		port.Description = req.Description
 	}
+	port.Status = req.Status
+	port.Protocol = req.Protocol
+	port.Speed = req.Speed
+	port.DuplexMode = req.DuplexMode
+	port.Description = req.Description
 
 	// Save changes
-	if err := s.repo.UpdateRouter(router); err != nil {
-		return fmt.Errorf("failed to update router: %w", err)
+	if err := s.repo.SavePort(port); err != nil {
+		return fmt.Errorf("failed to save port: %w", err)
 	}
 
 	return nil
@@ -399,28 +646,46 @@ func (s *DeviceService) ConfigurePort(ctx context.Context, req *models.Configure
 
 func (s *DeviceService) CreateConnection(req *models.CreateConnectionRequest) (*models.CreateConnectionResponse, error) {
 	// Проверяем существование первого роутера
-	routerFrom, err := s.repo.GetRouterByIP(req.RouterFromIP)
+	routerFrom, err := s.connectionEndpoint(req.RouterFromID, req.RouterFromIP)
 	if err != nil {
 		return nil, fmt.Errorf("source router not found: %w", err)
 	}
 
 	// Проверяем существование второго роутера
-	routerTo, err := s.repo.GetRouterByIP(req.RouterToIP)
+	routerTo, err := s.connectionEndpoint(req.RouterToID, req.RouterToIP)
 	if err != nil {
 		return nil, fmt.Errorf("destination router not found: %w", err)
 	}
 
-	// Проверяем, не существует ли уже соединение между этими роутерами
-	if s.repo.ConnectionExists(routerFrom.ID, routerTo.ID) {
-		return nil, fmt.Errorf("connection between these routers already exists")
+	if routerFrom.ID == routerTo.ID {
+		return nil, fmt.Errorf("cannot connect a router to itself")
+	}
+
+	// Соединение подключается к свободным интерфейсам; параллельные соединения допустимы
+	ifaceFrom, err := s.cableInterface(routerFrom, req.FromInterface)
+	if err != nil {
+		return nil, err
+	}
+	ifaceTo, err := s.cableInterface(routerTo, req.ToInterface)
+	if err != nil {
+		return nil, err
 	}
 
 	// Создаем новое соединение
 	connection := &models.RouterConnection{
-		RouterFromID: routerFrom.ID,
-		RouterToID:   routerTo.ID,
-		Status:       "active",
-		CreatedAt:    time.Now().Format(time.RFC3339),
+		RouterFromID:    routerFrom.ID,
+		RouterToID:      routerTo.ID,
+		FromInterfaceID: ifaceFrom.ID,
+		ToInterfaceID:   ifaceTo.ID,
+		Status:          "active",
+		Delay:           defaultLinkDelay,
+		Bandwidth:       defaultLinkBandwidth,
+		CreatedAt:       time.Now().Format(time.RFC3339),
+	}
+	applyLinkProperties(connection, req.Delay, req.Jitter, req.PacketLoss, req.Bandwidth)
+
+	if err := validateLink(connection); err != nil {
+		return nil, err
 	}
 
 	// Сохраняем соединение в базе данных
@@ -428,13 +693,168 @@ func (s *DeviceService) CreateConnection(req *models.CreateConnectionRequest) (*
 		return nil, fmt.Errorf("failed to create connection: %w", err)
 	}
 
+	// Назначаем интерфейсам адреса из транзитной подсети, если они еще не адресованы.
+	// Порты коммутаторов адресов не имеют, поэтому сегменты через них адресуются вручную,
+	// а DHCP клиенты получают адрес от сервера на сегменте.
+	subnet := ""
+	routed := routerFrom.Type != models.DeviceTypeSwitch && routerTo.Type != models.DeviceTypeSwitch
+	dhcp := ifaceFrom.DHCPClient || ifaceTo.DHCPClient
+	if (req.AutoAddress == nil || *req.AutoAddress) && routed && !dhcp && ifaceFrom.IPv4Address == "" && ifaceTo.IPv4Address == "" {
+		subnet, err = s.addressLink(connection, ifaceFrom, ifaceTo, req.LinkPoolID)
+		if err != nil {
+			return nil, s.discardConnection(connection, err)
+		}
+	}
+
+	if err := s.syncOperStatus(ifaceFrom.ID, ifaceTo.ID); err != nil {
+		return nil, s.discardConnection(connection, err)
+	}
+	if err := s.reconverge(); err != nil {
+		return nil, s.discardConnection(connection, err)
+	}
+	s.bindDHCPClients()
+
 	return &models.CreateConnectionResponse{
-		ID:           connection.ID,
+		ID:            connection.ID,
+		RouterFromIP:  routerFrom.IPAddress,
+		RouterToIP:    routerTo.IPAddress,
+		FromInterface: ifaceFrom.Name,
+		ToInterface:   ifaceTo.Name,
+		Subnet:        subnet,
+		Status:        connection.Status,
+		Delay:         connection.Delay,
+		Jitter:        connection.Jitter,
+		PacketLoss:    connection.PacketLoss,
+		Bandwidth:     connection.Bandwidth,
+		CreatedAt:     connection.CreatedAt,
+	}, nil
+}
+
+// connectionEndpoint находит устройство на конце нового соединения по ID или адресу.
+// Хост, получающий адрес только по DHCP, до подключения к серверу можно найти лишь по ID.
+func (s *DeviceService) connectionEndpoint(id uint, ip string) (*models.Router, error) {
+	if id != 0 {
+		return s.repo.GetRouterByID(id)
+	}
+	return s.repo.GetRouterByIP(ip)
+}
+
+// discardConnection удаляет соединение, которое не удалось создать полностью, освобождает
+// его транзитную подсеть, возвращает интерфейсы и маршруты в прежнее состояние
+// и возвращает причину отказа
+func (s *DeviceService) discardConnection(connection *models.RouterConnection, cause error) error {
+	if err := s.repo.DeleteConnection(connection.ID); err != nil {
+		return fmt.Errorf("%w (failed to delete connection: %v)", cause, err)
+	}
+	if err := s.releaseLink(connection.ID); err != nil {
+		return fmt.Errorf("%w (failed to release link: %v)", cause, err)
+	}
+	if err := s.syncOperStatus(connection.FromInterfaceID, connection.ToInterfaceID); err != nil {
+		return fmt.Errorf("%w (failed to restore interfaces: %v)", cause, err)
+	}
+	if err := s.reconverge(); err != nil {
+		return fmt.Errorf("%w (failed to restore routes: %v)", cause, err)
+	}
+	return cause
+}
+
+func (s *DeviceService) GetConnection(id uint) (*models.ConnectionInfo, error) {
+	connection, err := s.repo.GetConnectionByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("connection not found: %w", err)
+	}
+	return s.connectionInfo(connection)
+}
+
+// UpdateConnection изменяет статус и характеристики соединения.
+// Неактивное соединение не используется при пересылке пакетов.
+func (s *DeviceService) UpdateConnection(id uint, req *models.UpdateConnectionRequest) (*models.RouterConnection, error) {
+	connection, err := s.repo.GetConnectionByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("connection not found: %w", err)
+	}
+
+	if req.Status != nil {
+		if *req.Status != "active" && *req.Status != "inactive" {
+			return nil, fmt.Errorf("invalid status: %s", *req.Status)
+		}
+		connection.Status = *req.Status
+	}
+	applyLinkProperties(connection, req.Delay, req.Jitter, req.PacketLoss, req.Bandwidth)
+
+	if err := validateLink(connection); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateConnection(connection); err != nil {
+		return nil, fmt.Errorf("failed to update connection: %w", err)
+	}
+
+	// Состояние соединения определяет операционное состояние интерфейсов на его концах
+	if err := s.syncOperStatus(connection.FromInterfaceID, connection.ToInterfaceID); err != nil {
+		return nil, err
+	}
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
+	s.bindDHCPClients()
+
+	return connection, nil
+}
+
+func (s *DeviceService) DeleteConnection(id uint) error {
+	connection, err := s.repo.GetConnectionByID(id)
+	if err != nil {
+		return fmt.Errorf("connection not found: %w", err)
+	}
+	if err := s.repo.DeleteConnection(id); err != nil {
+		return err
+	}
+	if err := s.releaseLink(id); err != nil {
+		return err
+	}
+	if err := s.syncOperStatus(connection.FromInterfaceID, connection.ToInterfaceID); err != nil {
+		return err
+	}
+	return s.reconverge()
+}
+
+// connectionInfo дополняет соединение информацией о роутерах
+func (s *DeviceService) connectionInfo(conn *models.RouterConnection) (*models.ConnectionInfo, error) {
+	routerFrom, err := s.repo.GetRouterByID(conn.RouterFromID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source router: %w", err)
+	}
+
+	routerTo, err := s.repo.GetRouterByID(conn.RouterToID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destination router: %w", err)
+	}
+
+	info := &models.ConnectionInfo{
+		ID:           conn.ID,
 		RouterFromIP: routerFrom.IPAddress,
 		RouterToIP:   routerTo.IPAddress,
-		Status:       connection.Status,
-		CreatedAt:    connection.CreatedAt,
-	}, nil
+		Status:       conn.Status,
+		Delay:        conn.Delay,
+		Jitter:       conn.Jitter,
+		PacketLoss:   conn.PacketLoss,
+		Bandwidth:    conn.Bandwidth,
+		CreatedAt:    conn.CreatedAt,
+		FromRouter:   *routerFrom,
+		ToRouter:     *routerTo,
+	}
+	for _, iface := range routerFrom.Interfaces {
+		if iface.ID == conn.FromInterfaceID {
+			info.FromInterface = iface.Name
+		}
+	}
+	for _, iface := range routerTo.Interfaces {
+		if iface.ID == conn.ToInterfaceID {
+			info.ToInterface = iface.Name
+		}
+	}
+	return info, nil
 }
 
 func (s *DeviceService) GetAllConnections() ([]models.ConnectionInfo, error) {
@@ -444,27 +864,12 @@ func (s *DeviceService) GetAllConnections() ([]models.ConnectionInfo, error) {
 	}
 
 	connectionInfos := make([]models.ConnectionInfo, 0, len(connections))
-	for _, conn := range connections {
-		// Получаем информацию о роутерах
-		routerFrom, err := s.repo.GetRouterByID(conn.RouterFromID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get source router: %w", err)
-		}
-
-		routerTo, err := s.repo.GetRouterByID(conn.RouterToID)
+	for i := range connections {
+		info, err := s.connectionInfo(&connections[i])
 		if err != nil {
-			return nil, fmt.Errorf("failed to get destination router: %w", err)
+			return nil, err
 		}
-
-		connectionInfos = append(connectionInfos, models.ConnectionInfo{
-			ID:           conn.ID,
-			RouterFromIP: routerFrom.IPAddress,
-			RouterToIP:   routerTo.IPAddress,
-			Status:       conn.Status,
-			CreatedAt:    conn.CreatedAt,
-			FromRouter:   *routerFrom,
-			ToRouter:     *routerTo,
-		})
+		connectionInfos = append(connectionInfos, *info)
 	}
 
 	return connectionInfos, nil
@@ -477,26 +882,12 @@ func (s *DeviceService) GetConnectionsByRouterIP(ip string) ([]models.Connection
 	}
 
 	connectionInfos := make([]models.ConnectionInfo, 0, len(connections))
-	for _, conn := range connections {
-		routerFrom, err := s.repo.GetRouterByID(conn.RouterFromID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get source router: %w", err)
-		}
-
-		routerTo, err := s.repo.GetRouterByID(conn.RouterToID)
+	for i := range connections {
+		info, err := s.connectionInfo(&connections[i])
 		if err != nil {
-			return nil, fmt.Errorf("failed to get destination router: %w", err)
+			return nil, err
 		}
-
-		connectionInfos = append(connectionInfos, models.ConnectionInfo{
-			ID:           conn.ID,
-			RouterFromIP: routerFrom.IPAddress,
-			RouterToIP:   routerTo.IPAddress,
-			Status:       conn.Status,
-			CreatedAt:    conn.CreatedAt,
-			FromRouter:   *routerFrom,
-			ToRouter:     *routerTo,
-		})
+		connectionInfos = append(connectionInfos, *info)
 	}
 
 	return connectionInfos, nil
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"network/internal/models"
+)
+
+func TestCreateRouterRollsBackOnInterfaceError(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	pool, err := services.IPAM.CreatePool(&models.CreateIPPoolRequest{Name: "routers", Subnet: "172.16.0.0/24"})
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	if _, err := s.CreateRouter(&models.CreateRouterRequest{
+		Name:       "R1",
+		IPAddress:  "10.0.0.1",
+		Interfaces: []models.CreateInterfaceRequest{{Name: "Gi0/0", IPv4Address: "192.168.1.1", IPv4PrefixLength: 24}},
+	}); err != nil {
+		t.Fatalf("create R1: %v", err)
+	}
+
+	// Первый интерфейс создается, второй конфликтует с адресом R1 уже после создания роутера
+	req := &models.CreateRouterRequest{
+		Name:   "R2",
+		PoolID: &pool.ID,
+		Interfaces: []models.CreateInterfaceRequest{
+			{Name: "Gi0/0", IPv4Address: "192.168.2.1", IPv4PrefixLength: 24},
+			{Name: "Gi0/1", IPv4Address: "192.168.1.1", IPv4PrefixLength: 24},
+		},
+	}
+	if _, err := s.CreateRouter(req); err == nil {
+		t.Fatal("create R2 with a conflicting interface succeeded")
+	}
+
+	routers, err := s.repo.GetAllRouters()
+	if err != nil {
+		t.Fatalf("get routers: %v", err)
+	}
+	if len(routers) != 1 {
+		t.Errorf("routers after failed create = %d, want 1", len(routers))
+	}
+	if s.repo.InterfaceIPExists("192.168.2.1", 0) {
+		t.Error("interface of the failed router was left behind")
+	}
+	allocations, err := services.IPAM.repo.GetAllocationsByPool(pool.ID)
+	if err != nil {
+		t.Fatalf("get allocations: %v", err)
+	}
+	if len(allocations) != 0 {
+		t.Errorf("allocations after failed create = %+v, want none", allocations)
+	}
+
+	// После отката имя, адрес из пула и адрес интерфейса снова свободны
+	req.Interfaces = req.Interfaces[:1]
+	router, err := s.CreateRouter(req)
+	if err != nil {
+		t.Fatalf("create R2 after rollback: %v", err)
+	}
+	if router.IPAddress != "172.16.0.1" {
+		t.Errorf("R2 address = %s, want the first pool address 172.16.0.1", router.IPAddress)
+	}
+}
+
+func TestCreateConnectionRollsBackOnLinkAllocationError(t *testing.T) {
+	services, db := newTestService(t)
+	s := services.Devices
+	r1 := mustCreateRouter(t, s, "R1", "10.0.0.1")
+	r2 := mustCreateRouter(t, s, "R2", "10.0.0.2")
+
+	// Адрес второго конца первой подсети /31 занят вне пула: первый конец выделяется, второй нет
+	blocker := &models.IPAllocation{Address: "10.255.0.1", Description: "outside the link pool"}
+	if err := db.Create(blocker).Error; err != nil {
+		t.Fatalf("reserve blocker: %v", err)
+	}
+	if _, err := s.CreateConnection(&models.CreateConnectionRequest{RouterFromIP: r1.IPAddress, RouterToIP: r2.IPAddress}); err == nil {
+		t.Fatal("create connection over a taken link address succeeded")
+	}
+
+	connections, err := s.repo.GetAllConnections()
+	if err != nil {
+		t.Fatalf("get connections: %v", err)
+	}
+	if len(connections) != 0 {
+		t.Errorf("connections after failed create = %+v, want none", connections)
+	}
+	var allocations []models.IPAllocation
+	if err := db.Where("id <> ?", blocker.ID).Find(&allocations).Error; err != nil {
+		t.Fatalf("get allocations: %v", err)
+	}
+	if len(allocations) != 0 {
+		t.Errorf("allocations after failed create = %+v, want none", allocations)
+	}
+	for _, router := range []*models.Router{r1, r2} {
+		ifaces, err := s.GetInterfaces(router.ID)
+		if err != nil {
+			t.Fatalf("get interfaces of %s: %v", router.Name, err)
+		}
+		for _, iface := range ifaces {
+			if iface.Name == "Gi0/0" && iface.IPv4Address != "" {
+				t.Errorf("%s %s kept link address %s", router.Name, iface.Name, iface.IPv4Address)
+			}
+		}
+	}
+
+	// После отката та же подсеть выделяется заново
+	if err := db.Delete(blocker).Error; err != nil {
+		t.Fatalf("release blocker: %v", err)
+	}
+	if conn := mustConnect(t, s, r1, r2, 1, 0, 0); conn.Subnet != "10.255.0.0/31" {
+		t.Errorf("link subnet after rollback = %s, want 10.255.0.0/31", conn.Subnet)
+	}
+}
+
+func TestConfigurePortControlsDelivery(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	r1 := mustCreateRouter(t, s, "R1", "10.0.0.1")
+	r2 := mustCreateRouter(t, s, "R2", "10.0.0.2")
+	mustConnect(t, s, r1, r2, 1, 0, 0)
+	mustEnableOSPF(t, s, r1, r2)
+
+	send := func(port int) *models.PacketResponse {
+		t.Helper()
+		response, err := s.SendPacket(&models.PacketRequest{
+			SourceIP:      r1.IPAddress,
+			DestinationIP: r2.IPAddress,
+			Protocol:      "tcp",
+			Port:          port,
+		})
+		if err != nil {
+			t.Fatalf("send to port %d: %v", port, err)
+		}
+		return response
+	}
+	configure := func(number int, status string) error {
+		return s.ConfigurePort(context.Background(), &models.ConfigurePortRequest{
+			RouterID:   strconv.FormatUint(uint64(r2.ID), 10),
+			PortNumber: number,
+			Protocol:   "tcp",
+			Status:     status,
+			Speed:      models.Speed1000,
+			DuplexMode: models.DuplexModeFull,
+		})
+	}
+
+	// Порт 80 создается открытым, 443 — закрытым
+	if response := send(80); response.Status != "success" {
+		t.Fatalf("packet to default port 80 = %s (%s), want success", response.Status, response.Error)
+	}
+	if response := send(443); response.Status == "success" {
+		t.Fatal("packet to default closed port 443 succeeded")
+	}
+
+	if err := configure(443, models.PortStatusOpen); err != nil {
+		t.Fatalf("open port 443: %v", err)
+	}
+	if response := send(443); response.Status != "success" {
+		t.Errorf("packet to opened port 443 = %s (%s), want success", response.Status, response.Error)
+	}
+	if err := configure(80, models.PortStatusClosed); err != nil {
+		t.Fatalf("close port 80: %v", err)
+	}
+	if response := send(80); response.Status == "success" {
+		t.Error("packet to closed port 80 succeeded")
+	}
+	if err := configure(8080, models.PortStatusOpen); err != nil {
+		t.Fatalf("open port 8080: %v", err)
+	}
+	if response := send(8080); response.Status != "success" {
+		t.Errorf("packet to new port 8080 = %s (%s), want success", response.Status, response.Error)
+	}
+
+	if err := configure(80, "up"); err == nil {
+		t.Error("port status up accepted")
+	}
+}
+
+func TestInterfaceMACsAreDeterministic(t *testing.T) {
+	// Одна и та же топология в двух базах получает одни и те же MAC-адреса
+	var macs [2][]string
+	for run := range macs {
+		services, _ := newTestService(t)
+		r1 := mustCreateRouter(t, services.Devices, "R1", "10.0.0.1")
+		r2 := mustCreateRouter(t, services.Devices, "R2", "10.0.0.2")
+		for _, router := range []*models.Router{r1, r2} {
+			for _, iface := range router.Interfaces {
+				macs[run] = append(macs[run], iface.MACAddress)
+			}
+		}
+	}
+	want := []string{
+		"02:00:00:01:00:00", "02:00:00:01:00:01",
+		"02:00:00:02:00:00", "02:00:00:02:00:01",
+	}
+	for run, got := range macs {
+		var ethernet []string
+		for _, mac := range got {
+			if mac != "" {
+				ethernet = append(ethernet, mac)
+			}
+		}
+		if strings.Join(ethernet, " ") != strings.Join(want, " ") {
+			t.Errorf("run %d MACs = %v, want %v", run, ethernet, want)
+		}
+	}
+
+	// Новый интерфейс занимает номер удаленного и не совпадает с MAC соседнего интерфейса
+	services, _ := newTestService(t)
+	s := services.Devices
+	router := mustCreateRouter(t, s, "R1", "10.0.0.1")
+	if err := s.DeleteInterface(router.ID, router.Interfaces[0].ID); err != nil {
+		t.Fatalf("delete Gi0/0: %v", err)
+	}
+	for _, name := range []string{"Gi0/2", "Gi0/3"} {
+		if _, err := s.CreateInterface(router.ID, &models.CreateInterfaceRequest{Name: name}); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+	ifaces, err := s.GetInterfaces(router.ID)
+	if err != nil {
+		t.Fatalf("get interfaces: %v", err)
+	}
+	seen := make(map[string]string)
+	for _, iface := range ifaces {
+		if iface.MACAddress == "" {
+			continue
+		}
+		if other, ok := seen[iface.MACAddress]; ok {
+			t.Errorf("%s and %s share MAC %s", other, iface.Name, iface.MACAddress)
+		}
+		seen[iface.MACAddress] = iface.Name
+	}
+	if seen["02:00:00:01:00:00"] != "Gi0/2" {
+		t.Errorf("MAC 02:00:00:01:00:00 belongs to %q, want Gi0/2", seen["02:00:00:01:00:00"])
+	}
+}
@@ -0,0 +1,598 @@
+package service
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"network/internal/models"
+	"strings"
+)
+
+// Время аренды DHCP, секунд
+const (
+	defaultLeaseTime = 86400
+	minLeaseTime     = 60
+	maxLeaseTime     = 31536000
+)
+
+// Порты DHCP сервера и клиента
+const (
+	dhcpServerPort = 67
+	dhcpClientPort = 68
+)
+
+// Адреса сообщений клиента, еще не получившего адрес
+const (
+	dhcpUnspecified = "0.0.0.0"
+	dhcpBroadcast   = "255.255.255.255"
+)
+
+// dhcpIPv4 проверяет, что адрес параметра пула — адрес IPv4
+func dhcpIPv4(field, addr string) (uint32, error) {
+	ip := net.ParseIP(strings.TrimSpace(addr)).To4()
+	if ip == nil {
+		return 0, fmt.Errorf("invalid %s address: %q, DHCP supports only IPv4", field, addr)
+	}
+	return binary.BigEndian.Uint32(ip), nil
+}
+
+// getDHCPServer возвращает роутер, обслуживающий пулы DHCP
+func (s *DeviceService) getDHCPServer(routerID uint) (*models.Router, error) {
+	router, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	if err := requireRouter(router); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
+
+// servingInterface возвращает интерфейс роутера в подсети пула
+func servingInterface(router *models.Router, subnet string) *models.Interface {
+	for i := range router.Interfaces {
+		iface := &router.Interfaces[i]
+		if iface.IPv4Address == "" {
+			continue
+		}
+		if prefix, err := normalizePrefix(fmt.Sprintf("%s/%d", iface.IPv4Address, iface.IPv4PrefixLength)); err == nil && prefix == subnet {
+			return iface
+		}
+	}
+	return nil
+}
+
+// validateDHCPPool проверяет пул и заполняет диапазон, время аренды и
+// список DNS серверов значениями по умолчанию
+func validateDHCPPool(router *models.Router, pool *models.DHCPPool, existing []models.DHCPPool) error {
+	if pool.Name == "" {
+		return fmt.Errorf("pool name is required")
+	}
+	subnet, err := normalizePrefix(pool.Subnet)
+	if err != nil {
+		return err
+	}
+	first, last, err := poolRange(subnet)
+	if err != nil {
+		return err
+	}
+	pool.Subnet = subnet
+	if servingInterface(router, subnet) == nil {
+		return fmt.Errorf("router %s has no interface in subnet %s", router.IPAddress, subnet)
+	}
+	for _, other := range existing {
+		if other.Name == pool.Name {
+			return fmt.Errorf("DHCP pool %s already exists", pool.Name)
+		}
+		if other.Subnet == subnet {
+			return fmt.Errorf("subnet %s is already served by DHCP pool %s", subnet, other.Name)
+		}
+	}
+
+	start, end := first, last
+	if pool.RangeStart != "" {
+		if start, err = dhcpIPv4("range start", pool.RangeStart); err != nil {
+			return err
+		}
+	}
+	if pool.RangeEnd != "" {
+		if end, err = dhcpIPv4("range end", pool.RangeEnd); err != nil {
+			return err
+		}
+	}
+	if start < first || end > last {
+		return fmt.Errorf("DHCP range %s-%s is outside subnet %s", uint32ToIP(start), uint32ToIP(end), subnet)
+	}
+	if start > end {
+		return fmt.Errorf("DHCP range start %s is after its end %s", uint32ToIP(start), uint32ToIP(end))
+	}
+	pool.RangeStart, pool.RangeEnd = uint32ToIP(start), uint32ToIP(end)
+
+	if pool.Gateway != "" {
+		gateway, err := dhcpIPv4("gateway", pool.Gateway)
+		if err != nil {
+			return err
+		}
+		if gateway < first || gateway > last {
+			return fmt.Errorf("gateway %s is outside subnet %s", pool.Gateway, subnet)
+		}
+		pool.Gateway = uint32ToIP(gateway)
+	}
+
+	servers := dhcpDNSServers(pool.DNSServers)
+	for i, server := range servers {
+		ip, err := dhcpIPv4("DNS server", server)
+		if err != nil {
+			return err
+		}
+		servers[i] = uint32ToIP(ip)
+	}
+	pool.DNSServers = strings.Join(servers, ",")
+
+	if pool.LeaseTime == 0 {
+		pool.LeaseTime = defaultLeaseTime
+	}
+	if pool.LeaseTime < minLeaseTime || pool.LeaseTime > maxLeaseTime {
+		return fmt.Errorf("invalid lease time: %d (must be %d-%d)", pool.LeaseTime, minLeaseTime, maxLeaseTime)
+	}
+	return nil
+}
+
+// dhcpDNSServers разбирает список DNS серверов пула
+func dhcpDNSServers(list string) []string {
+	var servers []string
+	for _, server := range strings.Split(list, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// leaseState заполняет состояние аренды и оставшееся время на момент now виртуального времени
+func leaseState(lease *models.DHCPLease, now float64) {
+	lease.State = models.DHCPLeaseExpired
+	lease.Remaining = 0
+	if now >= lease.ExpiresAt {
+		return
+	}
+	lease.State = models.DHCPLeaseActive
+	lease.Remaining = int((lease.ExpiresAt - now) / 1000)
+}
+
+// dhcpServerLink — сосед на сегменте клиентского интерфейса, обслуживающий его подсеть
+type dhcpServerLink struct {
+	server *models.Router
+	iface  *models.Interface // интерфейс сервера на сегменте
+	conn   *models.RouterConnection
+	pool   models.DHCPPool
+}
+
+// dhcpServers находит DHCP серверы, до которых доходят широковещательные сообщения
+// клиентского интерфейса: соседей по сегменту с пулом в подсети своего интерфейса
+func (t *topology) dhcpServers(client *models.Router, iface *models.Interface, pools map[uint][]models.DHCPPool) []dhcpServerLink {
+	var servers []dhcpServerLink
+	for _, link := range t.links[client.ID] {
+		if local := connectionInterface(client, link.conn); local == nil || local.ID != iface.ID {
+			continue
+		}
+		server := t.routers[link.to]
+		serving := connectionInterface(server, link.conn)
+		if serving == nil || serving.IPv4Address == "" {
+			continue
+		}
+		subnet, err := normalizePrefix(fmt.Sprintf("%s/%d", serving.IPv4Address, serving.IPv4PrefixLength))
+		if err != nil {
+			continue
+		}
+		for _, pool := range pools[server.ID] {
+			if pool.Subnet == subnet {
+				servers = append(servers, dhcpServerLink{server: server, iface: serving, conn: link.conn, pool: pool})
+				break
+			}
+		}
+	}
+	return servers
+}
+
+// dhcpMessage передает сообщение DHCP от устройства from соседу to и возвращает его с пройденными переходами
+func (t *topology) dhcpMessage(msgType models.DHCPMessageType, from, to *models.Router, conn *models.RouterConnection, srcIP, dstIP, yourIP string) models.DHCPMessage {
+	hops := []models.PacketHop{newHop(from, nil)}
+	return models.DHCPMessage{
+		Type:          msgType,
+		SourceIP:      srcIP,
+		DestinationIP: dstIP,
+		YourIP:        yourIP,
+		Hops:          append(hops, t.crossLink(from, to, conn)...),
+	}
+}
+
+// allocateLease выбирает адрес пула для интерфейса клиента: адрес его прежней аренды
+// или первый свободный адрес диапазона, не занятый действующей арендой, устройством или шлюзом
+func (s *DeviceService) allocateLease(pool models.DHCPPool, gateway string, iface *models.Interface, now float64) (*models.DHCPLease, error) {
+	leases, err := s.repo.GetDHCPLeasesByPoolID(pool.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DHCP leases: %w", err)
+	}
+	leased := make(map[string]bool, len(leases))
+	for i := range leases {
+		lease := &leases[i]
+		own := lease.InterfaceID == iface.ID || iface.MACAddress != "" && lease.MACAddress == iface.MACAddress
+		if own && lease.IPAddress != gateway && !s.repo.InterfaceIPExists(lease.IPAddress, iface.ID) {
+			return lease, nil
+		}
+		if leaseState(lease, now); lease.State == models.DHCPLeaseActive {
+			leased[lease.IPAddress] = true
+		}
+	}
+
+	first, _ := dhcpIPv4("range start", pool.RangeStart)
+	last, _ := dhcpIPv4("range end", pool.RangeEnd)
+	for v := uint64(first); v <= uint64(last); v++ {
+		ip := uint32ToIP(uint32(v))
+		if ip == gateway || leased[ip] || s.repo.IsIPTaken(ip) {
+			continue
+		}
+		// Истекшая аренда свободного адреса передается новому клиенту
+		for i := range leases {
+			if leases[i].IPAddress == ip {
+				return &leases[i], nil
+			}
+		}
+		return &models.DHCPLease{RouterID: pool.RouterID, PoolID: pool.ID, IPAddress: ip}, nil
+	}
+	return nil, fmt.Errorf("DHCP pool %s has no free addresses", pool.Name)
+}
+
+// obtainLease эмулирует обмен DORA клиентского интерфейса с DHCP сервером на его сегменте.
+// Discover проверяется входящим ACL интерфейса сервера; первый сервер, принявший его,
+// выдает адрес. Интерфейс получает адрес аренды, хост — также шлюз по умолчанию.
+func (s *DeviceService) obtainLease(client *models.Router, iface *models.Interface) (*models.DHCPExchange, error) {
+	topo, err := s.loadTopology()
+	if err != nil {
+		return nil, err
+	}
+	allPools, err := s.repo.GetDHCPPools()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DHCP pools: %w", err)
+	}
+	pools := make(map[uint][]models.DHCPPool)
+	for _, pool := range allPools {
+		pools[pool.RouterID] = append(pools[pool.RouterID], pool)
+	}
+
+	exchange := &models.DHCPExchange{
+		Interface:  iface.Name,
+		MACAddress: iface.MACAddress,
+		Status:     "failed",
+		Messages:   []models.DHCPMessage{},
+	}
+	servers := topo.dhcpServers(client, iface, pools)
+	if len(servers) == 0 {
+		return exchange, fmt.Errorf("no DHCP server found on the segment of interface %s", iface.Name)
+	}
+
+	discover := packetHeader{
+		srcIP:    dhcpUnspecified,
+		dstIP:    dhcpBroadcast,
+		protocol: "udp",
+		srcPort:  dhcpClientPort,
+		dstPort:  dhcpServerPort,
+	}
+	now := s.clock.time()
+	var lastErr error
+	for _, link := range servers {
+		msg := topo.dhcpMessage(models.DHCPDiscover, client, link.server, link.conn, dhcpUnspecified, dhcpBroadcast, "")
+		err := topo.filterPacket(&msg.Hops[len(msg.Hops)-1], link.server, link.conn, models.ACLInbound, discover)
+		exchange.Messages = append(exchange.Messages, msg)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		gateway := link.pool.Gateway
+		if gateway == "" {
+			gateway = link.iface.IPv4Address
+		}
+		lease, err := s.allocateLease(link.pool, gateway, iface, now)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		_, subnet, _ := net.ParseCIDR(link.pool.Subnet)
+		prefixLength, _ := subnet.Mask.Size()
+		exchange.Messages = append(exchange.Messages,
+			topo.dhcpMessage(models.DHCPOffer, link.server, client, link.conn, link.iface.IPv4Address, dhcpBroadcast, lease.IPAddress),
+			topo.dhcpMessage(models.DHCPRequest, client, link.server, link.conn, dhcpUnspecified, dhcpBroadcast, lease.IPAddress),
+			topo.dhcpMessage(models.DHCPAck, link.server, client, link.conn, link.iface.IPv4Address, dhcpBroadcast, lease.IPAddress),
+		)
+
+		lease.MACAddress = iface.MACAddress
+		lease.ClientID = client.ID
+		lease.InterfaceID = iface.ID
+		lease.Hostname = client.Name
+		lease.LeasedAt = now
+		lease.ExpiresAt = now + float64(link.pool.LeaseTime)*1000
+		if err := s.bindLease(client, iface, lease, prefixLength, gateway); err != nil {
+			return exchange, err
+		}
+		leaseState(lease, now)
+
+		exchange.Status = "bound"
+		exchange.Server = link.iface.IPv4Address
+		exchange.Lease = lease
+		exchange.PrefixLength = prefixLength
+		exchange.Gateway = gateway
+		exchange.DNSServers = dhcpDNSServers(link.pool.DNSServers)
+		return exchange, s.reconverge()
+	}
+	return exchange, fmt.Errorf("interface %s did not obtain a DHCP lease: %w", iface.Name, lastErr)
+}
+
+// bindLease сохраняет аренду и назначает ее адрес клиентскому интерфейсу.
+// Прежние аренды интерфейса освобождаются.
+func (s *DeviceService) bindLease(client *models.Router, iface *models.Interface, lease *models.DHCPLease, prefixLength int, gateway string) error {
+	previous, err := s.repo.GetDHCPLeasesByInterfaceID(iface.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get DHCP leases: %w", err)
+	}
+	if err := s.repo.SaveDHCPLease(lease); err != nil {
+		return fmt.Errorf("failed to save DHCP lease: %w", err)
+	}
+	for _, old := range previous {
+		if old.ID == lease.ID {
+			continue
+		}
+		if err := s.repo.DeleteDHCPLease(old.RouterID, old.ID); err != nil {
+			return fmt.Errorf("failed to release DHCP lease: %w", err)
+		}
+	}
+
+	// Адресом хоста без собственного адреса служит аренда интерфейса
+	leasedAddress := client.IPAddress == "" || client.IPAddress == iface.IPv4Address
+
+	iface.IPv4Address = lease.IPAddress
+	iface.IPv4PrefixLength = prefixLength
+	if err := s.repo.UpdateInterface(iface); err != nil {
+		return fmt.Errorf("failed to address interface %s: %w", iface.Name, err)
+	}
+	if client.Type != models.DeviceTypeHost {
+		return nil
+	}
+	updates := map[string]interface{}{"default_gateway": gateway}
+	if leasedAddress {
+		updates["ip_address"] = lease.IPAddress
+	}
+	if err := s.repo.UpdateRouterConfig(client.ID, updates); err != nil {
+		return fmt.Errorf("failed to update host configuration: %w", err)
+	}
+	if leasedAddress {
+		client.IPAddress = lease.IPAddress
+	}
+	return nil
+}
+
+// releaseLeases освобождает аренды интерфейса и снимает с него адрес.
+// Шлюз хоста, оставшийся вне подсетей его интерфейсов, сбрасывается.
+func (s *DeviceService) releaseLeases(client *models.Router, iface *models.Interface) error {
+	if err := s.repo.DeleteDHCPLeasesByInterfaceID(iface.ID); err != nil {
+		return fmt.Errorf("failed to release DHCP leases: %w", err)
+	}
+	leasedAddress := iface.IPv4Address != "" && client.IPAddress == iface.IPv4Address
+	iface.IPv4Address = ""
+	iface.IPv4PrefixLength = 0
+	if err := s.repo.UpdateInterface(iface); err != nil {
+		return fmt.Errorf("failed to update interface %s: %w", iface.Name, err)
+	}
+	if client.Type != models.DeviceTypeHost {
+		return nil
+	}
+
+	interfaces := make([]models.Interface, 0, len(client.Interfaces))
+	for _, other := range client.Interfaces {
+		if other.ID != iface.ID {
+			interfaces = append(interfaces, other)
+		}
+	}
+	updates := make(map[string]interface{})
+	if client.DefaultGateway != "" && validateGateway(client.DefaultGateway, interfaces) != nil {
+		updates["default_gateway"] = ""
+	}
+	// Хост, адресом которого была аренда, берет адрес другого интерфейса или остается без адреса
+	if leasedAddress {
+		updates["ip_address"] = ""
+		for _, other := range interfaces {
+			if other.IPv4Address != "" {
+				updates["ip_address"] = other.IPv4Address
+				break
+			}
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := s.repo.UpdateRouterConfig(client.ID, updates); err != nil {
+		return fmt.Errorf("failed to update host configuration: %w", err)
+	}
+	return nil
+}
+
+// bindDHCPClients повторяет обмен DORA для клиентских интерфейсов без адреса,
+// например после подключения клиента к сегменту или создания пула.
+// Клиенты, не нашедшие сервер, остаются без адреса.
+func (s *DeviceService) bindDHCPClients() {
+	interfaces, err := s.repo.GetUnaddressedDHCPClients()
+	if err != nil {
+		return
+	}
+	for i := range interfaces {
+		iface := &interfaces[i]
+		if iface.OperStatus != models.InterfaceStatusUp {
+			continue
+		}
+		client, err := s.repo.GetRouterByID(iface.RouterID)
+		if err != nil {
+			continue
+		}
+		s.obtainLease(client, iface)
+	}
+}
+
+// getDHCPClient возвращает устройство и его интерфейс, получающий адрес по DHCP
+func (s *DeviceService) getDHCPClient(routerID, ifaceID uint) (*models.Router, *models.Interface, error) {
+	client, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("router not found: %w", err)
+	}
+	iface, err := s.repo.GetInterface(routerID, ifaceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("interface not found: %w", err)
+	}
+	if !iface.DHCPClient {
+		return nil, nil, fmt.Errorf("interface %s is not a DHCP client", iface.Name)
+	}
+	return client, iface, nil
+}
+
+func (s *DeviceService) GetDHCPPools(routerID uint) ([]models.DHCPPool, error) {
+	if _, err := s.getDHCPServer(routerID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDHCPPoolsByRouterID(routerID)
+}
+
+func (s *DeviceService) CreateDHCPPool(routerID uint, req *models.CreateDHCPPoolRequest) (*models.DHCPPool, error) {
+	router, err := s.getDHCPServer(routerID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.GetDHCPPoolsByRouterID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DHCP pools: %w", err)
+	}
+
+	pool := &models.DHCPPool{
+		RouterID:   routerID,
+		Name:       req.Name,
+		Subnet:     req.Subnet,
+		RangeStart: req.RangeStart,
+		RangeEnd:   req.RangeEnd,
+		Gateway:    req.Gateway,
+		DNSServers: req.DNSServers,
+		LeaseTime:  req.LeaseTime,
+	}
+	if err := validateDHCPPool(router, pool, existing); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateDHCPPool(pool); err != nil {
+		return nil, fmt.Errorf("failed to create DHCP pool: %w", err)
+	}
+
+	// Клиенты сегмента, ожидающие сервер, получают адреса нового пула
+	s.bindDHCPClients()
+	return pool, nil
+}
+
+// DeleteDHCPPool удаляет пул вместе с арендами. Клиенты сохраняют полученные
+// адреса, пока не обновят аренду.
+func (s *DeviceService) DeleteDHCPPool(routerID, id uint) error {
+	if _, err := s.getDHCPServer(routerID); err != nil {
+		return err
+	}
+	return s.repo.DeleteDHCPPool(routerID, id)
+}
+
+// GetDHCPLeases возвращает таблицу аренд DHCP сервера с оставшимся временем аренды
+func (s *DeviceService) GetDHCPLeases(routerID uint) ([]models.DHCPLease, error) {
+	if _, err := s.getDHCPServer(routerID); err != nil {
+		return nil, err
+	}
+	leases, err := s.repo.GetDHCPLeasesByRouterID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DHCP leases: %w", err)
+	}
+	now := s.clock.time()
+	for i := range leases {
+		leaseState(&leases[i], now)
+	}
+	return leases, nil
+}
+
+// DeleteDHCPLease удаляет аренду из таблицы сервера. Как и clear ip dhcp binding,
+// адрес с интерфейса клиента не снимается.
+func (s *DeviceService) DeleteDHCPLease(routerID, id uint) error {
+	if _, err := s.getDHCPServer(routerID); err != nil {
+		return err
+	}
+	return s.repo.DeleteDHCPLease(routerID, id)
+}
+
+// RenewDHCPLease повторяет обмен DORA клиентского интерфейса, продлевая аренду.
+// Неудачный обмен возвращается с описанием ошибки и отправленными сообщениями.
+func (s *DeviceService) RenewDHCPLease(routerID, ifaceID uint) (*models.DHCPExchange, error) {
+	client, iface, err := s.getDHCPClient(routerID, ifaceID)
+	if err != nil {
+		return nil, err
+	}
+	if iface.OperStatus != models.InterfaceStatusUp {
+		return nil, fmt.Errorf("interface %s is down", iface.Name)
+	}
+	exchange, err := s.obtainLease(client, iface)
+	if exchange == nil {
+		return nil, err
+	}
+	if err != nil {
+		exchange.Error = err.Error()
+	}
+	return exchange, nil
+}
+
+// ReleaseDHCPLease отправляет серверу DHCPRELEASE, освобождает аренды интерфейса
+// и снимает с него адрес
+func (s *DeviceService) ReleaseDHCPLease(routerID, ifaceID uint) (*models.DHCPExchange, error) {
+	client, iface, err := s.getDHCPClient(routerID, ifaceID)
+	if err != nil {
+		return nil, err
+	}
+	leases, err := s.repo.GetDHCPLeasesByInterfaceID(iface.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DHCP leases: %w", err)
+	}
+	if len(leases) == 0 {
+		return nil, fmt.Errorf("interface %s has no DHCP lease", iface.Name)
+	}
+	topo, err := s.loadTopology()
+	if err != nil {
+		return nil, err
+	}
+
+	exchange := &models.DHCPExchange{
+		Interface:  iface.Name,
+		MACAddress: iface.MACAddress,
+		Status:     "released",
+		Messages:   []models.DHCPMessage{},
+	}
+	for _, lease := range leases {
+		server := topo.routers[lease.RouterID]
+		if server == nil {
+			continue
+		}
+		for _, link := range topo.links[client.ID] {
+			local := connectionInterface(client, link.conn)
+			serving := connectionInterface(server, link.conn)
+			if link.to != server.ID || local == nil || local.ID != iface.ID || serving == nil {
+				continue
+			}
+			exchange.Messages = append(exchange.Messages,
+				topo.dhcpMessage(models.DHCPRelease, client, server, link.conn, iface.IPv4Address, serving.IPv4Address, ""))
+			exchange.Server = serving.IPv4Address
+			break
+		}
+	}
+
+	if err := s.releaseLeases(client, iface); err != nil {
+		return nil, err
+	}
+	return exchange, s.reconverge()
+}
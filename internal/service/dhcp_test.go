@@ -0,0 +1,180 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"network/internal/models"
+)
+
+// dhcpTestServer создает роутер с интерфейсом Gi0/0 192.168.1.1/24 и пулом DHCP в его подсети
+func dhcpTestServer(t *testing.T, s *DeviceService, rangeStart, rangeEnd string) *models.Router {
+	t.Helper()
+	server, err := s.CreateRouter(&models.CreateRouterRequest{
+		Name:      "R1",
+		IPAddress: "10.0.0.1",
+		Interfaces: []models.CreateInterfaceRequest{
+			{Name: "Gi0/0", IPv4Address: "192.168.1.1", IPv4PrefixLength: 24},
+			{Name: "Gi0/1", IPv4Address: "192.168.2.1", IPv4PrefixLength: 24},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create DHCP server: %v", err)
+	}
+	if _, err := s.CreateDHCPPool(server.ID, &models.CreateDHCPPoolRequest{
+		Name:       "lan",
+		Subnet:     "192.168.1.0/24",
+		RangeStart: rangeStart,
+		RangeEnd:   rangeEnd,
+	}); err != nil {
+		t.Fatalf("create DHCP pool: %v", err)
+	}
+	return server
+}
+
+// mustCreateDHCPHost создает хост, единственный интерфейс eth0 которого получает адрес по DHCP
+func mustCreateDHCPHost(t *testing.T, s *DeviceService, name string) *models.Router {
+	t.Helper()
+	host, err := s.CreateRouter(&models.CreateRouterRequest{
+		Name:       name,
+		Type:       models.DeviceTypeHost,
+		Interfaces: []models.CreateInterfaceRequest{{Name: "eth0", DHCPClient: true}},
+	})
+	if err != nil {
+		t.Fatalf("create host %s: %v", name, err)
+	}
+	return host
+}
+
+// mustCableToServer подключает eth0 хоста к первому свободному интерфейсу сервера
+func mustCableToServer(t *testing.T, s *DeviceService, host, server *models.Router) *models.Router {
+	t.Helper()
+	if _, err := s.CreateConnection(&models.CreateConnectionRequest{
+		RouterFromID: host.ID,
+		RouterToID:   server.ID,
+		ToInterface:  "Gi0/0",
+	}); err != nil {
+		t.Fatalf("connect %s to %s: %v", host.Name, server.Name, err)
+	}
+	bound, err := s.repo.GetRouterByID(host.ID)
+	if err != nil {
+		t.Fatalf("get host %s: %v", host.Name, err)
+	}
+	return bound
+}
+
+func TestDHCPOnlyHostTakesLeaseAddress(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	server := dhcpTestServer(t, s, "192.168.1.100", "192.168.1.110")
+
+	host := mustCreateDHCPHost(t, s, "H1")
+	if host.IPAddress != "" {
+		t.Fatalf("DHCP-only host got address %s before a lease", host.IPAddress)
+	}
+
+	host = mustCableToServer(t, s, host, server)
+	if host.IPAddress != "192.168.1.100" {
+		t.Fatalf("host address = %q, want the leased 192.168.1.100", host.IPAddress)
+	}
+	if host.DefaultGateway != "192.168.1.1" {
+		t.Errorf("host gateway = %q, want 192.168.1.1", host.DefaultGateway)
+	}
+
+	// Ответы на ping приходят с адреса аренды, а хост отправляет пакеты с него же
+	for _, tt := range []struct {
+		name     string
+		source   string
+		dest     string
+		wantFrom string
+	}{
+		{name: "to host", source: server.IPAddress, dest: "192.168.1.100", wantFrom: "192.168.1.100"},
+		{name: "from host", source: "192.168.1.100", dest: server.IPAddress, wantFrom: server.IPAddress},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.PingIP(&models.PingRequest{IPAddress: tt.dest, SourceIP: tt.source, Count: 1})
+			if err != nil {
+				t.Fatalf("ping: %v", err)
+			}
+			if result.Status != "success" || result.Probes[0].From != tt.wantFrom {
+				t.Errorf("ping = %s from %s, want success from %s", result.Status, result.Probes[0].From, tt.wantFrom)
+			}
+		})
+	}
+
+	// После освобождения аренды у хоста снова нет адреса
+	if _, err := s.ReleaseDHCPLease(host.ID, host.Interfaces[0].ID); err != nil {
+		t.Fatalf("release lease: %v", err)
+	}
+	released, err := s.repo.GetRouterByID(host.ID)
+	if err != nil {
+		t.Fatalf("get host: %v", err)
+	}
+	if released.IPAddress != "" || released.DefaultGateway != "" {
+		t.Errorf("host after release = %q via %q, want no address and gateway", released.IPAddress, released.DefaultGateway)
+	}
+}
+
+func TestDHCPPoolExhaustionAndLeaseExpiry(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	server := dhcpTestServer(t, s, "192.168.1.100", "192.168.1.100")
+
+	first := mustCableToServer(t, s, mustCreateDHCPHost(t, s, "H1"), server)
+	if first.IPAddress != "192.168.1.100" {
+		t.Fatalf("H1 address = %q, want the only pool address 192.168.1.100", first.IPAddress)
+	}
+
+	// Отключенный H1 сохраняет аренду, и следующему клиенту адреса не остается
+	conn, err := s.repo.GetConnectionByInterface(first.Interfaces[0].ID)
+	if err != nil {
+		t.Fatalf("get H1 connection: %v", err)
+	}
+	if err := s.DeleteConnection(conn.ID); err != nil {
+		t.Fatalf("disconnect H1: %v", err)
+	}
+	second := mustCableToServer(t, s, mustCreateDHCPHost(t, s, "H2"), server)
+	if second.IPAddress != "" {
+		t.Fatalf("H2 got address %s from an exhausted pool", second.IPAddress)
+	}
+	exchange, err := s.RenewDHCPLease(second.ID, second.Interfaces[0].ID)
+	if err != nil {
+		t.Fatalf("renew H2: %v", err)
+	}
+	if exchange.Status != "failed" || !strings.Contains(exchange.Error, "no free addresses") {
+		t.Errorf("H2 exchange = %s (%s), want failed with no free addresses", exchange.Status, exchange.Error)
+	}
+
+	// Аренда истекает по виртуальным часам
+	currentLease := func() (models.DHCPLeaseState, int) {
+		leases, err := s.GetDHCPLeases(server.ID)
+		if err != nil {
+			t.Fatalf("get leases: %v", err)
+		}
+		if len(leases) != 1 || leases[0].Hostname != "H1" {
+			t.Fatalf("leases = %+v, want the lease of H1", leases)
+		}
+		return leases[0].State, leases[0].Remaining
+	}
+	timeout := float64(defaultLeaseTime) * 1000
+	mustRunClock(t, s, timeout-1000)
+	if state, remaining := currentLease(); state != models.DHCPLeaseActive || remaining != 1 {
+		t.Errorf("lease before expiry = %s with %d s left, want active with 1 s", state, remaining)
+	}
+	mustRunClock(t, s, timeout)
+	if state, remaining := currentLease(); state != models.DHCPLeaseExpired || remaining != 0 {
+		t.Errorf("lease after expiry = %s with %d s left, want expired", state, remaining)
+	}
+
+	// Освобожденный адрес достается ожидающему клиенту
+	if _, err := s.ReleaseDHCPLease(first.ID, first.Interfaces[0].ID); err != nil {
+		t.Fatalf("release H1: %v", err)
+	}
+	exchange, err = s.RenewDHCPLease(second.ID, second.Interfaces[0].ID)
+	if err != nil {
+		t.Fatalf("renew H2: %v", err)
+	}
+	if exchange.Status != "bound" || exchange.Lease.IPAddress != "192.168.1.100" {
+		t.Errorf("H2 exchange = %s (%s), want bound to 192.168.1.100", exchange.Status, exchange.Error)
+	}
+}
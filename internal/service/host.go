@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"network/internal/models"
+)
+
+// Интерфейс хоста по умолчанию и маска его подсети
+const (
+	defaultHostInterface = "eth0"
+	defaultHostNetmask   = "255.255.255.0"
+)
+
+// parseNetmask преобразует маску подсети вида 255.255.255.0 в длину префикса
+func parseNetmask(netmask string) (int, error) {
+	ip := net.ParseIP(netmask).To4()
+	if ip == nil {
+		return 0, fmt.Errorf("invalid netmask: %s", netmask)
+	}
+	ones, bits := net.IPMask(ip).Size()
+	if bits == 0 || ones == 0 {
+		return 0, fmt.Errorf("invalid netmask: %s", netmask)
+	}
+	return ones, nil
+}
+
+// defaultHostInterfaces возвращает интерфейс eth0 с адресом хоста
+func defaultHostInterfaces(ip string, prefixLength int) []models.CreateInterfaceRequest {
+	return []models.CreateInterfaceRequest{{
+		Name:             defaultHostInterface,
+		IPv4Address:      ip,
+		IPv4PrefixLength: prefixLength,
+	}}
+}
+
+// validateGateway проверяет, что шлюз по умолчанию находится в подсети
+// одного из интерфейсов хоста и не совпадает с адресом самого хоста
+func validateGateway(gateway string, interfaces []models.Interface) error {
+	ip := net.ParseIP(gateway).To4()
+	if ip == nil {
+		return fmt.Errorf("invalid default gateway: %s", gateway)
+	}
+	for _, iface := range interfaces {
+		if iface.IPv4Address == "" {
+			continue
+		}
+		if iface.IPv4Address == ip.String() {
+			return fmt.Errorf("default gateway %s is an address of the host", gateway)
+		}
+		_, subnet, err := net.ParseCIDR(fmt.Sprintf("%s/%d", iface.IPv4Address, iface.IPv4PrefixLength))
+		if err == nil && subnet.Contains(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("default gateway %s is not in a subnet of the host interfaces", gateway)
+}
+
+// gatewayRoutes возвращает маршрут по умолчанию через шлюз хоста
+func gatewayRoutes(device *models.Router) []models.Route {
+	if device.Type != models.DeviceTypeHost || device.DefaultGateway == "" {
+		return nil
+	}
+	return []models.Route{{
+		RouterID:      device.ID,
+		Prefix:        "0.0.0.0/0",
+		NextHop:       device.DefaultGateway,
+		AdminDistance: defaultStaticDistance,
+		Protocol:      models.RouteProtocolStatic,
+	}}
+}
+
+// UpdateGateway меняет шлюз по умолчанию хоста
+func (s *DeviceService) UpdateGateway(id uint, req *models.UpdateGatewayRequest) (*models.Router, error) {
+	host, err := s.repo.GetRouterByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("host not found: %w", err)
+	}
+	if host.Type != models.DeviceTypeHost {
+		return nil, fmt.Errorf("device %s is not a host", host.IPAddress)
+	}
+
+	if req.DefaultGateway != "" {
+		if err := validateGateway(req.DefaultGateway, host.Interfaces); err != nil {
+			return nil, err
+		}
+		req.DefaultGateway = net.ParseIP(req.DefaultGateway).String()
+	}
+
+	host.DefaultGateway = req.DefaultGateway
+	if err := s.repo.UpdateRouterConfig(host.ID, map[string]interface{}{"default_gateway": host.DefaultGateway}); err != nil {
+		return nil, fmt.Errorf("failed to update default gateway: %w", err)
+	}
+	return host, nil
+}
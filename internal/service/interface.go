@@ -0,0 +1,593 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"network/internal/models"
+)
+
+// Интерфейсы, создаваемые для нового роутера, если они не заданы в запросе
+const defaultInterfaceCount = 4
+
+// Допустимые значения MTU
+const (
+	defaultMTU = 1500
+	minMTU     = 68
+	maxMTU     = 9216
+)
+
+// derivedMAC строит локально администрируемый unicast MAC-адрес интерфейса
+// из ID роутера (младшие 24 бита) и номера интерфейса. Адреса не зависят от случайности,
+// поэтому выборы корня STP по MAC повторяются от запуска к запуску.
+func derivedMAC(routerID uint, index int) string {
+	return net.HardwareAddr{
+		0x02,
+		byte(routerID >> 16), byte(routerID >> 8), byte(routerID),
+		byte(index >> 8), byte(index),
+	}.String()
+}
+
+// nextInterfaceMAC возвращает MAC-адрес с наименьшим номером, не занятым интерфейсами роутера
+func nextInterfaceMAC(router *models.Router) string {
+	taken := make(map[string]bool, len(router.Interfaces))
+	for _, iface := range router.Interfaces {
+		taken[iface.MACAddress] = true
+	}
+	for index := 0; ; index++ {
+		if mac := derivedMAC(router.ID, index); !taken[mac] {
+			return mac
+		}
+	}
+}
+
+// newInterface создает интерфейс из запроса, заполняя значения по умолчанию
+func newInterface(routerID uint, req *models.CreateInterfaceRequest) *models.Interface {
+	iface := &models.Interface{
+		RouterID:         routerID,
+		Name:             req.Name,
+		Type:             req.Type,
+		MACAddress:       req.MACAddress,
+		IPv4Address:      req.IPv4Address,
+		IPv4PrefixLength: req.IPv4PrefixLength,
+		IPv6Address:      req.IPv6Address,
+		IPv6PrefixLength: req.IPv6PrefixLength,
+		MTU:              req.MTU,
+		Speed:            req.Speed,
+		DuplexMode:       req.DuplexMode,
+		AdminStatus:      req.AdminStatus,
+		Description:      req.Description,
+		OSPFArea:         req.OSPFArea,
+		OSPFCost:         req.OSPFCost,
+		VLAN:             req.VLAN,
+		InboundACL:       req.InboundACL,
+		OutboundACL:      req.OutboundACL,
+		FirewallZone:     req.FirewallZone,
+		DHCPClient:       req.DHCPClient,
+		SwitchportMode:   req.SwitchportMode,
+		AccessVLAN:       req.AccessVLAN,
+		NativeVLAN:       req.NativeVLAN,
+		AllowedVLANs:     req.AllowedVLANs,
+	}
+
+	if iface.Type == "" {
+		iface.Type = models.InterfaceTypeEthernet
+	}
+	if iface.MTU == 0 {
+		iface.MTU = defaultMTU
+	}
+	if iface.Speed == "" {
+		iface.Speed = models.SpeedAuto
+	}
+	if iface.DuplexMode == "" {
+		iface.DuplexMode = models.DuplexModeAuto
+	}
+	if iface.AdminStatus == "" {
+		iface.AdminStatus = models.InterfaceStatusUp
+	}
+	return iface
+}
+
+// defaultInterfaces возвращает Ethernet интерфейсы Gi0/0..Gi0/N без адресов
+func defaultInterfaces() []models.CreateInterfaceRequest {
+	reqs := make([]models.CreateInterfaceRequest, 0, defaultInterfaceCount)
+	for i := 0; i < defaultInterfaceCount; i++ {
+		reqs = append(reqs, models.CreateInterfaceRequest{
+			Name: fmt.Sprintf("Gi0/%d", i),
+		})
+	}
+	return reqs
+}
+
+// validateInterface проверяет поля интерфейса и нормализует адреса
+func validateInterface(iface *models.Interface) error {
+	if iface.Name == "" {
+		return fmt.Errorf("interface name is required")
+	}
+
+	switch iface.Type {
+	case models.InterfaceTypeEthernet, models.InterfaceTypeLoopback, models.InterfaceTypeSubinterface:
+	default:
+		return fmt.Errorf("invalid interface type: %s", iface.Type)
+	}
+	if err := validateSubinterface(iface); err != nil {
+		return err
+	}
+
+	if iface.MACAddress != "" {
+		mac, err := net.ParseMAC(iface.MACAddress)
+		if err != nil || len(mac) != 6 {
+			return fmt.Errorf("invalid MAC address: %s", iface.MACAddress)
+		}
+		iface.MACAddress = mac.String()
+	}
+
+	if iface.IPv4Address != "" {
+		ip := net.ParseIP(iface.IPv4Address).To4()
+		if ip == nil {
+			return fmt.Errorf("invalid IPv4 address: %s", iface.IPv4Address)
+		}
+		if iface.IPv4PrefixLength < 1 || iface.IPv4PrefixLength > 32 {
+			return fmt.Errorf("invalid IPv4 prefix length: %d (must be 1-32)", iface.IPv4PrefixLength)
+		}
+		iface.IPv4Address = ip.String()
+	} else {
+		iface.IPv4PrefixLength = 0
+	}
+	if iface.DHCPClient && iface.Type == models.InterfaceTypeLoopback {
+		return fmt.Errorf("loopback interface %s cannot be a DHCP client", iface.Name)
+	}
+
+	if iface.IPv6Address != "" {
+		ip := net.ParseIP(iface.IPv6Address)
+		if ip == nil || ip.To4() != nil {
+			return fmt.Errorf("invalid IPv6 address: %s", iface.IPv6Address)
+		}
+		if iface.IPv6PrefixLength < 1 || iface.IPv6PrefixLength > 128 {
+			return fmt.Errorf("invalid IPv6 prefix length: %d (must be 1-128)", iface.IPv6PrefixLength)
+		}
+		iface.IPv6Address = ip.String()
+	} else {
+		iface.IPv6PrefixLength = 0
+	}
+
+	if iface.MTU < minMTU || iface.MTU > maxMTU {
+		return fmt.Errorf("invalid MTU: %d (must be %d-%d)", iface.MTU, minMTU, maxMTU)
+	}
+	if err := validateSpeed(iface.Speed); err != nil {
+		return err
+	}
+	if err := validateDuplexMode(iface.DuplexMode); err != nil {
+		return err
+	}
+
+	switch iface.AdminStatus {
+	case models.InterfaceStatusUp, models.InterfaceStatusDown:
+	default:
+		return fmt.Errorf("invalid admin status: %s", iface.AdminStatus)
+	}
+
+	if iface.OSPFArea != "" {
+		area, err := normalizeArea(iface.OSPFArea)
+		if err != nil {
+			return err
+		}
+		iface.OSPFArea = area
+	}
+	if iface.OSPFCost < 0 || iface.OSPFCost > maxOSPFCost {
+		return fmt.Errorf("invalid OSPF cost: %d (must be 0-%d)", iface.OSPFCost, maxOSPFCost)
+	}
+	return nil
+}
+
+// interfacePrefixes возвращает подсети, к которым подключен интерфейс
+func interfacePrefixes(iface *models.Interface) []string {
+	var prefixes []string
+	if iface.IPv4Address != "" {
+		if prefix, err := normalizePrefix(fmt.Sprintf("%s/%d", iface.IPv4Address, iface.IPv4PrefixLength)); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if iface.IPv6Address != "" {
+		if prefix, err := normalizePrefix(fmt.Sprintf("%s/%d", iface.IPv6Address, iface.IPv6PrefixLength)); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// operStatus вычисляет операционное состояние интерфейса. Loopback работает,
+// пока включен администратором; Ethernet — только если подключен активным
+// соединением к включенному интерфейсу соседа; подынтерфейс — пока работает родительский интерфейс.
+func (s *DeviceService) operStatus(iface *models.Interface) (models.InterfaceStatus, error) {
+	if iface.AdminStatus != models.InterfaceStatusUp {
+		return models.InterfaceStatusDown, nil
+	}
+	if iface.Type == models.InterfaceTypeLoopback {
+		return models.InterfaceStatusUp, nil
+	}
+	if iface.Type == models.InterfaceTypeSubinterface {
+		parent, err := s.repo.GetInterfaceByName(iface.RouterID, parentInterfaceName(iface.Name))
+		if err != nil || parent.OperStatus != models.InterfaceStatusUp {
+			return models.InterfaceStatusDown, nil
+		}
+		return models.InterfaceStatusUp, nil
+	}
+	if iface.ID == 0 {
+		return models.InterfaceStatusDown, nil
+	}
+
+	conn, err := s.repo.GetConnectionByInterface(iface.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get connection: %w", err)
+	}
+	if conn == nil || conn.Status != "active" {
+		return models.InterfaceStatusDown, nil
+	}
+
+	peerID := conn.ToInterfaceID
+	if peerID == iface.ID {
+		peerID = conn.FromInterfaceID
+	}
+	peer, err := s.repo.GetInterfaceByID(peerID)
+	if err != nil || peer.AdminStatus != models.InterfaceStatusUp {
+		return models.InterfaceStatusDown, nil
+	}
+	return models.InterfaceStatusUp, nil
+}
+
+// syncOperStatus пересчитывает и сохраняет операционное состояние интерфейсов
+// и их подынтерфейсов
+func (s *DeviceService) syncOperStatus(ids ...uint) error {
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		iface, err := s.repo.GetInterfaceByID(id)
+		if err != nil {
+			continue
+		}
+		status, err := s.operStatus(iface)
+		if err != nil {
+			return err
+		}
+		if status != iface.OperStatus {
+			if err := s.repo.UpdateInterfaceOperStatus(id, status); err != nil {
+				return fmt.Errorf("failed to update interface status: %w", err)
+			}
+		}
+		if iface.Type != models.InterfaceTypeEthernet {
+			continue
+		}
+
+		subs, err := s.repo.GetSubinterfaces(iface.RouterID, iface.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get subinterfaces: %w", err)
+		}
+		subIDs := make([]uint, 0, len(subs))
+		for _, sub := range subs {
+			subIDs = append(subIDs, sub.ID)
+		}
+		if err := s.syncOperStatus(subIDs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSubinterface проверяет, что у подынтерфейса есть родительский Ethernet интерфейс,
+// а его тег VLAN не занят другим подынтерфейсом того же интерфейса
+func (s *DeviceService) checkSubinterface(iface *models.Interface) error {
+	if iface.Type != models.InterfaceTypeSubinterface {
+		return nil
+	}
+	name := parentInterfaceName(iface.Name)
+	parent, err := s.repo.GetInterfaceByName(iface.RouterID, name)
+	if err != nil || parent.Type != models.InterfaceTypeEthernet {
+		return fmt.Errorf("parent Ethernet interface %s not found", name)
+	}
+
+	subs, err := s.repo.GetSubinterfaces(iface.RouterID, name)
+	if err != nil {
+		return fmt.Errorf("failed to get subinterfaces: %w", err)
+	}
+	for _, sub := range subs {
+		if sub.ID != iface.ID && sub.VLAN == iface.VLAN {
+			return fmt.Errorf("VLAN %d is already used by subinterface %s", iface.VLAN, sub.Name)
+		}
+	}
+	return nil
+}
+
+// peerInterfaceID возвращает интерфейс на другом конце соединения или 0
+func (s *DeviceService) peerInterfaceID(ifaceID uint) uint {
+	conn, err := s.repo.GetConnectionByInterface(ifaceID)
+	if err != nil || conn == nil {
+		return 0
+	}
+	if conn.FromInterfaceID == ifaceID {
+		return conn.ToInterfaceID
+	}
+	return conn.FromInterfaceID
+}
+
+// cableInterface выбирает интерфейс роутера для нового соединения: заданный по
+// имени или первый свободный Ethernet интерфейс. Занятый интерфейс отклоняется.
+func (s *DeviceService) cableInterface(router *models.Router, name string) (*models.Interface, error) {
+	if name != "" {
+		iface, err := s.repo.GetInterfaceByName(router.ID, name)
+		if err != nil {
+			return nil, fmt.Errorf("interface %s not found on router %s", name, router.IPAddress)
+		}
+		if iface.Type != models.InterfaceTypeEthernet {
+			return nil, fmt.Errorf("interface %s cannot be cabled", name)
+		}
+		conn, err := s.repo.GetConnectionByInterface(iface.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get connection: %w", err)
+		}
+		if conn != nil {
+			return nil, fmt.Errorf("interface %s on router %s is already cabled", name, router.IPAddress)
+		}
+		return iface, nil
+	}
+
+	for i := range router.Interfaces {
+		iface := &router.Interfaces[i]
+		if iface.Type != models.InterfaceTypeEthernet {
+			continue
+		}
+		conn, err := s.repo.GetConnectionByInterface(iface.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get connection: %w", err)
+		}
+		if conn == nil {
+			return iface, nil
+		}
+	}
+	return nil, fmt.Errorf("router %s has no free interfaces", router.IPAddress)
+}
+
+// checkInterfaceConflicts проверяет уникальность имени интерфейса и его адресов
+func (s *DeviceService) checkInterfaceConflicts(iface *models.Interface) error {
+	if s.repo.InterfaceNameExists(iface.RouterID, iface.Name, iface.ID) {
+		return fmt.Errorf("interface %s already exists", iface.Name)
+	}
+	for _, ip := range []string{iface.IPv4Address, iface.IPv6Address} {
+		if ip == "" {
+			continue
+		}
+		if s.repo.InterfaceIPExists(ip, iface.ID) {
+			return fmt.Errorf("IP address %s is already in use", ip)
+		}
+		// Адрес управления самого роутера можно назначить его интерфейсу
+		if router, err := s.repo.GetRouterByIP(ip); err == nil && router.ID != iface.RouterID {
+			return fmt.Errorf("IP address %s is already in use", ip)
+		}
+	}
+	return nil
+}
+
+// createInterface проверяет и сохраняет новый интерфейс роутера
+func (s *DeviceService) createInterface(router *models.Router, req *models.CreateInterfaceRequest) (*models.Interface, error) {
+	var err error
+	iface := newInterface(router.ID, req)
+	if err := validateInterface(iface); err != nil {
+		return nil, err
+	}
+	if iface.MACAddress == "" && iface.Type == models.InterfaceTypeEthernet {
+		iface.MACAddress = nextInterfaceMAC(router)
+	}
+	if iface.DHCPClient && iface.IPv4Address != "" {
+		return nil, fmt.Errorf("interface %s obtains its IPv4 address from DHCP", iface.Name)
+	}
+	if err := validateDeviceInterface(router.Type, iface); err != nil {
+		return nil, err
+	}
+	if err := s.checkInterfaceConflicts(iface); err != nil {
+		return nil, err
+	}
+	if err := s.checkSubinterface(iface); err != nil {
+		return nil, err
+	}
+	if err := s.checkInterfaceACLs(iface); err != nil {
+		return nil, err
+	}
+	if iface.OperStatus, err = s.operStatus(iface); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateInterface(iface); err != nil {
+		return nil, fmt.Errorf("failed to create interface: %w", err)
+	}
+	return iface, nil
+}
+
+func (s *DeviceService) GetInterfaces(routerID uint) ([]models.Interface, error) {
+	if _, err := s.repo.GetRouterByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	return s.repo.GetInterfacesByRouterID(routerID)
+}
+
+func (s *DeviceService) CreateInterface(routerID uint, req *models.CreateInterfaceRequest) (*models.Interface, error) {
+	router, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	iface, err := s.createInterface(router, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
+	return iface, nil
+}
+
+func (s *DeviceService) UpdateInterface(routerID, id uint, req *models.UpdateInterfaceRequest) (*models.Interface, error) {
+	router, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	iface, err := s.repo.GetInterface(routerID, id)
+	if err != nil {
+		return nil, fmt.Errorf("interface not found: %w", err)
+	}
+	subs, err := s.repo.GetSubinterfaces(routerID, iface.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subinterfaces: %w", err)
+	}
+
+	if req.Name != nil {
+		if *req.Name != iface.Name && iface.Type == models.InterfaceTypeEthernet && len(subs) > 0 {
+			return nil, fmt.Errorf("interface %s has subinterfaces and cannot be renamed", iface.Name)
+		}
+		iface.Name = *req.Name
+	}
+	if req.MACAddress != nil {
+		iface.MACAddress = *req.MACAddress
+	}
+	// Адрес DHCP клиента назначает сервер: включение DHCP снимает адрес, выключение освобождает аренду
+	wasDHCPClient := iface.DHCPClient
+	if req.DHCPClient != nil {
+		iface.DHCPClient = *req.DHCPClient
+	}
+	if wasDHCPClient != iface.DHCPClient {
+		iface.IPv4Address = ""
+		iface.IPv4PrefixLength = 0
+	}
+	if req.IPv4Address != nil {
+		if iface.DHCPClient && *req.IPv4Address != "" {
+			return nil, fmt.Errorf("interface %s obtains its IPv4 address from DHCP", iface.Name)
+		}
+		iface.IPv4Address = *req.IPv4Address
+	}
+	if req.IPv4PrefixLength != nil {
+		iface.IPv4PrefixLength = *req.IPv4PrefixLength
+	}
+	if req.IPv6Address != nil {
+		iface.IPv6Address = *req.IPv6Address
+	}
+	if req.IPv6PrefixLength != nil {
+		iface.IPv6PrefixLength = *req.IPv6PrefixLength
+	}
+	if req.MTU != nil {
+		iface.MTU = *req.MTU
+	}
+	if req.Speed != nil {
+		iface.Speed = *req.Speed
+	}
+	if req.DuplexMode != nil {
+		iface.DuplexMode = *req.DuplexMode
+	}
+	if req.AdminStatus != nil {
+		iface.AdminStatus = *req.AdminStatus
+	}
+	if req.Description != nil {
+		iface.Description = *req.Description
+	}
+	if req.OSPFArea != nil {
+		iface.OSPFArea = *req.OSPFArea
+	}
+	if req.OSPFCost != nil {
+		iface.OSPFCost = *req.OSPFCost
+	}
+	if req.VLAN != nil {
+		iface.VLAN = *req.VLAN
+	}
+	if req.InboundACL != nil {
+		iface.InboundACL = *req.InboundACL
+	}
+	if req.OutboundACL != nil {
+		iface.OutboundACL = *req.OutboundACL
+	}
+	if req.FirewallZone != nil {
+		iface.FirewallZone = *req.FirewallZone
+	}
+	if req.SwitchportMode != nil {
+		iface.SwitchportMode = *req.SwitchportMode
+	}
+	if req.AccessVLAN != nil {
+		iface.AccessVLAN = *req.AccessVLAN
+	}
+	if req.NativeVLAN != nil {
+		iface.NativeVLAN = *req.NativeVLAN
+	}
+	if req.AllowedVLANs != nil {
+		iface.AllowedVLANs = *req.AllowedVLANs
+	}
+
+	if err := validateInterface(iface); err != nil {
+		return nil, err
+	}
+	if err := validateDeviceInterface(router.Type, iface); err != nil {
+		return nil, err
+	}
+	if err := s.checkInterfaceConflicts(iface); err != nil {
+		return nil, err
+	}
+	if err := s.checkSubinterface(iface); err != nil {
+		return nil, err
+	}
+	if err := s.checkInterfaceACLs(iface); err != nil {
+		return nil, err
+	}
+	if iface.OperStatus, err = s.operStatus(iface); err != nil {
+		return nil, err
+	}
+
+	if wasDHCPClient != iface.DHCPClient {
+		if err := s.repo.DeleteDHCPLeasesByInterfaceID(iface.ID); err != nil {
+			return nil, fmt.Errorf("failed to release DHCP leases: %w", err)
+		}
+	}
+	if err := s.repo.UpdateInterface(iface); err != nil {
+		return nil, fmt.Errorf("failed to update interface: %w", err)
+	}
+
+	// Выключение интерфейса опускает соединение и на стороне соседа, а также подынтерфейсы
+	if err := s.syncOperStatus(iface.ID, s.peerInterfaceID(iface.ID)); err != nil {
+		return nil, err
+	}
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
+	if iface.DHCPClient && iface.IPv4Address == "" {
+		s.bindDHCPClients()
+		if iface, err = s.repo.GetInterface(routerID, id); err != nil {
+			return nil, fmt.Errorf("interface not found: %w", err)
+		}
+	}
+	return iface, nil
+}
+
+func (s *DeviceService) DeleteInterface(routerID, id uint) error {
+	iface, err := s.repo.GetInterface(routerID, id)
+	if err != nil {
+		return fmt.Errorf("interface not found: %w", err)
+	}
+
+	conn, err := s.repo.GetConnectionByInterface(id)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	if conn != nil {
+		return fmt.Errorf("interface %s is cabled to connection %d, delete the connection first", iface.Name, conn.ID)
+	}
+	if iface.Type == models.InterfaceTypeEthernet {
+		subs, err := s.repo.GetSubinterfaces(routerID, iface.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get subinterfaces: %w", err)
+		}
+		if len(subs) > 0 {
+			return fmt.Errorf("interface %s has subinterfaces, delete them first", iface.Name)
+		}
+	}
+	if err := s.repo.DeleteInterface(routerID, id); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteDHCPLeasesByInterfaceID(id); err != nil {
+		return fmt.Errorf("failed to release DHCP leases: %w", err)
+	}
+	return s.reconverge()
+}
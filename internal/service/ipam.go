@@ -0,0 +1,579 @@
+package service
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"network/internal/models"
+	"network/internal/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Пул, из которого выделяются адреса роутеров, если пул не указан явно
+const (
+	defaultPoolName   = "default"
+	defaultPoolSubnet = "192.168.0.0/16"
+)
+
+// Пул транзитных подсетей соединений, создаваемый, если нет ни одного пула для соединений
+const (
+	defaultLinkPoolName         = "links"
+	defaultLinkPoolSubnet       = "10.255.0.0/16"
+	defaultLinkPoolPrefixLength = 31
+)
+
+// randomAllocationAttempts — число случайных попыток перед последовательным поиском
+const randomAllocationAttempts = 100
+
+type IPAMService struct {
+	repo    *repository.IPAMRepository
+	devices *repository.DeviceRepository
+}
+
+func NewIPAMService(repo *repository.IPAMRepository, devices *repository.DeviceRepository) *IPAMService {
+	return &IPAMService{
+		repo:    repo,
+		devices: devices,
+	}
+}
+
+// subnetBounds возвращает адрес сети и широковещательный адрес IPv4 подсети
+func subnetBounds(subnet string) (uint32, uint32, error) {
+	_, network, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid subnet: %s", subnet)
+	}
+	ip := network.IP.To4()
+	if ip == nil {
+		return 0, 0, fmt.Errorf("only IPv4 subnets are supported: %s", subnet)
+	}
+
+	ones, bits := network.Mask.Size()
+	first := binary.BigEndian.Uint32(ip)
+	return first, first + uint32(uint64(1)<<(bits-ones)-1), nil
+}
+
+// poolRange возвращает первый и последний назначаемые адреса подсети.
+// Для подсетей крупнее /31 адрес сети и широковещательный адрес исключаются.
+func poolRange(subnet string) (uint32, uint32, error) {
+	first, last, err := subnetBounds(subnet)
+	if err != nil {
+		return 0, 0, err
+	}
+	if last-first > 1 {
+		first++
+		last--
+	}
+	return first, last, nil
+}
+
+func uint32ToIP(v uint32) string {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, v)
+	return ip.String()
+}
+
+// isTaken проверяет, занят ли адрес выделением или роутером
+func (s *IPAMService) isTaken(ip string) bool {
+	return s.repo.IsAllocated(ip) || s.devices.IsIPTaken(ip)
+}
+
+func (s *IPAMService) CreatePool(req *models.CreateIPPoolRequest) (*models.IPPoolInfo, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("pool name is required")
+	}
+	subnet, err := normalizePrefix(req.Subnet)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := poolRange(subnet); err != nil {
+		return nil, err
+	}
+
+	mode := req.Allocation
+	if mode == "" {
+		mode = models.AllocationSequential
+	}
+	if mode != models.AllocationSequential && mode != models.AllocationRandom {
+		return nil, fmt.Errorf("invalid allocation mode: %s", mode)
+	}
+
+	purpose := req.Purpose
+	if purpose == "" {
+		purpose = models.PoolPurposeHost
+	}
+	linkPrefixLength := 0
+	switch purpose {
+	case models.PoolPurposeHost:
+	case models.PoolPurposeLink:
+		linkPrefixLength = req.LinkPrefixLength
+		if linkPrefixLength == 0 {
+			linkPrefixLength = defaultLinkPoolPrefixLength
+		}
+		if linkPrefixLength != 30 && linkPrefixLength != 31 {
+			return nil, fmt.Errorf("invalid link prefix length: %d (must be 30 or 31)", linkPrefixLength)
+		}
+		_, network, _ := net.ParseCIDR(subnet)
+		if ones, _ := network.Mask.Size(); ones > linkPrefixLength {
+			return nil, fmt.Errorf("subnet %s is smaller than /%d", subnet, linkPrefixLength)
+		}
+	default:
+		return nil, fmt.Errorf("invalid pool purpose: %s", purpose)
+	}
+
+	// Пулы не должны пересекаться, иначе один адрес можно выделить дважды
+	pools, err := s.repo.GetAllPools()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pools: %w", err)
+	}
+	_, network, _ := net.ParseCIDR(subnet)
+	for _, pool := range pools {
+		_, existing, err := net.ParseCIDR(pool.Subnet)
+		if err != nil {
+			continue
+		}
+		if existing.Contains(network.IP) || network.Contains(existing.IP) {
+			return nil, fmt.Errorf("subnet %s overlaps pool %s (%s)", subnet, pool.Name, pool.Subnet)
+		}
+	}
+
+	pool := &models.IPPool{
+		Name:             req.Name,
+		Subnet:           subnet,
+		Purpose:          purpose,
+		Allocation:       mode,
+		LinkPrefixLength: linkPrefixLength,
+		Description:      req.Description,
+	}
+	if err := s.repo.CreatePool(pool); err != nil {
+		return nil, fmt.Errorf("failed to create pool: %w", err)
+	}
+
+	return s.poolInfo(pool)
+}
+
+// poolInfo дополняет пул сведениями о заполненности
+func (s *IPAMService) poolInfo(pool *models.IPPool) (*models.IPPoolInfo, error) {
+	first, last, err := poolRange(pool.Subnet)
+	if err != nil {
+		return nil, err
+	}
+	used, err := s.repo.CountAllocations(pool.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count allocations: %w", err)
+	}
+
+	info := &models.IPPoolInfo{
+		IPPool: *pool,
+		Size:   int(last - first + 1),
+		Used:   int(used),
+	}
+	info.Free = info.Size - info.Used
+	if info.Size > 0 {
+		info.Utilization = float64(info.Used) * 100 / float64(info.Size)
+	}
+	return info, nil
+}
+
+func (s *IPAMService) GetAllPools() ([]models.IPPoolInfo, error) {
+	pools, err := s.repo.GetAllPools()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pools: %w", err)
+	}
+
+	infos := make([]models.IPPoolInfo, 0, len(pools))
+	for i := range pools {
+		info, err := s.poolInfo(&pools[i])
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, *info)
+	}
+	return infos, nil
+}
+
+func (s *IPAMService) GetPool(id uint) (*models.IPPoolInfo, error) {
+	pool, err := s.repo.GetPoolByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("pool not found: %w", err)
+	}
+	return s.poolInfo(pool)
+}
+
+// DeletePool удаляет пул, если из него не выделено ни одного адреса
+func (s *IPAMService) DeletePool(id uint) error {
+	if _, err := s.repo.GetPoolByID(id); err != nil {
+		return fmt.Errorf("pool not found: %w", err)
+	}
+	used, err := s.repo.CountAllocations(id)
+	if err != nil {
+		return fmt.Errorf("failed to count allocations: %w", err)
+	}
+	if used > 0 {
+		return fmt.Errorf("pool has %d allocated addresses", used)
+	}
+	return s.repo.DeletePool(id)
+}
+
+func (s *IPAMService) GetAllocations(poolID uint) ([]models.IPAllocation, error) {
+	if _, err := s.repo.GetPoolByID(poolID); err != nil {
+		return nil, fmt.Errorf("pool not found: %w", err)
+	}
+	return s.repo.GetAllocationsByPool(poolID)
+}
+
+// Reserve резервирует в пуле указанный или следующий свободный адрес
+func (s *IPAMService) Reserve(poolID uint, req *models.ReserveIPRequest) (*models.IPAllocation, error) {
+	pool, err := s.repo.GetPoolByID(poolID)
+	if err != nil {
+		return nil, fmt.Errorf("pool not found: %w", err)
+	}
+
+	if req.IPAddress == "" {
+		return s.allocateFrom(pool, req.Description)
+	}
+	return s.allocateAddress(pool, req.IPAddress, req.Description)
+}
+
+// Release освобождает выделенный адрес
+func (s *IPAMService) Release(id uint) error {
+	allocation, err := s.repo.GetAllocationByID(id)
+	if err != nil {
+		return fmt.Errorf("allocation not found: %w", err)
+	}
+	if allocation.RouterID != 0 {
+		return fmt.Errorf("address %s is assigned to router %d", allocation.Address, allocation.RouterID)
+	}
+	return s.repo.DeleteAllocation(id)
+}
+
+// defaultPool возвращает пул по умолчанию, создавая его при первом обращении
+func (s *IPAMService) defaultPool() (*models.IPPool, error) {
+	pool, err := s.repo.GetPoolByName(defaultPoolName)
+	if err == nil {
+		return pool, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	pool = &models.IPPool{
+		Name:        defaultPoolName,
+		Subnet:      defaultPoolSubnet,
+		Purpose:     models.PoolPurposeHost,
+		Allocation:  models.AllocationRandom,
+		Description: "Router management addresses",
+	}
+	if err := s.repo.CreatePool(pool); err != nil {
+		return nil, fmt.Errorf("failed to create default pool: %w", err)
+	}
+	return pool, nil
+}
+
+// linkPool возвращает первый пул для соединений, создавая пул по умолчанию при необходимости
+func (s *IPAMService) linkPool() (*models.IPPool, error) {
+	pool, err := s.repo.GetPoolByPurpose(models.PoolPurposeLink)
+	if err == nil {
+		return pool, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	pool = &models.IPPool{
+		Name:             defaultLinkPoolName,
+		Subnet:           defaultLinkPoolSubnet,
+		Purpose:          models.PoolPurposeLink,
+		Allocation:       models.AllocationSequential,
+		LinkPrefixLength: defaultLinkPoolPrefixLength,
+		Description:      "Point-to-point link subnets",
+	}
+	if err := s.repo.CreatePool(pool); err != nil {
+		return nil, fmt.Errorf("failed to create link pool: %w", err)
+	}
+	return pool, nil
+}
+
+// LinkEnd описывает интерфейс на конце соединения, которому назначается адрес
+type LinkEnd struct {
+	RouterID    uint
+	InterfaceID uint
+}
+
+// AllocateLink выделяет транзитную подсеть /30 или /31 для соединения и
+// возвращает выделенные адреса концов соединения и длину префикса.
+// Если пул не указан, используется первый пул для соединений.
+func (s *IPAMService) AllocateLink(poolID *uint, connectionID uint, ends [2]LinkEnd) ([]models.IPAllocation, int, error) {
+	var pool *models.IPPool
+	var err error
+	if poolID != nil {
+		pool, err = s.repo.GetPoolByID(*poolID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("pool not found: %w", err)
+		}
+		if pool.Purpose != models.PoolPurposeLink {
+			return nil, 0, fmt.Errorf("pool %s is not a link pool", pool.Name)
+		}
+	} else {
+		pool, err = s.linkPool()
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	first, last, err := subnetBounds(pool.Subnet)
+	if err != nil {
+		return nil, 0, err
+	}
+	taken, err := s.takenAddresses(pool.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Перебираем выровненные блоки; в /30 адреса сети и broadcast не назначаются
+	blockSize := uint64(1) << (32 - pool.LinkPrefixLength)
+	for block := uint64(first); block+blockSize-1 <= uint64(last); block += blockSize {
+		free := true
+		for v := block; v < block+blockSize; v++ {
+			if taken[uint32ToIP(uint32(v))] {
+				free = false
+				break
+			}
+		}
+		if !free {
+			continue
+		}
+
+		host := uint32(block)
+		if blockSize > 2 {
+			host++
+		}
+		allocations := make([]models.IPAllocation, 0, len(ends))
+		for i, end := range ends {
+			allocation := models.IPAllocation{
+				PoolID:       pool.ID,
+				Address:      uint32ToIP(host + uint32(i)),
+				RouterID:     end.RouterID,
+				InterfaceID:  end.InterfaceID,
+				ConnectionID: connectionID,
+				Description:  fmt.Sprintf("connection %d", connectionID),
+				CreatedAt:    time.Now().Format(time.RFC3339),
+			}
+			if err := s.repo.CreateAllocation(&allocation); err != nil {
+				return nil, 0, s.releaseLinkAllocations(allocations, fmt.Errorf("failed to allocate %s: %w", allocation.Address, err))
+			}
+			allocations = append(allocations, allocation)
+		}
+		return allocations, pool.LinkPrefixLength, nil
+	}
+
+	return nil, 0, fmt.Errorf("pool %s (%s) is exhausted", pool.Name, pool.Subnet)
+}
+
+// releaseLinkAllocations освобождает адреса концов соединения, выделенные до отказа,
+// и возвращает причину отказа
+func (s *IPAMService) releaseLinkAllocations(allocations []models.IPAllocation, cause error) error {
+	for _, allocation := range allocations {
+		if err := s.repo.DeleteAllocation(allocation.ID); err != nil {
+			return fmt.Errorf("%w (failed to release %s: %v)", cause, allocation.Address, err)
+		}
+	}
+	return cause
+}
+
+// ReleaseConnection освобождает адреса, выделенные соединению, и возвращает их
+func (s *IPAMService) ReleaseConnection(connectionID uint) ([]models.IPAllocation, error) {
+	allocations, err := s.repo.GetAllocationsByConnection(connectionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allocations: %w", err)
+	}
+	if err := s.repo.DeleteAllocationsByConnection(connectionID); err != nil {
+		return nil, fmt.Errorf("failed to release allocations: %w", err)
+	}
+	return allocations, nil
+}
+
+// poolContaining возвращает пул, которому принадлежит адрес, или nil
+func (s *IPAMService) poolContaining(ip net.IP) (*models.IPPool, error) {
+	pools, err := s.repo.GetAllPools()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pools: %w", err)
+	}
+	for i := range pools {
+		_, network, err := net.ParseCIDR(pools[i].Subnet)
+		if err == nil && network.Contains(ip) {
+			return &pools[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// AllocateRouterIP выделяет адрес для нового роутера: фиксированный адрес,
+// свободный адрес из указанного пула или из пула по умолчанию.
+// Выделение привязывается к роутеру вызовом AssignAllocation.
+func (s *IPAMService) AllocateRouterIP(ip string, poolID *uint) (*models.IPAllocation, error) {
+	if ip != "" {
+		parsed := net.ParseIP(ip)
+		if parsed == nil || parsed.To4() == nil {
+			return nil, fmt.Errorf("invalid IP address: %s", ip)
+		}
+		pool, err := s.poolContaining(parsed)
+		if err != nil {
+			return nil, err
+		}
+		if pool == nil {
+			// Адрес вне пулов не учитывается в IPAM, проверяем только конфликт с роутерами
+			if s.devices.IsIPTaken(ip) {
+				return nil, fmt.Errorf("IP address %s is already in use", ip)
+			}
+			return &models.IPAllocation{Address: parsed.String()}, nil
+		}
+		if poolID != nil && pool.ID != *poolID {
+			return nil, fmt.Errorf("IP address %s does not belong to pool %d", ip, *poolID)
+		}
+		if pool.Purpose == models.PoolPurposeLink {
+			return nil, fmt.Errorf("IP address %s belongs to link pool %s", ip, pool.Name)
+		}
+		return s.allocateAddress(pool, ip, "")
+	}
+
+	var pool *models.IPPool
+	var err error
+	if poolID != nil {
+		pool, err = s.repo.GetPoolByID(*poolID)
+		if err != nil {
+			return nil, fmt.Errorf("pool not found: %w", err)
+		}
+		if pool.Purpose == models.PoolPurposeLink {
+			return nil, fmt.Errorf("pool %s is a link pool", pool.Name)
+		}
+	} else {
+		pool, err = s.defaultPool()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.allocateFrom(pool, "")
+}
+
+// AssignAllocation привязывает выделенный адрес к роутеру
+func (s *IPAMService) AssignAllocation(allocation *models.IPAllocation, routerID uint) error {
+	if allocation.ID == 0 {
+		return nil
+	}
+	allocation.RouterID = routerID
+	return s.repo.AssignAllocation(allocation.ID, routerID)
+}
+
+// ReleaseAllocation освобождает адрес, выделенный для роутера, который не удалось создать
+func (s *IPAMService) ReleaseAllocation(allocation *models.IPAllocation) error {
+	if allocation.ID == 0 {
+		return nil
+	}
+	return s.repo.DeleteAllocation(allocation.ID)
+}
+
+// allocateAddress выделяет конкретный адрес пула
+func (s *IPAMService) allocateAddress(pool *models.IPPool, ip, description string) (*models.IPAllocation, error) {
+	parsed := net.ParseIP(ip).To4()
+	if parsed == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+	first, last, err := poolRange(pool.Subnet)
+	if err != nil {
+		return nil, err
+	}
+	if v := binary.BigEndian.Uint32(parsed); v < first || v > last {
+		return nil, fmt.Errorf("IP address %s is not assignable in pool %s (%s)", ip, pool.Name, pool.Subnet)
+	}
+	if s.isTaken(parsed.String()) {
+		return nil, fmt.Errorf("IP address %s is already in use", ip)
+	}
+	return s.createAllocation(pool, parsed.String(), description)
+}
+
+// allocateFrom выделяет свободный адрес пула согласно режиму выделения
+func (s *IPAMService) allocateFrom(pool *models.IPPool, description string) (*models.IPAllocation, error) {
+	first, last, err := poolRange(pool.Subnet)
+	if err != nil {
+		return nil, err
+	}
+
+	taken, err := s.takenAddresses(pool.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pool.Allocation == models.AllocationRandom {
+		rng := s.allocationRand(pool.ID, len(taken))
+		size := int64(last-first) + 1
+		for i := 0; i < randomAllocationAttempts; i++ {
+			ip := uint32ToIP(first + uint32(rng.Int63n(size)))
+			if !taken[ip] {
+				return s.createAllocation(pool, ip, description)
+			}
+		}
+	}
+
+	// Последовательный поиск; для случайного режима — когда пул почти заполнен
+	for v := uint64(first); v <= uint64(last); v++ {
+		ip := uint32ToIP(uint32(v))
+		if !taken[ip] {
+			return s.createAllocation(pool, ip, description)
+		}
+	}
+
+	return nil, fmt.Errorf("pool %s (%s) is exhausted", pool.Name, pool.Subnet)
+}
+
+// allocationRand возвращает источник случайных чисел для выделения адреса из пула.
+// При заданном seed топологии источник зависит от пула и числа занятых адресов,
+// поэтому топология, построенная заново с тем же seed, получает те же адреса.
+func (s *IPAMService) allocationRand(poolID uint, taken int) *rand.Rand {
+	value := time.Now().UnixNano()
+	if config, err := s.devices.GetSimulationConfig(); err == nil && config.Seed != nil {
+		value = *config.Seed ^ int64(poolID)<<32 ^ int64(taken)
+	}
+	return rand.New(rand.NewSource(value))
+}
+
+// takenAddresses возвращает адреса, выделенные из пула, и адреса всех роутеров и их интерфейсов
+func (s *IPAMService) takenAddresses(poolID uint) (map[string]bool, error) {
+	allocations, err := s.repo.GetAllocationsByPool(poolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allocations: %w", err)
+	}
+	routers, err := s.devices.GetAllRouters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routers: %w", err)
+	}
+
+	taken := make(map[string]bool, len(allocations)+len(routers))
+	for _, allocation := range allocations {
+		taken[allocation.Address] = true
+	}
+	for _, router := range routers {
+		taken[router.IPAddress] = true
+		for _, iface := range router.Interfaces {
+			taken[iface.IPv4Address] = true
+		}
+	}
+	return taken, nil
+}
+
+func (s *IPAMService) createAllocation(pool *models.IPPool, ip, description string) (*models.IPAllocation, error) {
+	allocation := &models.IPAllocation{
+		PoolID:      pool.ID,
+		Address:     ip,
+		Description: description,
+		CreatedAt:   time.Now().Format(time.RFC3339),
+	}
+	if err := s.repo.CreateAllocation(allocation); err != nil {
+		return nil, fmt.Errorf("failed to allocate %s: %w", ip, err)
+	}
+	return allocation, nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+
+	"network/internal/models"
+)
+
+func TestAllocateLinkReleasesFirstEndOnError(t *testing.T) {
+	services, db := newTestService(t)
+
+	// Адрес второго конца первой подсети /31 занят вне пула
+	blocker := &models.IPAllocation{Address: "10.255.0.1", Description: "outside the link pool"}
+	if err := db.Create(blocker).Error; err != nil {
+		t.Fatalf("reserve blocker: %v", err)
+	}
+	ends := [2]LinkEnd{{RouterID: 1, InterfaceID: 1}, {RouterID: 2, InterfaceID: 2}}
+	if _, _, err := services.IPAM.AllocateLink(nil, 1, ends); err == nil {
+		t.Fatal("allocate link over a taken address succeeded")
+	}
+
+	allocations, err := services.IPAM.repo.GetAllocationsByConnection(1)
+	if err != nil {
+		t.Fatalf("get allocations: %v", err)
+	}
+	if len(allocations) != 0 {
+		t.Errorf("allocations after failed allocation = %+v, want none", allocations)
+	}
+}
+
+func TestPoolInfoOfSmallPools(t *testing.T) {
+	services, _ := newTestService(t)
+	tests := []struct {
+		subnet string
+		size   int
+	}{
+		{subnet: "172.16.0.1/32", size: 1},
+		{subnet: "172.16.1.0/31", size: 2},
+		{subnet: "172.16.2.0/30", size: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subnet, func(t *testing.T) {
+			pool, err := services.IPAM.CreatePool(&models.CreateIPPoolRequest{Name: tt.subnet, Subnet: tt.subnet})
+			if err != nil {
+				t.Fatalf("create pool: %v", err)
+			}
+			if pool.Size != tt.size || pool.Utilization != 0 {
+				t.Fatalf("empty pool size = %d, utilization = %v, want %d and 0", pool.Size, pool.Utilization, tt.size)
+			}
+
+			for i := 0; i < tt.size; i++ {
+				if _, err := services.IPAM.Reserve(pool.ID, &models.ReserveIPRequest{}); err != nil {
+					t.Fatalf("reserve address %d: %v", i+1, err)
+				}
+			}
+			if _, err := services.IPAM.Reserve(pool.ID, &models.ReserveIPRequest{}); err == nil {
+				t.Error("reserve in a full pool succeeded")
+			}
+			full, err := services.IPAM.GetPool(pool.ID)
+			if err != nil {
+				t.Fatalf("get pool: %v", err)
+			}
+			if full.Free != 0 || full.Utilization != 100 {
+				t.Errorf("full pool free = %d, utilization = %v, want 0 and 100", full.Free, full.Utilization)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"network/internal/models"
+)
+
+// Размеры заголовков IP + транспортного протокола, байт
+const (
+	tcpHeaderSize = 40
+	udpHeaderSize = 28
+)
+
+// Параметры повторной передачи TCP
+const (
+	tcpRetransmitTimeout = 200.0 // мс
+	tcpMaxRetries        = 3
+)
+
+// Характеристики нового соединения по умолчанию
+const (
+	defaultLinkDelay     = 1.0    // мс
+	defaultLinkBandwidth = 1000.0 // Мбит/с
+)
+
+// applyLinkProperties переносит заданные в запросе характеристики в соединение
+func applyLinkProperties(conn *models.RouterConnection, delay, jitter, loss, bandwidth *float64) {
+	if delay != nil {
+		conn.Delay = *delay
+	}
+	if jitter != nil {
+		conn.Jitter = *jitter
+	}
+	if loss != nil {
+		conn.PacketLoss = *loss
+	}
+	if bandwidth != nil {
+		conn.Bandwidth = *bandwidth
+	}
+}
+
+// validateLink проверяет характеристики соединения
+func validateLink(conn *models.RouterConnection) error {
+	if conn.Delay < 0 {
+		return fmt.Errorf("invalid delay: %v", conn.Delay)
+	}
+	if conn.Jitter < 0 {
+		return fmt.Errorf("invalid jitter: %v", conn.Jitter)
+	}
+	if conn.PacketLoss < 0 || conn.PacketLoss > 1 {
+		return fmt.Errorf("invalid packet loss: %v (must be 0-1)", conn.PacketLoss)
+	}
+	if conn.Bandwidth <= 0 {
+		return fmt.Errorf("invalid bandwidth: %v", conn.Bandwidth)
+	}
+	return nil
+}
+
+// linkDelay вычисляет задержку передачи пакета через соединение, мс:
+// задержка распространения, случайное отклонение в пределах jitter и время сериализации
+func linkDelay(rng *rand.Rand, conn *models.RouterConnection, size int) float64 {
+	delay := conn.Delay
+	if conn.Jitter > 0 {
+		delay += (rng.Float64()*2 - 1) * conn.Jitter
+	}
+	if delay < 0 {
+		delay = 0
+	}
+
+	// Мбит/с = бит/мкс, переводим в мс
+	return delay + float64(size*8)/conn.Bandwidth/1000
+}
+
+// pathLoss вычисляет вероятность потери пакета на пути
+func pathLoss(links []*models.RouterConnection) float64 {
+	delivered := 1.0
+	for _, conn := range links {
+		delivered *= 1 - conn.PacketLoss
+	}
+	return 1 - delivered
+}
+
+// pathLinks возвращает соединения, пройденные пакетом
+func (t *topology) pathLinks(hops []models.PacketHop) []*models.RouterConnection {
+	links := make([]*models.RouterConnection, 0, len(hops))
+	for _, hop := range hops {
+		if conn, ok := t.connections[hop.ConnectionID]; ok {
+			links = append(links, conn)
+		}
+	}
+	return links
+}
+
+// transmit эмулирует однократную передачу пакета по пути.
+// Заполняет задержку на каждом переходе и возвращает общую задержку,
+// а также признак доставки пакета.
+func transmit(rng *rand.Rand, hops []models.PacketHop, links []*models.RouterConnection, size int) (float64, bool) {
+	latency := 0.0
+	for i, conn := range links {
+		latency += linkDelay(rng, conn, size)
+		if rng.Float64() < conn.PacketLoss {
+			return latency, false
+		}
+		hops[i+1].Latency = latency
+	}
+	return latency, true
+}
+
+// addressLink выделяет соединению транзитную подсеть и назначает ее адреса
+// интерфейсам на концах соединения. Возвращает подсеть в формате CIDR.
+func (s *DeviceService) addressLink(conn *models.RouterConnection, from, to *models.Interface, poolID *uint) (string, error) {
+	allocations, prefixLength, err := s.ipam.AllocateLink(poolID, conn.ID, [2]LinkEnd{
+		{RouterID: conn.RouterFromID, InterfaceID: from.ID},
+		{RouterID: conn.RouterToID, InterfaceID: to.ID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to allocate link subnet: %w", err)
+	}
+
+	for i, iface := range []*models.Interface{from, to} {
+		iface.IPv4Address = allocations[i].Address
+		iface.IPv4PrefixLength = prefixLength
+		if err := s.repo.UpdateInterface(iface); err != nil {
+			return "", fmt.Errorf("failed to address interface %s: %w", iface.Name, err)
+		}
+	}
+
+	return normalizePrefix(fmt.Sprintf("%s/%d", allocations[0].Address, prefixLength))
+}
+
+// releaseLink освобождает транзитную подсеть соединения и снимает ее адреса с интерфейсов
+func (s *DeviceService) releaseLink(connectionID uint) error {
+	allocations, err := s.ipam.ReleaseConnection(connectionID)
+	if err != nil {
+		return err
+	}
+
+	for _, allocation := range allocations {
+		iface, err := s.repo.GetInterfaceByID(allocation.InterfaceID)
+		if err != nil || iface.IPv4Address != allocation.Address {
+			continue
+		}
+		iface.IPv4Address = ""
+		iface.IPv4PrefixLength = 0
+		if err := s.repo.UpdateInterface(iface); err != nil {
+			return fmt.Errorf("failed to update interface %s: %w", iface.Name, err)
+		}
+	}
+	return nil
+}
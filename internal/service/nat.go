@@ -0,0 +1,702 @@
+package service
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"network/internal/models"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Время хранения динамических трансляций NAT без пакетов, с
+var natTimeouts = map[string]int{
+	"tcp":  86400,
+	"udp":  300,
+	"icmp": 60,
+}
+
+// Порты, которые PAT назначает соединениям, если порт отправителя уже занят
+const (
+	natPortFirst = 1024
+	natPortLast  = 65535
+)
+
+// maxNATPoolSize ограничивает число адресов пула динамического NAT
+const maxNATPoolSize = 65536
+
+// natEntry — динамическая трансляция: кортеж исходного направления до трансляции
+// и кортеж ответного направления после нее
+type natEntry struct {
+	orig    ctTuple
+	reply   ctTuple
+	rule    models.NATRule
+	inbound bool // соединение открыто снаружи и транслирован адрес назначения
+	packets int64
+	expires float64 // виртуальное время, мс
+}
+
+// natTables — таблицы трансляций NAT роутеров. Трансляция доступна по кортежам
+// обоих направлений; таблицы хранятся только в памяти, а трансляции истекают
+// по виртуальным часам симуляции.
+type natTables struct {
+	mu     sync.Mutex
+	clock  *simClock
+	tables map[uint]map[ctTuple]*natEntry
+}
+
+func newNATTables(clock *simClock) *natTables {
+	return &natTables{clock: clock, tables: make(map[uint]map[ctTuple]*natEntry)}
+}
+
+// lookup находит трансляцию по кортежу пакета; reply — пакет идет в ответном направлении.
+// Истекшие трансляции удаляются.
+func (c *natTables) lookup(routerID uint, key ctTuple) (*natEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.tables[routerID][key]
+	if entry == nil {
+		return nil, false
+	}
+	if c.clock.time() > entry.expires {
+		delete(c.tables[routerID], entry.orig)
+		delete(c.tables[routerID], entry.reply)
+		return nil, false
+	}
+	return entry, key == entry.reply && key != entry.orig
+}
+
+// touch записывает трансляцию в таблицу и продлевает ее
+func (c *natTables) touch(routerID uint, entry *natEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table := c.tables[routerID]
+	if table == nil {
+		table = make(map[ctTuple]*natEntry)
+		c.tables[routerID] = table
+	}
+	timeout, ok := natTimeouts[entry.orig.protocol]
+	if !ok {
+		timeout = natTimeouts["tcp"]
+	}
+	entry.packets++
+	entry.expires = c.clock.time() + float64(timeout)*1000
+	table[entry.orig] = entry
+	table[entry.reply] = entry
+}
+
+// freePort возвращает порт отправителя для кортежа после PAT: исходный порт,
+// если ответы на него не относятся к другой трансляции, иначе первый свободный
+func (c *natTables) freePort(routerID uint, key ctTuple) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.time()
+	free := func(port int) bool {
+		candidate := key
+		candidate.srcPort = port
+		entry := c.tables[routerID][candidate.reverse()]
+		return entry == nil || now > entry.expires
+	}
+	if free(key.srcPort) {
+		return key.srcPort, true
+	}
+	for port := natPortFirst; port <= natPortLast; port++ {
+		if free(port) {
+			return port, true
+		}
+	}
+	return 0, false
+}
+
+// poolAddress возвращает глобальный адрес динамического NAT для внутреннего адреса:
+// адрес, уже назначенный ему правилом, или первый свободный адрес пула
+func (c *natTables) poolAddress(routerID uint, rule *models.NATRule, inside string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.time()
+	used := make(map[string]bool)
+	for _, entry := range c.tables[routerID] {
+		if entry.rule.ID != rule.ID || entry.inbound || now > entry.expires {
+			continue
+		}
+		if entry.orig.srcIP == inside {
+			return entry.reply.dstIP, true
+		}
+		used[entry.reply.dstIP] = true
+	}
+
+	first, last, err := natPoolRange(rule.PoolStart, rule.PoolEnd)
+	if err != nil {
+		return "", false
+	}
+	for v := first; v <= last && v >= first; v++ {
+		if ip := uint32ToIP(v); !used[ip] {
+			return ip, true
+		}
+	}
+	return "", false
+}
+
+// entries возвращает действующие трансляции роутера
+func (c *natTables) entries(routerID uint) []models.NATTranslation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.time()
+	translations := []models.NATTranslation{}
+	for key, entry := range c.tables[routerID] {
+		if now > entry.expires {
+			delete(c.tables[routerID], key)
+			continue
+		}
+		if key != entry.orig {
+			continue
+		}
+		protocol := entry.orig.protocol
+		translation := models.NATTranslation{
+			Protocol: protocol,
+			Type:     entry.rule.Type,
+			RuleID:   entry.rule.ID,
+			Packets:  entry.packets,
+			Timeout:  int((entry.expires - now) / 1000),
+		}
+		// Соединение, открытое снаружи, идет к глобальному адресу, а отвечает ему внутренний
+		if entry.inbound {
+			translation.InsideLocal = natAddress(entry.reply.srcIP, entry.reply.srcPort, protocol)
+			translation.InsideGlobal = natAddress(entry.orig.dstIP, entry.orig.dstPort, protocol)
+			translation.OutsideLocal = natAddress(entry.reply.dstIP, entry.reply.dstPort, protocol)
+			translation.OutsideGlobal = natAddress(entry.orig.srcIP, entry.orig.srcPort, protocol)
+		} else {
+			translation.InsideLocal = natAddress(entry.orig.srcIP, entry.orig.srcPort, protocol)
+			translation.InsideGlobal = natAddress(entry.reply.dstIP, entry.reply.dstPort, protocol)
+			translation.OutsideLocal = natAddress(entry.orig.dstIP, entry.orig.dstPort, protocol)
+			translation.OutsideGlobal = natAddress(entry.reply.srcIP, entry.reply.srcPort, protocol)
+		}
+		translations = append(translations, translation)
+	}
+	sort.Slice(translations, func(i, j int) bool {
+		a, b := translations[i], translations[j]
+		if a.InsideGlobal != b.InsideGlobal {
+			return a.InsideGlobal < b.InsideGlobal
+		}
+		if a.InsideLocal != b.InsideLocal {
+			return a.InsideLocal < b.InsideLocal
+		}
+		return a.OutsideGlobal < b.OutsideGlobal
+	})
+	return translations
+}
+
+// flushRule удаляет трансляции правила
+func (c *natTables) flushRule(routerID, ruleID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.tables[routerID] {
+		if entry.rule.ID == ruleID {
+			delete(c.tables[routerID], key)
+		}
+	}
+}
+
+// flush очищает таблицу роутера
+func (c *natTables) flush(routerID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tables, routerID)
+}
+
+// reset очищает динамические трансляции всех роутеров при сбросе часов
+func (c *natTables) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables = make(map[uint]map[ctTuple]*natEntry)
+}
+
+// natAddress форматирует адрес трансляции; у TCP и UDP он включает порт
+func natAddress(ip string, port int, protocol string) string {
+	if protocol == "tcp" || protocol == "udp" {
+		return net.JoinHostPort(ip, strconv.Itoa(port))
+	}
+	return ip
+}
+
+// natPoolRange возвращает границы пула динамического NAT
+func natPoolRange(start, end string) (uint32, uint32, error) {
+	first, last := net.ParseIP(start).To4(), net.ParseIP(end).To4()
+	if first == nil || last == nil {
+		return 0, 0, fmt.Errorf("invalid NAT pool: %s-%s", start, end)
+	}
+	from, to := binary.BigEndian.Uint32(first), binary.BigEndian.Uint32(last)
+	if from > to {
+		return 0, 0, fmt.Errorf("NAT pool start %s is after its end %s", start, end)
+	}
+	if to-from >= maxNATPoolSize {
+		return 0, 0, fmt.Errorf("NAT pool %s-%s exceeds %d addresses", start, end, maxNATPoolSize)
+	}
+	return from, to, nil
+}
+
+// sortNATRules упорядочивает правила роутера: статические правила применяются первыми
+func sortNATRules(rules []models.NATRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		a, b := rules[i].Type == models.NATTypeStatic, rules[j].Type == models.NATTypeStatic
+		if a != b {
+			return a
+		}
+		return rules[i].ID < rules[j].ID
+	})
+}
+
+// natGlobalAddresses возвращает глобальные адреса правила, на запросы ARP о которых
+// отвечает роутер: адреса статических правил, правил назначения и пулов
+func natGlobalAddresses(rule models.NATRule) []string {
+	switch rule.Type {
+	case models.NATTypeStatic, models.NATTypeDestination:
+		if rule.Global != "" {
+			return []string{rule.Global}
+		}
+	case models.NATTypeDynamic:
+		first, last, err := natPoolRange(rule.PoolStart, rule.PoolEnd)
+		if err != nil {
+			return nil
+		}
+		addresses := make([]string, 0, last-first+1)
+		for v := first; v <= last && v >= first; v++ {
+			addresses = append(addresses, uint32ToIP(v))
+		}
+		return addresses
+	}
+	return nil
+}
+
+// addresses возвращает адреса пакета; порты указываются только у TCP и UDP
+func (p packetHeader) addresses() models.PacketAddresses {
+	addresses := models.PacketAddresses{SourceIP: p.srcIP, DestinationIP: p.dstIP}
+	if p.protocol == "tcp" || p.protocol == "udp" {
+		addresses.SourcePort = p.srcPort
+		addresses.DestinationPort = p.dstPort
+	}
+	return addresses
+}
+
+// natFlow — трансляция пакета, принятого устройством
+type natFlow struct {
+	entry *natEntry
+	reply bool            // пакет идет в ответном направлении и уже транслирован
+	rule  *models.NATRule // правило, транслировавшее адрес назначения нового соединения
+}
+
+// natRewrite заменяет адреса отправителя и (или) получателя пакета адресами кортежа to
+// и отмечает трансляцию в переходе
+func natRewrite(hop *models.PacketHop, iface string, rule models.NATRule, reply bool, pkt *packetHeader, to ctTuple, source, dest bool) {
+	original := pkt.addresses()
+	if source {
+		pkt.srcIP, pkt.srcPort = to.srcIP, to.srcPort
+	}
+	if dest {
+		pkt.dstIP, pkt.dstPort = to.dstIP, to.dstPort
+	}
+	if translated := pkt.addresses(); translated != original {
+		hop.NAT = append(hop.NAT, models.NATMatch{
+			RuleID:     rule.ID,
+			Type:       rule.Type,
+			Reply:      reply,
+			Interface:  iface,
+			Original:   original,
+			Translated: translated,
+		})
+	}
+}
+
+// translateIn транслирует пакет, принятый роутером через соединение in, до выбора маршрута:
+// ответам известных трансляций возвращает исходные адреса, а новым соединениям
+// к глобальным адресам правил static и destination — внутренний адрес назначения
+func (t *topology) translateIn(hop *models.PacketHop, device *models.Router, in *models.RouterConnection, pkt *packetHeader) (natFlow, error) {
+	rules := t.natRules[device.ID]
+	if len(rules) == 0 || t.nat == nil || in == nil {
+		return natFlow{}, nil
+	}
+	iface := connectionInterface(device, in)
+	if iface == nil {
+		return natFlow{}, nil
+	}
+
+	// Ошибка ICMP о транслированном пакете возвращается к его отправителю с исходными адресами
+	if pkt.inner != nil {
+		entry, reply := t.nat.lookup(device.ID, pkt.inner.tuple().reverse())
+		if entry != nil && reply {
+			inner := *pkt.inner
+			natRewrite(hop, iface.Name, entry.rule, true, &inner, entry.orig, true, true)
+			natRewrite(hop, iface.Name, entry.rule, true, pkt, ctTuple{dstIP: entry.orig.srcIP}, false, true)
+			pkt.inner = &inner
+		}
+		return natFlow{}, nil
+	}
+
+	entry, reply := t.nat.lookup(device.ID, pkt.tuple())
+	if entry != nil {
+		if reply {
+			natRewrite(hop, iface.Name, entry.rule, true, pkt, entry.orig.reverse(), true, true)
+		} else {
+			// Адрес отправителя следующих пакетов соединения транслируется при отправке
+			natRewrite(hop, iface.Name, entry.rule, false, pkt, entry.reply.reverse(), false, true)
+		}
+		return natFlow{entry: entry, reply: reply}, nil
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Interface != iface.Name {
+			continue
+		}
+		to := pkt.tuple()
+		switch rule.Type {
+		case models.NATTypeStatic:
+			if pkt.dstIP != rule.Global {
+				continue
+			}
+			to.dstIP = rule.Inside
+		case models.NATTypeDestination:
+			global := rule.Global
+			if global == "" {
+				global = iface.IPv4Address
+			}
+			if pkt.dstIP != global || rule.Port != 0 && (pkt.protocol != rule.Protocol || pkt.dstPort != rule.Port) {
+				continue
+			}
+			to.dstIP = rule.Inside
+			if rule.Port != 0 {
+				to.dstPort = rule.InsidePort
+			}
+		default:
+			continue
+		}
+		natRewrite(hop, iface.Name, *rule, false, pkt, to, false, true)
+		return natFlow{rule: rule}, nil
+	}
+
+	if owner := t.natGlobals[pkt.dstIP]; owner == device {
+		return natFlow{}, fmt.Errorf("no NAT translation for %s on %s of %s", pkt.dstIP, iface.Name, device.IPAddress)
+	}
+	return natFlow{}, nil
+}
+
+// translateOut транслирует адрес отправителя пакета, который роутер отправляет через соединение conn,
+// и записывает трансляцию нового соединения. received — заголовок пакета до трансляции.
+func (t *topology) translateOut(hop *models.PacketHop, device *models.Router, conn *models.RouterConnection, pkt *packetHeader, received packetHeader, flow natFlow) error {
+	rules := t.natRules[device.ID]
+	if len(rules) == 0 || t.nat == nil {
+		return nil
+	}
+	iface := connectionInterface(device, conn)
+	name := ""
+	if iface != nil {
+		name = iface.Name
+	}
+
+	if flow.entry != nil {
+		if !flow.reply {
+			natRewrite(hop, name, flow.entry.rule, false, pkt, flow.entry.reply.reverse(), true, false)
+		}
+		t.nat.touch(device.ID, flow.entry)
+		return nil
+	}
+	// Ошибки ICMP не создают трансляций
+	if pkt.inner != nil {
+		return nil
+	}
+
+	rule := flow.rule
+	if iface != nil {
+		source, to, err := t.sourceRule(device, iface, *pkt)
+		if err != nil {
+			return err
+		}
+		if source != nil {
+			natRewrite(hop, name, *source, false, pkt, to, true, false)
+			if rule == nil {
+				rule = source
+			}
+		}
+	}
+	if rule == nil {
+		return nil
+	}
+	t.nat.touch(device.ID, &natEntry{
+		orig:    received.tuple(),
+		reply:   pkt.tuple().reverse(),
+		rule:    *rule,
+		inbound: flow.rule != nil,
+	})
+	return nil
+}
+
+// sourceRule находит правило, транслирующее адрес отправителя пакета на внешнем интерфейсе iface,
+// и возвращает кортеж пакета после трансляции
+func (t *topology) sourceRule(device *models.Router, iface *models.Interface, pkt packetHeader) (*models.NATRule, ctTuple, error) {
+	rules := t.natRules[device.ID]
+	to := pkt.tuple()
+	// Собственные пакеты внешнего интерфейса не транслируются
+	if pkt.srcIP == iface.IPv4Address {
+		return nil, to, nil
+	}
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Interface != iface.Name {
+			continue
+		}
+		switch rule.Type {
+		case models.NATTypeStatic:
+			if pkt.srcIP != rule.Inside {
+				continue
+			}
+			to.srcIP = rule.Global
+		case models.NATTypeDynamic:
+			if !prefixContains(rule.Inside, pkt.srcIP) {
+				continue
+			}
+			global, ok := t.nat.poolAddress(device.ID, rule, pkt.srcIP)
+			if !ok {
+				return nil, to, fmt.Errorf("NAT pool %s-%s exhausted on %s", rule.PoolStart, rule.PoolEnd, device.IPAddress)
+			}
+			to.srcIP = global
+		case models.NATTypePAT:
+			if !prefixContains(rule.Inside, pkt.srcIP) || iface.IPv4Address == "" {
+				continue
+			}
+			to.srcIP = iface.IPv4Address
+			port, ok := t.nat.freePort(device.ID, to)
+			if !ok {
+				return nil, to, fmt.Errorf("no free PAT ports on %s of %s", iface.Name, device.IPAddress)
+			}
+			to.srcPort = port
+		default:
+			continue
+		}
+		return rule, to, nil
+	}
+	return nil, to, nil
+}
+
+// getNATRouter возвращает роутер, на котором настраивается NAT
+func (s *DeviceService) getNATRouter(routerID uint) (*models.Router, error) {
+	router, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	if err := requireRouter(router); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
+
+// natIPv4 проверяет, что адрес правила — адрес IPv4
+func natIPv4(field, addr string) (string, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.To4() == nil {
+		return "", fmt.Errorf("invalid %s address: %q, NAT supports only IPv4", field, addr)
+	}
+	return ip.String(), nil
+}
+
+// validateNATRule проверяет и нормализует правило NAT роутера
+func (s *DeviceService) validateNATRule(router *models.Router, rule *models.NATRule, existing []models.NATRule) error {
+	var outside *models.Interface
+	for i := range router.Interfaces {
+		if router.Interfaces[i].Name == rule.Interface {
+			outside = &router.Interfaces[i]
+		}
+	}
+	if outside == nil {
+		return fmt.Errorf("interface %q not found on router %s", rule.Interface, router.IPAddress)
+	}
+
+	var err error
+	switch rule.Type {
+	case models.NATTypeStatic:
+		if rule.Inside, err = natIPv4("inside", rule.Inside); err != nil {
+			return err
+		}
+		if rule.Global, err = natIPv4("global", rule.Global); err != nil {
+			return err
+		}
+		if s.repo.IsIPTaken(rule.Global) {
+			return fmt.Errorf("global address %s is already assigned to a device", rule.Global)
+		}
+	case models.NATTypeDynamic, models.NATTypePAT:
+		if rule.Inside, err = aclAddress(rule.Inside); err != nil {
+			return err
+		}
+		if rule.Type == models.NATTypePAT {
+			break
+		}
+		if rule.PoolStart, err = natIPv4("pool start", rule.PoolStart); err != nil {
+			return err
+		}
+		if rule.PoolEnd, err = natIPv4("pool end", rule.PoolEnd); err != nil {
+			return err
+		}
+		if _, _, err := natPoolRange(rule.PoolStart, rule.PoolEnd); err != nil {
+			return err
+		}
+	case models.NATTypeDestination:
+		if rule.Inside, err = natIPv4("inside", rule.Inside); err != nil {
+			return err
+		}
+		if rule.Global != "" {
+			if rule.Global, err = natIPv4("global", rule.Global); err != nil {
+				return err
+			}
+		}
+		if rule.Port == 0 {
+			if rule.Protocol != "" || rule.InsidePort != 0 {
+				return fmt.Errorf("protocol and inside port require a destination port")
+			}
+			break
+		}
+		if rule.Protocol != "tcp" && rule.Protocol != "udp" {
+			return fmt.Errorf("invalid protocol for port forwarding: %q", rule.Protocol)
+		}
+		if rule.InsidePort == 0 {
+			rule.InsidePort = rule.Port
+		}
+		if rule.Port < 1 || rule.Port > 65535 || rule.InsidePort < 1 || rule.InsidePort > 65535 {
+			return fmt.Errorf("invalid port: %d -> %d", rule.Port, rule.InsidePort)
+		}
+	default:
+		return fmt.Errorf("invalid NAT type: %q", rule.Type)
+	}
+	if rule.Type != models.NATTypeDynamic && (rule.PoolStart != "" || rule.PoolEnd != "") {
+		return fmt.Errorf("only dynamic rules use an address pool")
+	}
+	if rule.Type != models.NATTypeDestination && (rule.Protocol != "" || rule.Port != 0 || rule.InsidePort != 0) {
+		return fmt.Errorf("only destination rules forward ports")
+	}
+
+	// Внутренний и глобальный адреса статического правила не могут транслироваться другим правилом
+	for _, other := range existing {
+		switch {
+		case rule.Type == models.NATTypeStatic && other.Type == models.NATTypeStatic && other.Inside == rule.Inside:
+			return fmt.Errorf("inside address %s is already translated by rule %d", rule.Inside, other.ID)
+		case rule.Type == models.NATTypeStatic && other.Type == models.NATTypeStatic && other.Global == rule.Global:
+			return fmt.Errorf("global address %s is already used by rule %d", rule.Global, other.ID)
+		case rule.Type == models.NATTypeDestination && other.Type == models.NATTypeDestination &&
+			other.Interface == rule.Interface && other.Global == rule.Global && other.Protocol == rule.Protocol && other.Port == rule.Port:
+			return fmt.Errorf("destination is already forwarded by rule %d", other.ID)
+		}
+	}
+	return nil
+}
+
+// staticTranslations возвращает постоянные трансляции статических правил и правил назначения
+func staticTranslations(router *models.Router, rules []models.NATRule) []models.NATTranslation {
+	translations := []models.NATTranslation{}
+	for _, rule := range rules {
+		translation := models.NATTranslation{Type: rule.Type, RuleID: rule.ID}
+		switch rule.Type {
+		case models.NATTypeStatic:
+			translation.InsideLocal = rule.Inside
+			translation.InsideGlobal = rule.Global
+		case models.NATTypeDestination:
+			global := rule.Global
+			if global == "" {
+				for _, iface := range router.Interfaces {
+					if iface.Name == rule.Interface {
+						global = iface.IPv4Address
+					}
+				}
+			}
+			translation.Protocol = rule.Protocol
+			translation.InsideLocal = natAddress(rule.Inside, rule.InsidePort, rule.Protocol)
+			translation.InsideGlobal = natAddress(global, rule.Port, rule.Protocol)
+		default:
+			continue
+		}
+		translations = append(translations, translation)
+	}
+	return translations
+}
+
+// GetNAT возвращает правила NAT роутера с таблицей трансляций
+func (s *DeviceService) GetNAT(routerID uint) (*models.NATTable, error) {
+	router, err := s.getNATRouter(routerID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.repo.GetNATRulesByRouterID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NAT rules: %w", err)
+	}
+	sortNATRules(rules)
+	translations := append(staticTranslations(router, rules), s.nat.entries(routerID)...)
+	return &models.NATTable{Rules: rules, Translations: translations}, nil
+}
+
+// GetNATTranslations возвращает таблицу трансляций NAT роутера:
+// постоянные трансляции статических правил и действующие динамические
+func (s *DeviceService) GetNATTranslations(routerID uint) ([]models.NATTranslation, error) {
+	table, err := s.GetNAT(routerID)
+	if err != nil {
+		return nil, err
+	}
+	return table.Translations, nil
+}
+
+// CreateNATRule добавляет правило NAT роутера
+func (s *DeviceService) CreateNATRule(routerID uint, req *models.CreateNATRuleRequest) (*models.NATRule, error) {
+	router, err := s.getNATRouter(routerID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.GetNATRulesByRouterID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NAT rules: %w", err)
+	}
+
+	rule := &models.NATRule{
+		RouterID:    routerID,
+		Type:        req.Type,
+		Interface:   req.Interface,
+		Inside:      req.Inside,
+		Global:      req.Global,
+		PoolStart:   req.PoolStart,
+		PoolEnd:     req.PoolEnd,
+		Protocol:    req.Protocol,
+		Port:        req.Port,
+		InsidePort:  req.InsidePort,
+		Description: req.Description,
+	}
+	if err := s.validateNATRule(router, rule, existing); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateNATRule(rule); err != nil {
+		return nil, fmt.Errorf("failed to create NAT rule: %w", err)
+	}
+	return rule, nil
+}
+
+// DeleteNATRule удаляет правило NAT вместе с его динамическими трансляциями
+func (s *DeviceService) DeleteNATRule(routerID, id uint) error {
+	if _, err := s.getNATRouter(routerID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteNATRule(routerID, id); err != nil {
+		return err
+	}
+	s.nat.flushRule(routerID, id)
+	return nil
+}
+
+// ClearNATTranslations удаляет динамические трансляции роутера
+func (s *DeviceService) ClearNATTranslations(routerID uint) error {
+	if _, err := s.getNATRouter(routerID); err != nil {
+		return err
+	}
+	s.nat.flush(routerID)
+	return nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"testing"
+
+	"network/internal/models"
+)
+
+// natMatch возвращает трансляцию пакета на устройстве
+func natMatch(t *testing.T, hops []models.PacketHop, device *models.Router) models.NATMatch {
+	t.Helper()
+	matches := hopOf(t, hops, device).NAT
+	if len(matches) != 1 {
+		t.Fatalf("NAT on %s = %+v, want one translation", device.Name, matches)
+	}
+	return matches[0]
+}
+
+func TestPATTranslatesAndReversesConnections(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	topo := mustCreateEdgeTopology(t, s)
+	rule, err := s.CreateNATRule(topo.edge.ID, &models.CreateNATRuleRequest{Type: models.NATTypePAT, Interface: "Gi0/1"})
+	if err != nil {
+		t.Fatalf("create PAT rule: %v", err)
+	}
+
+	// Адрес отправителя заменяется адресом внешнего интерфейса, порт сохраняется
+	first := mustSendTCP(t, s, "192.168.1.2", "203.0.113.3", true)
+	if first.Status != "success" {
+		t.Fatalf("R1 -> R3 = %s (%s), want success", first.Status, first.Error)
+	}
+	out := natMatch(t, first.Hops, topo.edge)
+	wantOut := models.PacketAddresses{SourceIP: "203.0.113.1", SourcePort: defaultSourcePort, DestinationIP: "203.0.113.3", DestinationPort: 80}
+	if out.RuleID != rule.ID || out.Reply || out.Translated != wantOut {
+		t.Errorf("outbound translation = %+v, want %+v by rule %d", out, wantOut, rule.ID)
+	}
+
+	// Ответ R3 адресован внешнему адресу R2 и возвращается внутреннему отправителю
+	back := natMatch(t, first.ReplyHops, topo.edge)
+	wantBack := models.PacketAddresses{SourceIP: "203.0.113.3", SourcePort: 80, DestinationIP: "192.168.1.2", DestinationPort: defaultSourcePort}
+	if !back.Reply || back.Original.DestinationIP != "203.0.113.1" || back.Translated != wantBack {
+		t.Errorf("reply translation = %+v, want %+v from 203.0.113.1", back, wantBack)
+	}
+	if last := first.ReplyHops[len(first.ReplyHops)-1]; last.RouterID != topo.inside.ID {
+		t.Errorf("reply ends at %s, want R1", last.Name)
+	}
+
+	// Второе соединение с тем же портом отправителя получает первый свободный порт
+	second := mustSendTCP(t, s, topo.inside.IPAddress, "203.0.113.3", true)
+	if second.Status != "success" {
+		t.Fatalf("R1 10.0.0.1 -> R3 = %s (%s), want success", second.Status, second.Error)
+	}
+	if out := natMatch(t, second.Hops, topo.edge); out.Translated.SourceIP != "203.0.113.1" || out.Translated.SourcePort != natPortFirst {
+		t.Errorf("second translation = %+v, want 203.0.113.1:%d", out.Translated, natPortFirst)
+	}
+
+	translations, err := s.GetNATTranslations(topo.edge.ID)
+	if err != nil {
+		t.Fatalf("get translations: %v", err)
+	}
+	globals := make(map[string]string)
+	for _, tr := range translations {
+		globals[tr.InsideLocal] = tr.InsideGlobal
+	}
+	if len(translations) != 2 || globals["192.168.1.2:49152"] != "203.0.113.1:49152" || globals["10.0.0.1:49152"] != "203.0.113.1:1024" {
+		t.Fatalf("translations = %+v, want 192.168.1.2:49152 and 10.0.0.1:49152 behind 203.0.113.1", translations)
+	}
+
+	// Трансляции TCP без пакетов истекают по виртуальным часам
+	timeout := float64(natTimeouts["tcp"]) * 1000
+	mustRunClock(t, s, timeout-1000)
+	if translations, _ := s.GetNATTranslations(topo.edge.ID); len(translations) != 2 {
+		t.Errorf("translations before the timeout = %+v, want 2", translations)
+	}
+	mustRunClock(t, s, timeout+1)
+	if translations, _ := s.GetNATTranslations(topo.edge.ID); len(translations) != 0 {
+		t.Errorf("translations after the timeout = %+v, want none", translations)
+	}
+}
+
+func TestDestinationNATForwardsPort(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	topo := mustCreateEdgeTopology(t, s)
+	if _, err := s.CreateNATRule(topo.edge.ID, &models.CreateNATRuleRequest{
+		Type:       models.NATTypeDestination,
+		Interface:  "Gi0/1",
+		Inside:     "192.168.1.2",
+		Protocol:   "tcp",
+		Port:       8080,
+		InsidePort: 80,
+	}); err != nil {
+		t.Fatalf("create destination rule: %v", err)
+	}
+
+	// Соединение к порту 8080 внешнего адреса R2 попадает на порт 80 внутреннего R1
+	seed := int64(1)
+	response, err := s.SendPacket(&models.PacketRequest{
+		SourceIP:      "203.0.113.3",
+		DestinationIP: "203.0.113.1",
+		Protocol:      "tcp",
+		Port:          8080,
+		Reply:         true,
+		Seed:          &seed,
+	})
+	if err != nil {
+		t.Fatalf("send to the forwarded port: %v", err)
+	}
+	if response.Status != "success" || response.Hops[len(response.Hops)-1].RouterID != topo.inside.ID {
+		t.Fatalf("R3 -> 203.0.113.1:8080 = %s (%s), want delivered to R1", response.Status, response.Error)
+	}
+	in := natMatch(t, response.Hops, topo.edge)
+	if in.Translated.DestinationIP != "192.168.1.2" || in.Translated.DestinationPort != 80 {
+		t.Errorf("inbound translation = %+v, want 192.168.1.2:80", in.Translated)
+	}
+	if back := natMatch(t, response.ReplyHops, topo.edge); back.Translated.SourceIP != "203.0.113.1" || back.Translated.SourcePort != 8080 {
+		t.Errorf("reply translation = %+v, want from 203.0.113.1:8080", back.Translated)
+	}
+}
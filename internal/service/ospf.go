@@ -0,0 +1,767 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"network/internal/models"
+	"sort"
+	"strconv"
+)
+
+// Параметры OSPF
+const (
+	ospfDistance                  = 110
+	defaultOSPFReferenceBandwidth = 100 // Мбит/с
+	maxOSPFCost                   = 65535
+	ospfLoopbackCost              = 1
+)
+
+// normalizeArea приводит идентификатор области к виду 0.0.0.0; допускается десятичная запись
+func normalizeArea(area string) (string, error) {
+	if n, err := strconv.ParseUint(area, 10, 32); err == nil {
+		return uint32ToIP(uint32(n)), nil
+	}
+	if ip := net.ParseIP(area).To4(); ip != nil {
+		return ip.String(), nil
+	}
+	return "", fmt.Errorf("invalid OSPF area: %s", area)
+}
+
+// ospfCost вычисляет стоимость интерфейса: заданную вручную или reference / скорость.
+// Для скорости auto используется пропускная способность соединения.
+func ospfCost(iface *models.Interface, conn *models.RouterConnection, reference int) int {
+	if iface.OSPFCost > 0 {
+		return iface.OSPFCost
+	}
+	if iface.Type == models.InterfaceTypeLoopback {
+		return ospfLoopbackCost
+	}
+
+	speed := defaultLinkBandwidth
+	if iface.Speed != models.SpeedAuto {
+		if v, err := strconv.ParseFloat(string(iface.Speed), 64); err == nil {
+			speed = v
+		}
+	} else if conn != nil {
+		speed = conn.Bandwidth
+	}
+
+	cost := int(float64(reference) / speed)
+	if cost < 1 {
+		return 1
+	}
+	if cost > maxOSPFCost {
+		return maxOSPFCost
+	}
+	return cost
+}
+
+// ospfAdjacency — полная смежность с соседом через локальный интерфейс
+type ospfAdjacency struct {
+	neighbor uint
+	iface    *models.Interface
+	nextHop  string // адрес соседа на соединении
+	cost     int
+}
+
+// ospfStub — подсеть, анонсируемая роутером в области
+type ospfStub struct {
+	prefix string
+	iface  string
+	cost   int
+}
+
+// ospfSummary — межобластной анонс пограничного роутера (summary LSA)
+type ospfSummary struct {
+	abr    uint
+	prefix string
+	metric int
+}
+
+// ospfArea — граф одной области: смежности и подсети ее роутеров
+type ospfArea struct {
+	id          string
+	routers     []uint
+	adjacencies map[uint][]ospfAdjacency
+	stubs       map[uint][]ospfStub
+	summaries   map[string]*ospfSummary // abr/prefix -> анонс
+}
+
+// ospfPath — кратчайший путь от корня SPF до роутера
+type ospfPath struct {
+	cost   int
+	parent uint
+	first  *ospfAdjacency // первый переход от корня, nil для самого корня
+}
+
+// ospfRoute — маршрут, вычисленный роутером
+type ospfRoute struct {
+	cost  int
+	first *ospfAdjacency // nil для подсетей самого роутера
+}
+
+// ospfDomain — состояние протокола OSPF во всей топологии: LSDB, деревья SPF и маршруты
+type ospfDomain struct {
+	t          *topology
+	processes  map[uint]*models.OSPFProcess
+	ids        map[uint]string
+	areas      map[string]*ospfArea
+	areaIDs    []string
+	memberOf   map[uint][]string
+	interfaces map[uint][]models.OSPFInterfaceInfo
+	spf        map[uint]map[string]map[uint]*ospfPath
+	intra      map[uint]map[string]map[string]*ospfRoute
+	inter      map[uint]map[string]*ospfRoute
+}
+
+// spf вычисляет кратчайшие пути от корня внутри области (алгоритм Дейкстры)
+func (a *ospfArea) spf(root uint) map[uint]*ospfPath {
+	paths := map[uint]*ospfPath{root: {}}
+	done := make(map[uint]bool)
+
+	for {
+		var current uint
+		found := false
+		for _, id := range a.routers {
+			path, ok := paths[id]
+			if !ok || done[id] {
+				continue
+			}
+			if !found || path.cost < paths[current].cost {
+				current, found = id, true
+			}
+		}
+		if !found {
+			return paths
+		}
+		done[current] = true
+
+		for i := range a.adjacencies[current] {
+			adj := &a.adjacencies[current][i]
+			cost := paths[current].cost + adj.cost
+			if path, ok := paths[adj.neighbor]; ok && path.cost <= cost {
+				continue
+			}
+			first := paths[current].first
+			if current == root {
+				first = adj
+			}
+			paths[adj.neighbor] = &ospfPath{cost: cost, parent: current, first: first}
+		}
+	}
+}
+
+// intraRoutes возвращает внутриобластные маршруты по вычисленным кратчайшим путям
+func (a *ospfArea) intraRoutes(paths map[uint]*ospfPath) map[string]*ospfRoute {
+	routes := make(map[string]*ospfRoute)
+	for _, id := range a.routers {
+		path, ok := paths[id]
+		if !ok {
+			continue
+		}
+		for _, stub := range a.stubs[id] {
+			cost := path.cost + stub.cost
+			if route, ok := routes[stub.prefix]; ok && route.cost <= cost {
+				continue
+			}
+			routes[stub.prefix] = &ospfRoute{cost: cost, first: path.first}
+		}
+	}
+	return routes
+}
+
+// summarize добавляет в область анонс пограничного роутера, сохраняя меньшую метрику
+func (a *ospfArea) summarize(abr uint, prefix string, metric int) {
+	key := fmt.Sprintf("%d/%s", abr, prefix)
+	if summary, ok := a.summaries[key]; ok && summary.metric <= metric {
+		return
+	}
+	a.summaries[key] = &ospfSummary{abr: abr, prefix: prefix, metric: metric}
+}
+
+func (d *ospfDomain) area(id string) *ospfArea {
+	area, ok := d.areas[id]
+	if !ok {
+		area = &ospfArea{
+			id:          id,
+			adjacencies: make(map[uint][]ospfAdjacency),
+			stubs:       make(map[uint][]ospfStub),
+			summaries:   make(map[string]*ospfSummary),
+		}
+		d.areas[id] = area
+		d.areaIDs = append(d.areaIDs, id)
+	}
+	return area
+}
+
+// join добавляет роутер в область
+func (d *ospfDomain) join(routerID uint, area *ospfArea) {
+	for _, id := range d.memberOf[routerID] {
+		if id == area.id {
+			return
+		}
+	}
+	d.memberOf[routerID] = append(d.memberOf[routerID], area.id)
+	area.routers = append(area.routers, routerID)
+}
+
+// isABR проверяет, является ли роутер пограничным: подключен к магистрали и другой области
+func (d *ospfDomain) isABR(routerID uint) bool {
+	areas := d.memberOf[routerID]
+	if len(areas) < 2 {
+		return false
+	}
+	for _, id := range areas {
+		if id == models.OSPFBackboneArea {
+			return true
+		}
+	}
+	return false
+}
+
+// interfaceLink возвращает соединение, подключенное к интерфейсу роутера
+func (t *topology) interfaceLink(routerID, ifaceID uint) (topologyLink, bool) {
+	for _, link := range t.links[routerID] {
+		if link.conn.FromInterfaceID == ifaceID || link.conn.ToInterfaceID == ifaceID {
+			return link, true
+		}
+	}
+	return topologyLink{}, false
+}
+
+// findInterface возвращает интерфейс роутера по идентификатору
+func findInterface(router *models.Router, id uint) *models.Interface {
+	for i := range router.Interfaces {
+		if router.Interfaces[i].ID == id {
+			return &router.Interfaces[i]
+		}
+	}
+	return nil
+}
+
+// buildOSPFDomain строит LSDB по топологии и вычисляет маршруты всех роутеров OSPF.
+// В процессе участвуют включенные процессы активных роутеров.
+func buildOSPFDomain(t *topology, processes []models.OSPFProcess) *ospfDomain {
+	d := &ospfDomain{
+		t:          t,
+		processes:  make(map[uint]*models.OSPFProcess),
+		ids:        make(map[uint]string),
+		areas:      make(map[string]*ospfArea),
+		memberOf:   make(map[uint][]string),
+		interfaces: make(map[uint][]models.OSPFInterfaceInfo),
+		spf:        make(map[uint]map[string]map[uint]*ospfPath),
+		intra:      make(map[uint]map[string]map[string]*ospfRoute),
+		inter:      make(map[uint]map[string]*ospfRoute),
+	}
+
+	var routerIDs []uint
+	for i := range processes {
+		process := &processes[i]
+		router := t.routers[process.RouterID]
+		if !process.Enabled || router == nil || router.Status != "active" {
+			continue
+		}
+		d.processes[router.ID] = process
+		d.ids[router.ID] = process.OSPFRouterID
+		if d.ids[router.ID] == "" {
+			d.ids[router.ID] = router.IPAddress
+		}
+		routerIDs = append(routerIDs, router.ID)
+	}
+	sort.Slice(routerIDs, func(i, j int) bool { return routerIDs[i] < routerIDs[j] })
+
+	for _, id := range routerIDs {
+		d.originate(id)
+	}
+	sort.Strings(d.areaIDs)
+
+	for _, id := range routerIDs {
+		d.spf[id] = make(map[string]map[uint]*ospfPath)
+		d.intra[id] = make(map[string]map[string]*ospfRoute)
+		for _, areaID := range d.memberOf[id] {
+			paths := d.areas[areaID].spf(id)
+			d.spf[id][areaID] = paths
+			d.intra[id][areaID] = d.areas[areaID].intraRoutes(paths)
+		}
+	}
+
+	d.interArea(routerIDs)
+	return d
+}
+
+// originate формирует router LSA: смежности и подсети интерфейсов роутера в его областях
+func (d *ospfDomain) originate(routerID uint) {
+	router := d.t.routers[routerID]
+	reference := d.processes[routerID].ReferenceBandwidth
+	if reference <= 0 {
+		reference = defaultOSPFReferenceBandwidth
+	}
+
+	ownsRouterIP := false
+	for i := range router.Interfaces {
+		iface := &router.Interfaces[i]
+		if iface.IPv4Address == router.IPAddress {
+			ownsRouterIP = true
+		}
+		if iface.OSPFArea == "" {
+			continue
+		}
+
+		link, cabled := d.t.interfaceLink(routerID, iface.ID)
+		info := models.OSPFInterfaceInfo{
+			Interface: iface.Name,
+			Area:      iface.OSPFArea,
+			Cost:      ospfCost(iface, link.conn, reference),
+			State:     models.OSPFAdjacencyDown,
+		}
+		if iface.OperStatus != models.InterfaceStatusUp {
+			d.interfaces[routerID] = append(d.interfaces[routerID], info)
+			continue
+		}
+
+		area := d.area(iface.OSPFArea)
+		d.join(routerID, area)
+		if prefix, err := normalizePrefix(fmt.Sprintf("%s/%d", iface.IPv4Address, iface.IPv4PrefixLength)); err == nil && iface.IPv4Address != "" {
+			area.stubs[routerID] = append(area.stubs[routerID], ospfStub{prefix: prefix, iface: iface.Name, cost: info.Cost})
+		}
+
+		switch {
+		case iface.Type == models.InterfaceTypeLoopback:
+			info.State = models.OSPFAdjacencyPassive
+		case !cabled:
+		default:
+			info.State = d.adjacency(routerID, iface, link, area, info.Cost, &info)
+		}
+		d.interfaces[routerID] = append(d.interfaces[routerID], info)
+	}
+
+	// Адрес роутера анонсируется как подсеть /32 в магистрали или первой из его областей
+	if areas := d.memberOf[routerID]; len(areas) > 0 && !ownsRouterIP && net.ParseIP(router.IPAddress).To4() != nil {
+		area := d.areas[areas[0]]
+		if backbone, ok := d.areas[models.OSPFBackboneArea]; ok && d.isABR(routerID) {
+			area = backbone
+		}
+		area.stubs[routerID] = append(area.stubs[routerID], ospfStub{prefix: router.IPAddress + "/32", cost: ospfLoopbackCost})
+	}
+}
+
+// adjacency определяет состояние соседства через соединение и добавляет полную смежность в область
+func (d *ospfDomain) adjacency(routerID uint, iface *models.Interface, link topologyLink, area *ospfArea, cost int, info *models.OSPFInterfaceInfo) models.OSPFAdjacencyState {
+	if _, ok := d.processes[link.to]; !ok {
+		return models.OSPFAdjacencyPassive
+	}
+	neighbor := d.t.routers[link.to]
+	peerID := link.conn.ToInterfaceID
+	if peerID == iface.ID {
+		peerID = link.conn.FromInterfaceID
+	}
+	peer := findInterface(neighbor, peerID)
+	if peer == nil || peer.OSPFArea == "" {
+		return models.OSPFAdjacencyPassive
+	}
+
+	info.Neighbor = d.ids[link.to]
+	if peer.OSPFArea != iface.OSPFArea {
+		return models.OSPFAdjacencyAreaMismatch
+	}
+
+	nextHop := peer.IPv4Address
+	if nextHop == "" {
+		nextHop = neighbor.IPAddress
+	}
+	area.adjacencies[routerID] = append(area.adjacencies[routerID], ospfAdjacency{
+		neighbor: link.to,
+		iface:    iface,
+		nextHop:  nextHop,
+		cost:     cost,
+	})
+	return models.OSPFAdjacencyFull
+}
+
+// interArea распространяет маршруты между областями через пограничные роутеры:
+// ABR анонсируют сети своих областей в магистраль, а сети магистрали и других
+// областей — в подключенные немагистральные области
+func (d *ospfDomain) interArea(routerIDs []uint) {
+	backbone := d.areas[models.OSPFBackboneArea]
+	if backbone == nil {
+		return
+	}
+
+	for _, id := range routerIDs {
+		if !d.isABR(id) {
+			continue
+		}
+		for _, areaID := range d.memberOf[id] {
+			if areaID == models.OSPFBackboneArea {
+				continue
+			}
+			for prefix, route := range d.intra[id][areaID] {
+				backbone.summarize(id, prefix, route.cost)
+			}
+		}
+	}
+
+	// Пограничные роутеры учитывают только анонсы магистрали
+	for _, id := range routerIDs {
+		if d.isABR(id) {
+			d.inter[id] = d.summaryRoutes(id, backbone)
+		}
+	}
+
+	for _, id := range routerIDs {
+		if !d.isABR(id) {
+			continue
+		}
+		for _, areaID := range d.memberOf[id] {
+			if areaID == models.OSPFBackboneArea {
+				continue
+			}
+			area := d.areas[areaID]
+			for otherID, routes := range d.intra[id] {
+				if otherID == areaID {
+					continue
+				}
+				for prefix, route := range routes {
+					area.summarize(id, prefix, route.cost)
+				}
+			}
+			for prefix, route := range d.inter[id] {
+				area.summarize(id, prefix, route.cost)
+			}
+		}
+	}
+
+	for _, id := range routerIDs {
+		if d.isABR(id) {
+			continue
+		}
+		routes := make(map[string]*ospfRoute)
+		for _, areaID := range d.memberOf[id] {
+			for prefix, route := range d.summaryRoutes(id, d.areas[areaID]) {
+				if best, ok := routes[prefix]; !ok || route.cost < best.cost {
+					routes[prefix] = route
+				}
+			}
+		}
+		d.inter[id] = routes
+	}
+}
+
+// summaryRoutes вычисляет межобластные маршруты роутера по анонсам пограничных роутеров области.
+// При равной стоимости выбирается анонс пограничного роутера с меньшим router ID.
+func (d *ospfDomain) summaryRoutes(routerID uint, area *ospfArea) map[string]*ospfRoute {
+	summaries := make([]*ospfSummary, 0, len(area.summaries))
+	for _, summary := range area.summaries {
+		if summary.abr != routerID {
+			summaries = append(summaries, summary)
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.prefix != b.prefix {
+			return a.prefix < b.prefix
+		}
+		if cmp := bytes.Compare(net.ParseIP(d.ids[a.abr]).To16(), net.ParseIP(d.ids[b.abr]).To16()); cmp != 0 {
+			return cmp < 0
+		}
+		return a.abr < b.abr
+	})
+
+	routes := make(map[string]*ospfRoute)
+	paths := d.spf[routerID][area.id]
+	for _, summary := range summaries {
+		path, ok := paths[summary.abr]
+		if !ok {
+			continue
+		}
+		cost := path.cost + summary.metric
+		if route, ok := routes[summary.prefix]; ok && route.cost <= cost {
+			continue
+		}
+		routes[summary.prefix] = &ospfRoute{cost: cost, first: path.first}
+	}
+	return routes
+}
+
+// routes возвращает маршруты OSPF роутера для установки в таблицу маршрутизации.
+// Внутриобластные маршруты предпочитаются межобластным независимо от стоимости.
+func (d *ospfDomain) routes(routerID uint) []models.Route {
+	connected := make(map[string]bool)
+	for _, route := range connectedRoutes(d.t.routers[routerID]) {
+		connected[route.Prefix] = true
+	}
+
+	best := make(map[string]*ospfRoute)
+	for _, areaID := range d.areaIDs {
+		for prefix, route := range d.intra[routerID][areaID] {
+			if current, ok := best[prefix]; !ok || route.cost < current.cost {
+				best[prefix] = route
+			}
+		}
+	}
+	for prefix, route := range d.inter[routerID] {
+		if _, ok := best[prefix]; !ok {
+			best[prefix] = route
+		}
+	}
+
+	prefixes := make([]string, 0, len(best))
+	for prefix, route := range best {
+		if route.first == nil || connected[prefix] {
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	routes := make([]models.Route, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		route := best[prefix]
+		routes = append(routes, models.Route{
+			RouterID:      routerID,
+			Prefix:        prefix,
+			NextHop:       route.first.nextHop,
+			Interface:     route.first.iface.Name,
+			Metric:        route.cost,
+			AdminDistance: ospfDistance,
+			Protocol:      models.RouteProtocolOSPF,
+		})
+	}
+	return routes
+}
+
+// database возвращает LSDB области: router LSA всех роутеров и summary LSA пограничных роутеров
+func (d *ospfDomain) database(areaID string) []models.OSPFLSA {
+	area := d.areas[areaID]
+	routerIDs := append([]uint(nil), area.routers...)
+	sort.Slice(routerIDs, func(i, j int) bool { return routerIDs[i] < routerIDs[j] })
+
+	var lsas []models.OSPFLSA
+	for _, id := range routerIDs {
+		lsa := models.OSPFLSA{
+			Type:              models.OSPFLSARouter,
+			Area:              areaID,
+			LinkStateID:       d.ids[id],
+			AdvertisingRouter: d.ids[id],
+		}
+		for _, adj := range area.adjacencies[id] {
+			lsa.Links = append(lsa.Links, models.OSPFLink{
+				Type:      models.OSPFLinkPointToPoint,
+				LinkID:    d.ids[adj.neighbor],
+				Interface: adj.iface.Name,
+				Metric:    adj.cost,
+			})
+		}
+		for _, stub := range area.stubs[id] {
+			lsa.Links = append(lsa.Links, models.OSPFLink{
+				Type:      models.OSPFLinkStub,
+				LinkID:    stub.prefix,
+				Interface: stub.iface,
+				Metric:    stub.cost,
+			})
+		}
+		lsas = append(lsas, lsa)
+	}
+
+	summaries := make([]models.OSPFLSA, 0, len(area.summaries))
+	for _, summary := range area.summaries {
+		summaries = append(summaries, models.OSPFLSA{
+			Type:              models.OSPFLSASummary,
+			Area:              areaID,
+			LinkStateID:       summary.prefix,
+			AdvertisingRouter: d.ids[summary.abr],
+			Metric:            summary.metric,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].AdvertisingRouter != summaries[j].AdvertisingRouter {
+			return summaries[i].AdvertisingRouter < summaries[j].AdvertisingRouter
+		}
+		return summaries[i].LinkStateID < summaries[j].LinkStateID
+	})
+	return append(lsas, summaries...)
+}
+
+// tree возвращает дерево кратчайших путей роутера в области
+func (d *ospfDomain) tree(routerID uint, areaID string) models.OSPFSPFTree {
+	paths := d.spf[routerID][areaID]
+	tree := models.OSPFSPFTree{Area: areaID, Root: d.ids[routerID]}
+	for _, id := range d.areas[areaID].routers {
+		path, ok := paths[id]
+		if !ok {
+			continue
+		}
+		node := models.OSPFSPFNode{
+			OSPFRouterID: d.ids[id],
+			DeviceID:     id,
+			Cost:         path.cost,
+		}
+		if id != routerID {
+			node.Parent = d.ids[path.parent]
+		}
+		if path.first != nil {
+			node.NextHop = path.first.nextHop
+			node.Interface = path.first.iface.Name
+		}
+		tree.Nodes = append(tree.Nodes, node)
+	}
+	sort.SliceStable(tree.Nodes, func(i, j int) bool { return tree.Nodes[i].Cost < tree.Nodes[j].Cost })
+	return tree
+}
+
+// ospfDomain вычисляет состояние OSPF по текущей топологии
+func (s *DeviceService) ospfDomain() (*ospfDomain, error) {
+	t, err := s.loadTopology()
+	if err != nil {
+		return nil, err
+	}
+	processes, err := s.repo.GetOSPFProcesses()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OSPF processes: %w", err)
+	}
+	return buildOSPFDomain(t, processes), nil
+}
+
+// recomputeOSPF пересчитывает маршруты OSPF и заменяет ими ранее установленные
+func (s *DeviceService) recomputeOSPF() error {
+	domain, err := s.ospfDomain()
+	if err != nil {
+		return err
+	}
+
+	ids := make([]uint, 0, len(domain.processes))
+	for id := range domain.processes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var routes []models.Route
+	for _, id := range ids {
+		routes = append(routes, domain.routes(id)...)
+	}
+	return s.repo.ReplaceRoutes(models.RouteProtocolOSPF, routes)
+}
+
+// GetOSPF возвращает настройки процесса OSPF роутера и состояние его интерфейсов
+func (s *DeviceService) GetOSPF(routerID uint) (*models.OSPFInfo, error) {
+	router, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	process, err := s.repo.GetOSPFProcess(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OSPF process: %w", err)
+	}
+	if process.ReferenceBandwidth == 0 {
+		process.ReferenceBandwidth = defaultOSPFReferenceBandwidth
+	}
+	domain, err := s.ospfDomain()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &models.OSPFInfo{
+		OSPFProcess: *process,
+		Areas:       domain.memberOf[routerID],
+		ABR:         domain.isABR(routerID),
+		Interfaces:  domain.interfaces[routerID],
+	}
+	if info.OSPFRouterID == "" {
+		info.OSPFRouterID = router.IPAddress
+	}
+	sort.Strings(info.Areas)
+	return info, nil
+}
+
+// UpdateOSPF изменяет настройки процесса OSPF роутера и пересчитывает маршруты
+func (s *DeviceService) UpdateOSPF(routerID uint, req *models.UpdateOSPFRequest) (*models.OSPFInfo, error) {
+	router, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	if err := requireRouter(router); err != nil {
+		return nil, err
+	}
+	process, err := s.repo.GetOSPFProcess(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OSPF process: %w", err)
+	}
+	if process.ReferenceBandwidth == 0 {
+		process.ReferenceBandwidth = defaultOSPFReferenceBandwidth
+	}
+
+	if req.Enabled != nil {
+		process.Enabled = *req.Enabled
+	}
+	if req.OSPFRouterID != nil {
+		process.OSPFRouterID = *req.OSPFRouterID
+	}
+	if req.ReferenceBandwidth != nil {
+		process.ReferenceBandwidth = *req.ReferenceBandwidth
+	}
+
+	if process.OSPFRouterID != "" {
+		ip := net.ParseIP(process.OSPFRouterID).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("invalid OSPF router ID: %s", process.OSPFRouterID)
+		}
+		process.OSPFRouterID = ip.String()
+		if s.repo.OSPFRouterIDExists(process.OSPFRouterID, routerID) {
+			return nil, fmt.Errorf("OSPF router ID %s is already in use", process.OSPFRouterID)
+		}
+	}
+	if process.ReferenceBandwidth <= 0 {
+		return nil, fmt.Errorf("invalid reference bandwidth: %d", process.ReferenceBandwidth)
+	}
+
+	if err := s.repo.SaveOSPFProcess(process); err != nil {
+		return nil, fmt.Errorf("failed to save OSPF process: %w", err)
+	}
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
+	return s.GetOSPF(routerID)
+}
+
+// GetOSPFDatabase возвращает LSDB всех областей или заданной области
+func (s *DeviceService) GetOSPFDatabase(area string) ([]models.OSPFLSA, error) {
+	if area != "" {
+		var err error
+		if area, err = normalizeArea(area); err != nil {
+			return nil, err
+		}
+	}
+
+	domain, err := s.ospfDomain()
+	if err != nil {
+		return nil, err
+	}
+
+	lsas := []models.OSPFLSA{}
+	for _, areaID := range domain.areaIDs {
+		if area == "" || area == areaID {
+			lsas = append(lsas, domain.database(areaID)...)
+		}
+	}
+	return lsas, nil
+}
+
+// GetOSPFSPF возвращает деревья кратчайших путей роутера во всех его областях
+func (s *DeviceService) GetOSPFSPF(routerID uint) ([]models.OSPFSPFTree, error) {
+	if _, err := s.repo.GetRouterByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	domain, err := s.ospfDomain()
+	if err != nil {
+		return nil, err
+	}
+
+	areas := append([]string(nil), domain.memberOf[routerID]...)
+	sort.Strings(areas)
+	trees := make([]models.OSPFSPFTree, 0, len(areas))
+	for _, areaID := range areas {
+		trees = append(trees, domain.tree(routerID, areaID))
+	}
+	return trees, nil
+}
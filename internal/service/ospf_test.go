@@ -0,0 +1,260 @@
+package service
+
+import (
+	"testing"
+
+	"network/internal/models"
+)
+
+// ospfInterface возвращает интерфейс OSPF с адресом в записи CIDR, областью и стоимостью
+func ospfInterface(id uint, name, cidr, area string, cost int) models.Interface {
+	iface := testInterface(id, name, cidr)
+	iface.OSPFArea = area
+	iface.OSPFCost = cost
+	return iface
+}
+
+// ospfProcesses возвращает включенные процессы OSPF роутеров
+func ospfProcesses(routers ...*models.Router) []models.OSPFProcess {
+	processes := make([]models.OSPFProcess, 0, len(routers))
+	for _, router := range routers {
+		processes = append(processes, models.OSPFProcess{RouterID: router.ID, Enabled: true})
+	}
+	return processes
+}
+
+// findRoute возвращает маршрут к префиксу
+func findRoute(routes []models.Route, prefix string) *models.Route {
+	for i := range routes {
+		if routes[i].Prefix == prefix {
+			return &routes[i]
+		}
+	}
+	return nil
+}
+
+// multiAreaTopology строит топологию из трех областей:
+//
+//	R1 -(0.0.0.1)- R2 =(0.0.0.0)= R3 -(0.0.0.2)- R5
+//
+// В магистрали R2 и R3 соединены напрямую (стоимость 10) и через R4 (1 + 1).
+func multiAreaTopology() (*topology, []*models.Router) {
+	const (
+		area0 = models.OSPFBackboneArea
+		area1 = "0.0.0.1"
+		area2 = "0.0.0.2"
+	)
+	r1 := &models.Router{ID: 1, Name: "R1", IPAddress: "10.0.0.1", Status: "active", Interfaces: []models.Interface{
+		ospfInterface(11, "Gi0/0", "192.168.1.1/30", area1, 1),
+	}}
+	r2 := &models.Router{ID: 2, Name: "R2", IPAddress: "10.0.0.2", Status: "active", Interfaces: []models.Interface{
+		ospfInterface(21, "Gi0/0", "192.168.1.2/30", area1, 1),
+		ospfInterface(22, "Gi0/1", "192.168.0.1/30", area0, 10),
+		ospfInterface(23, "Gi0/2", "192.168.0.5/30", area0, 1),
+	}}
+	r3 := &models.Router{ID: 3, Name: "R3", IPAddress: "10.0.0.3", Status: "active", Interfaces: []models.Interface{
+		ospfInterface(31, "Gi0/0", "192.168.0.2/30", area0, 10),
+		ospfInterface(32, "Gi0/1", "192.168.0.9/30", area0, 1),
+		ospfInterface(33, "Gi0/2", "192.168.2.1/30", area2, 1),
+	}}
+	r4 := &models.Router{ID: 4, Name: "R4", IPAddress: "10.0.0.4", Status: "active", Interfaces: []models.Interface{
+		ospfInterface(41, "Gi0/0", "192.168.0.6/30", area0, 1),
+		ospfInterface(42, "Gi0/1", "192.168.0.10/30", area0, 1),
+	}}
+	r5 := &models.Router{ID: 5, Name: "R5", IPAddress: "10.0.0.5", Status: "active", Interfaces: []models.Interface{
+		ospfInterface(51, "Gi0/0", "192.168.2.2/30", area2, 1),
+	}}
+	routers := []*models.Router{r1, r2, r3, r4, r5}
+	conns := []*models.RouterConnection{
+		testLink(1, r1, 11, r2, 21),
+		testLink(2, r2, 22, r3, 31),
+		testLink(3, r2, 23, r4, 41),
+		testLink(4, r4, 42, r3, 32),
+		testLink(5, r3, 33, r5, 51),
+	}
+	return newTestTopology(routers, conns), routers
+}
+
+func TestOSPFSPF(t *testing.T) {
+	topo, routers := multiAreaTopology()
+	d := buildOSPFDomain(topo, ospfProcesses(routers...))
+	paths := d.spf[2][models.OSPFBackboneArea]
+
+	tests := []struct {
+		name     string
+		target   uint
+		cost     int
+		parent   uint
+		neighbor uint
+	}{
+		{name: "direct neighbor", target: 4, cost: 1, parent: 2, neighbor: 4},
+		{name: "cheaper path through transit router", target: 3, cost: 2, parent: 4, neighbor: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, ok := paths[tt.target]
+			if !ok {
+				t.Fatalf("R%d is not in the SPF tree of R2", tt.target)
+			}
+			if path.cost != tt.cost || path.parent != tt.parent {
+				t.Errorf("path to R%d: cost %d parent R%d, want cost %d parent R%d", tt.target, path.cost, path.parent, tt.cost, tt.parent)
+			}
+			if path.first == nil || path.first.neighbor != tt.neighbor {
+				t.Errorf("path to R%d leaves R2 towards %+v, want R%d", tt.target, path.first, tt.neighbor)
+			}
+		})
+	}
+
+	if _, ok := paths[1]; ok {
+		t.Errorf("R1 of area 0.0.0.1 is in the backbone SPF tree")
+	}
+	if root := paths[2]; root == nil || root.cost != 0 || root.first != nil {
+		t.Errorf("root path = %+v, want zero cost without first hop", root)
+	}
+}
+
+func TestOSPFAreas(t *testing.T) {
+	topo, routers := multiAreaTopology()
+	d := buildOSPFDomain(topo, ospfProcesses(routers...))
+
+	tests := []struct {
+		router uint
+		areas  []string
+		abr    bool
+	}{
+		{router: 1, areas: []string{"0.0.0.1"}},
+		{router: 2, areas: []string{"0.0.0.1", models.OSPFBackboneArea}, abr: true},
+		{router: 3, areas: []string{models.OSPFBackboneArea, "0.0.0.2"}, abr: true},
+		{router: 4, areas: []string{models.OSPFBackboneArea}},
+		{router: 5, areas: []string{"0.0.0.2"}},
+	}
+	for _, tt := range tests {
+		areas := d.memberOf[tt.router]
+		if len(areas) != len(tt.areas) {
+			t.Errorf("R%d areas = %v, want %v", tt.router, areas, tt.areas)
+			continue
+		}
+		for i := range areas {
+			if areas[i] != tt.areas[i] {
+				t.Errorf("R%d areas = %v, want %v", tt.router, areas, tt.areas)
+				break
+			}
+		}
+		if abr := d.isABR(tt.router); abr != tt.abr {
+			t.Errorf("R%d ABR = %v, want %v", tt.router, abr, tt.abr)
+		}
+	}
+
+}
+
+func TestOSPFInterAreaRoutes(t *testing.T) {
+	topo, routers := multiAreaTopology()
+	d := buildOSPFDomain(topo, ospfProcesses(routers...))
+
+	// R2 анонсирует в магистраль сети области 0.0.0.1 со своей стоимостью до них
+	backbone := d.areas[models.OSPFBackboneArea]
+	for _, tt := range []struct {
+		key    string
+		metric int
+	}{
+		{key: "2/192.168.1.0/30", metric: 1},
+		{key: "2/10.0.0.1/32", metric: 2},
+		{key: "3/192.168.2.0/30", metric: 1},
+	} {
+		summary, ok := backbone.summaries[tt.key]
+		if !ok {
+			t.Errorf("backbone has no summary %s", tt.key)
+			continue
+		}
+		if summary.metric != tt.metric {
+			t.Errorf("summary %s metric = %d, want %d", tt.key, summary.metric, tt.metric)
+		}
+	}
+	// Сети области не возвращаются в нее же
+	if _, ok := d.areas["0.0.0.1"].summaries["2/192.168.1.0/30"]; ok {
+		t.Errorf("area 0.0.0.1 has a summary of its own network")
+	}
+
+	tests := []struct {
+		name    string
+		router  uint
+		prefix  string
+		nextHop string
+		metric  int
+	}{
+		// 1 до R2 + 2 до R3 через R4 + 1 до сети области 0.0.0.2
+		{name: "remote area through two ABRs", router: 1, prefix: "192.168.2.0/30", nextHop: "192.168.1.2", metric: 4},
+		{name: "router address of remote area", router: 5, prefix: "10.0.0.1/32", nextHop: "192.168.2.1", metric: 5},
+		{name: "backbone network from non-backbone area", router: 1, prefix: "192.168.0.8/30", nextHop: "192.168.1.2", metric: 3},
+		{name: "ABR reaches remote area over backbone", router: 2, prefix: "192.168.2.0/30", nextHop: "192.168.0.6", metric: 3},
+		{name: "intra-area route", router: 2, prefix: "192.168.0.8/30", nextHop: "192.168.0.6", metric: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := findRoute(d.routes(tt.router), tt.prefix)
+			if route == nil {
+				t.Fatalf("R%d has no route to %s", tt.router, tt.prefix)
+			}
+			if route.NextHop != tt.nextHop || route.Metric != tt.metric {
+				t.Errorf("R%d route to %s via %s metric %d, want via %s metric %d", tt.router, tt.prefix, route.NextHop, route.Metric, tt.nextHop, tt.metric)
+			}
+		})
+	}
+}
+
+func TestOSPFSummaryTieBreak(t *testing.T) {
+	// R1 в магистрали достигает сетей области 0.0.0.1 через два ABR с равной стоимостью:
+	// выбирается анонс ABR с меньшим router ID независимо от порядка обхода анонсов
+	const area1 = "0.0.0.1"
+	r1 := &models.Router{ID: 1, Name: "R1", IPAddress: "10.0.0.1", Status: "active", Interfaces: []models.Interface{
+		ospfInterface(11, "Gi0/0", "192.168.0.1/30", models.OSPFBackboneArea, 1),
+		ospfInterface(12, "Gi0/1", "192.168.0.5/30", models.OSPFBackboneArea, 1),
+	}}
+	r2 := &models.Router{ID: 2, Name: "R2", IPAddress: "10.0.0.2", Status: "active", Interfaces: []models.Interface{
+		ospfInterface(21, "Gi0/0", "192.168.0.2/30", models.OSPFBackboneArea, 1),
+		ospfInterface(22, "Gi0/1", "192.168.1.1/30", area1, 1),
+	}}
+	r3 := &models.Router{ID: 3, Name: "R3", IPAddress: "10.0.0.3", Status: "active", Interfaces: []models.Interface{
+		ospfInterface(31, "Gi0/0", "192.168.0.6/30", models.OSPFBackboneArea, 1),
+		ospfInterface(32, "Gi0/1", "192.168.1.5/30", area1, 1),
+	}}
+	r4 := &models.Router{ID: 4, Name: "R4", IPAddress: "10.0.0.4", Status: "active", Interfaces: []models.Interface{
+		ospfInterface(41, "Gi0/0", "192.168.1.2/30", area1, 1),
+		ospfInterface(42, "Gi0/1", "192.168.1.6/30", area1, 1),
+	}}
+	topo := newTestTopology([]*models.Router{r1, r2, r3, r4}, []*models.RouterConnection{
+		testLink(1, r1, 11, r2, 21),
+		testLink(2, r1, 12, r3, 31),
+		testLink(3, r2, 22, r4, 41),
+		testLink(4, r3, 32, r4, 42),
+	})
+
+	tests := []struct {
+		name    string
+		ids     map[uint]string
+		nextHop string
+	}{
+		{name: "lower router ID on lower router", ids: map[uint]string{2: "1.1.1.1", 3: "2.2.2.2"}, nextHop: "192.168.0.2"},
+		{name: "lower router ID on higher router", ids: map[uint]string{2: "2.2.2.2", 3: "1.1.1.1"}, nextHop: "192.168.0.6"},
+		{name: "router ID compared numerically", ids: map[uint]string{2: "10.0.0.2", 3: "9.0.0.3"}, nextHop: "192.168.0.6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processes := ospfProcesses(r1, r2, r3, r4)
+			for i := range processes {
+				processes[i].OSPFRouterID = tt.ids[processes[i].RouterID]
+			}
+			// Анонсы хранятся в map: повторы проверяют, что выбор не зависит от порядка обхода
+			for i := 0; i < 20; i++ {
+				d := buildOSPFDomain(topo, processes)
+				route := findRoute(d.routes(r1.ID), "10.0.0.4/32")
+				if route == nil {
+					t.Fatalf("R1 has no route to 10.0.0.4/32")
+				}
+				if route.NextHop != tt.nextHop || route.Metric != 3 {
+					t.Fatalf("R1 route to 10.0.0.4/32 via %s metric %d, want via %s metric 3", route.NextHop, route.Metric, tt.nextHop)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"network/internal/icmp"
+	"network/internal/models"
+	"os"
+	"time"
+)
+
+// Параметры ping по умолчанию и допустимые пределы
+const (
+	defaultPingCount       = 4
+	defaultPingInterval    = 1000 // мс
+	defaultPingTimeout     = 2000 // мс
+	defaultPingPayloadSize = 56
+	defaultPingTTL         = defaultTTL
+
+	maxPingCount       = 100
+	minPingInterval    = 10    // мс
+	maxPingInterval    = 10000 // мс
+	maxPingTimeout     = 60000 // мс
+	maxPingPayloadSize = 65000
+)
+
+// applyPingDefaults заполняет незаданные параметры и проверяет пределы
+func applyPingDefaults(req *models.PingRequest) error {
+	if req.Count == 0 {
+		req.Count = defaultPingCount
+	}
+	if req.Interval == 0 {
+		req.Interval = defaultPingInterval
+	}
+	if req.Timeout == 0 {
+		req.Timeout = defaultPingTimeout
+	}
+	if req.PayloadSize == 0 {
+		req.PayloadSize = defaultPingPayloadSize
+	}
+	if req.TTL == 0 {
+		req.TTL = defaultPingTTL
+	}
+
+	if req.Count < 1 || req.Count > maxPingCount {
+		return fmt.Errorf("invalid count: %d (must be 1-%d)", req.Count, maxPingCount)
+	}
+	if req.Interval < minPingInterval || req.Interval > maxPingInterval {
+		return fmt.Errorf("invalid interval: %d ms (must be %d-%d)", req.Interval, minPingInterval, maxPingInterval)
+	}
+	if req.Timeout < 1 || req.Timeout > maxPingTimeout {
+		return fmt.Errorf("invalid timeout: %d ms (must be 1-%d)", req.Timeout, maxPingTimeout)
+	}
+	if req.PayloadSize < 0 || req.PayloadSize > maxPingPayloadSize {
+		return fmt.Errorf("invalid payload size: %d (must be 0-%d)", req.PayloadSize, maxPingPayloadSize)
+	}
+	if req.TTL < 1 || req.TTL > 255 {
+		return fmt.Errorf("invalid TTL: %d (must be 1-255)", req.TTL)
+	}
+	return nil
+}
+
+// icmpEchoHeaderSize — размер заголовков IP + ICMP echo, байт
+const icmpEchoHeaderSize = 28
+
+// PingIP пингует адрес. В режиме auto адреса роутеров из базы данных
+// пингуются через симулятор, остальные — настоящим ICMP echo.
+// Без source_ip симулятор отправляет пробы от подключенного роутера, а если его нет,
+// в режиме auto адрес пингуется с хоста.
+// Настоящий ping не блокирует топологию на время ожидания ответов.
+func (s *DeviceService) PingIP(req *models.PingRequest) (*models.PingResult, error) {
+	if err := applyPingDefaults(req); err != nil {
+		return nil, err
+	}
+
+	switch req.Mode {
+	case "", models.PingModeAuto:
+		if s.repo.IsIPTaken(req.IPAddress) && s.fillSimulationSource(&req.SourceIP) {
+			return s.pingSimulated(req)
+		}
+		return s.pingReal(req)
+	case models.PingModeReal:
+		return s.pingReal(req)
+	case models.PingModeSimulated:
+		s.fillSimulationSource(&req.SourceIP)
+		return s.pingSimulated(req)
+	default:
+		return nil, fmt.Errorf("invalid mode: %s", req.Mode)
+	}
+}
+
+// fillSimulationSource подставляет в пустой адрес отправителя адрес подключенного роутера
+// и сообщает, известен ли отправитель
+func (s *DeviceService) fillSimulationSource(sourceIP *string) bool {
+	if *sourceIP == "" {
+		if router, err := s.repo.GetConnectedRouter(); err == nil {
+			*sourceIP = router.IPAddress
+		}
+	}
+	return *sourceIP != ""
+}
+
+// pingReal отправляет ICMP echo запросы с хоста и собирает статистику
+func (s *DeviceService) pingReal(req *models.PingRequest) (*models.PingResult, error) {
+	addr, err := net.ResolveIPAddr("ip4", req.IPAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", req.IPAddress, err)
+	}
+
+	result := &models.PingResult{
+		IPAddress: req.IPAddress,
+		Mode:      models.PingModeReal,
+		Status:    "failed",
+	}
+
+	conn, err := icmp.Listen()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer conn.Close()
+
+	if err := conn.SetTTL(req.TTL); err != nil {
+		result.Error = fmt.Sprintf("failed to set TTL: %v", err)
+		return result, nil
+	}
+
+	payload := make([]byte, req.PayloadSize)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	timeout := time.Duration(req.Timeout) * time.Millisecond
+	interval := time.Duration(req.Interval) * time.Millisecond
+
+	for seq := 1; seq <= req.Count; seq++ {
+		if seq > 1 {
+			time.Sleep(interval)
+		}
+
+		probe, err := sendProbe(conn, addr.IP, seq, payload, timeout)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.Probes = append(result.Probes, probe)
+	}
+
+	summarizePing(result)
+	return result, nil
+}
+
+// sendProbe отправляет один ICMP echo запрос и преобразует ответ в результат пробы
+func sendProbe(conn *icmp.Conn, ip net.IP, seq int, payload []byte, timeout time.Duration) (models.PingProbe, error) {
+	probe := models.PingProbe{Seq: seq, Status: "timeout"}
+
+	reply, err := conn.Echo(ip, seq, payload, timeout)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return probe, nil
+	}
+	if err != nil {
+		probe.Status = "error"
+		return probe, err
+	}
+
+	probe.From = reply.From.String()
+	probe.RTT = float64(reply.RTT.Microseconds()) / 1000
+	switch reply.Type {
+	case icmp.TypeEchoReply:
+		probe.Status = "success"
+	case icmp.TypeTimeExceeded:
+		probe.Status = "ttl exceeded"
+	default:
+		probe.Status = "unreachable"
+	}
+	return probe, nil
+}
+
+// pingSimulated отвечает на ping между виртуальными роутерами через симулятор.
+// Проход пакетов меняет таблицы MAC, ARP, conntrack и NAT, поэтому выполняется под блокировкой топологии.
+func (s *DeviceService) pingSimulated(req *models.PingRequest) (*models.PingResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.SourceIP == "" {
+		return nil, fmt.Errorf("source_ip is required for simulated ping when no router is connected")
+	}
+
+	topo, err := s.loadTopology()
+	if err != nil {
+		return nil, err
+	}
+
+	source := topo.byIP[req.SourceIP]
+	if source == nil {
+		return nil, fmt.Errorf("source router with IP %s not found", req.SourceIP)
+	}
+	if topo.byIP[req.IPAddress] == nil {
+		return nil, fmt.Errorf("destination router with IP %s not found", req.IPAddress)
+	}
+
+	rng, seed := s.newRand(req.Seed)
+	result := &models.PingResult{
+		IPAddress: req.IPAddress,
+		SourceIP:  source.IPAddress,
+		Mode:      models.PingModeSimulated,
+		Seed:      &seed,
+		Status:    "failed",
+	}
+
+	size := icmpEchoHeaderSize + req.PayloadSize
+	for seq := 1; seq <= req.Count; seq++ {
+		probe := topo.echo(rng, source, req.IPAddress, req.TTL, size, float64(req.Timeout))
+		probe.Seq = seq
+		result.Probes = append(result.Probes, probe)
+	}
+
+	summarizePing(result)
+	return result, nil
+}
+
+// echo эмулирует один ICMP echo запрос от роутера source: запрос и ответ проходят
+// по таблицам маршрутизации, задержки и потери берутся из характеристик соединений.
+// Если пересылка прервалась (TTL, нет маршрута), отвечает роутер, на котором это произошло.
+func (t *topology) echo(rng *rand.Rand, source *models.Router, destIP string, ttl, size int, timeout float64) models.PingProbe {
+	// Проба считается потерянной, пока не получен ответ
+	probe := models.PingProbe{Status: "timeout"}
+
+	request := packetHeader{srcIP: source.IPAddress, dstIP: destIP, protocol: "icmp", flags: icmpEchoRequest}
+	hops, received, err := t.deliver(source.ID, request, ttl)
+	if len(hops) == 0 {
+		return probe
+	}
+	there, delivered := transmit(rng, hops, t.pathLinks(hops), size)
+	if !delivered {
+		return probe
+	}
+
+	replier := hops[len(hops)-1]
+	status := "success"
+	switch {
+	case errors.Is(err, errTTLExceeded):
+		status = "ttl exceeded"
+	case err != nil:
+		status = "unreachable"
+	}
+
+	if replier.RouterID == source.ID {
+		probe.From = source.IPAddress
+		probe.Status = status
+		return probe
+	}
+
+	// Ответ на echo — echo reply, а роутер, на котором пересылка прервалась, отвечает ошибкой ICMP.
+	// Ответ адресуется отправителю запроса в том виде, в каком его получил отвечающий, — после NAT.
+	reply := received.reverse()
+	if err != nil {
+		reply = packetHeader{srcIP: replier.IPAddress, dstIP: received.srcIP, protocol: "icmp", inner: &received}
+	}
+	back, err := t.forward(replier.RouterID, reply, defaultTTL)
+	if err != nil {
+		return probe
+	}
+	returned, delivered := transmit(rng, back, t.pathLinks(back), size)
+	if !delivered || there+returned > timeout {
+		return probe
+	}
+
+	probe.RTT = there + returned
+	probe.From = replier.IPAddress
+	probe.Status = status
+	return probe
+}
+
+// summarizePing вычисляет потери и min/avg/max/stddev RTT по успешным пробам
+func summarizePing(result *models.PingResult) {
+	var rtts []float64
+	for _, probe := range result.Probes {
+		if probe.Status == "success" {
+			rtts = append(rtts, probe.RTT)
+		}
+	}
+
+	result.Sent = len(result.Probes)
+	result.Received = len(rtts)
+	if result.Sent > 0 {
+		result.LossPercent = float64(result.Sent-result.Received) * 100 / float64(result.Sent)
+	}
+	if len(rtts) == 0 {
+		return
+	}
+
+	result.MinRTT, result.MaxRTT = rtts[0], rtts[0]
+	sum := 0.0
+	for _, rtt := range rtts {
+		result.MinRTT = math.Min(result.MinRTT, rtt)
+		result.MaxRTT = math.Max(result.MaxRTT, rtt)
+		sum += rtt
+	}
+	result.AvgRTT = sum / float64(len(rtts))
+
+	variance := 0.0
+	for _, rtt := range rtts {
+		variance += (rtt - result.AvgRTT) * (rtt - result.AvgRTT)
+	}
+	result.StdDevRTT = math.Sqrt(variance / float64(len(rtts)))
+
+	result.Latency = result.AvgRTT
+	result.Status = "success"
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	"network/internal/models"
+)
+
+func TestApplyPingDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.PingRequest
+		wantErr bool
+	}{
+		{name: "defaults", req: models.PingRequest{}},
+		{name: "limits", req: models.PingRequest{Count: maxPingCount, Interval: maxPingInterval, Timeout: maxPingTimeout, TTL: 255}},
+		{name: "too many probes", req: models.PingRequest{Count: maxPingCount + 1}, wantErr: true},
+		{name: "interval too short", req: models.PingRequest{Interval: minPingInterval - 1}, wantErr: true},
+		{name: "interval too long", req: models.PingRequest{Interval: maxPingInterval + 1}, wantErr: true},
+		{name: "timeout too long", req: models.PingRequest{Timeout: maxPingTimeout + 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyPingDefaults(&tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyPingDefaults() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && (tt.req.Count == 0 || tt.req.Interval == 0 || tt.req.Timeout == 0 || tt.req.TTL == 0) {
+				t.Errorf("defaults not applied: %+v", tt.req)
+			}
+		})
+	}
+}
+
+func TestPingWithoutSource(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	r1 := mustCreateRouter(t, s, "R1", "10.0.0.1")
+	r2 := mustCreateRouter(t, s, "R2", "10.0.0.2")
+	mustConnect(t, s, r1, r2, 1, 0, 0)
+	mustEnableOSPF(t, s, r1, r2)
+
+	// Отправителем служит первый подключенный роутер
+	result, err := s.PingIP(&models.PingRequest{IPAddress: r2.IPAddress, Count: 1})
+	if err != nil {
+		t.Fatalf("auto ping: %v", err)
+	}
+	if result.Mode != models.PingModeSimulated || result.SourceIP != r1.IPAddress || result.Status != "success" {
+		t.Errorf("auto ping = %s %s from %s, want simulated success from %s", result.Mode, result.Status, result.SourceIP, r1.IPAddress)
+	}
+
+	for _, router := range []*models.Router{r1, r2} {
+		if err := s.repo.DisconnectRouter(router.ID); err != nil {
+			t.Fatalf("disconnect %s: %v", router.Name, err)
+		}
+	}
+
+	// Без подключенного роутера режим auto пингует с хоста, а режим simulated требует source_ip
+	result, err = s.PingIP(&models.PingRequest{IPAddress: r2.IPAddress, Count: 1, Timeout: 1})
+	if err != nil {
+		t.Fatalf("auto ping without connected router: %v", err)
+	}
+	if result.Mode != models.PingModeReal {
+		t.Errorf("auto ping without connected router mode = %s, want %s", result.Mode, models.PingModeReal)
+	}
+	if _, err := s.PingIP(&models.PingRequest{IPAddress: r2.IPAddress, Mode: models.PingModeSimulated}); err == nil {
+		t.Error("simulated ping without source and connected router succeeded")
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"network/internal/models"
+	"time"
+)
+
+// newRand возвращает источник случайных чисел для одной симуляции и использованный seed.
+// Приоритет: seed из запроса, затем seed топологии, иначе текущее время.
+// Одинаковый seed при одинаковой топологии дает одинаковые задержки и потери.
+func (s *DeviceService) newRand(seed *int64) (*rand.Rand, int64) {
+	if seed == nil {
+		if config, err := s.repo.GetSimulationConfig(); err == nil {
+			seed = config.Seed
+		}
+	}
+
+	value := time.Now().UnixNano()
+	if seed != nil {
+		value = *seed
+	}
+
+	return rand.New(rand.NewSource(value)), value
+}
+
+func (s *DeviceService) GetSimulationConfig() (*models.SimulationConfig, error) {
+	return s.repo.GetSimulationConfig()
+}
+
+// UpdateSimulationConfig задает seed топологии; пустой seed возвращает случайный режим.
+// Пустая задержка обнаружения отказа возвращает значение по умолчанию.
+func (s *DeviceService) UpdateSimulationConfig(req *models.UpdateSimulationConfigRequest) (*models.SimulationConfig, error) {
+	if req.DetectionDelay != nil && *req.DetectionDelay < 0 {
+		return nil, fmt.Errorf("invalid detection delay: %v", *req.DetectionDelay)
+	}
+	config := &models.SimulationConfig{Seed: req.Seed, DetectionDelay: req.DetectionDelay}
+	if err := s.repo.SaveSimulationConfig(config); err != nil {
+		return nil, fmt.Errorf("failed to save simulation config: %w", err)
+	}
+	return config, nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"network/internal/models"
+)
+
+func TestSameSeedReplaysSimulation(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	r1 := mustCreateRouter(t, s, "R1", "10.0.0.1")
+	r2 := mustCreateRouter(t, s, "R2", "10.0.0.2")
+	r3 := mustCreateRouter(t, s, "R3", "10.0.0.3")
+	mustConnect(t, s, r1, r2, 5, 3, 0.1)
+	mustConnect(t, s, r2, r3, 8, 4, 0.2)
+	mustEnableOSPF(t, s, r1, r2, r3)
+
+	tests := []struct {
+		name string
+		seed int64
+		run  func(seed int64) (interface{}, error)
+	}{
+		{
+			name: "tcp packet with reply",
+			seed: 42,
+			run: func(seed int64) (interface{}, error) {
+				return s.SendPacket(&models.PacketRequest{
+					SourceIP:      r1.IPAddress,
+					DestinationIP: r3.IPAddress,
+					Protocol:      "tcp",
+					Port:          80,
+					Reply:         true,
+					Data:          "hello",
+					Seed:          &seed,
+				})
+			},
+		},
+		{
+			name: "tcp packet",
+			seed: 7,
+			run: func(seed int64) (interface{}, error) {
+				return s.SendPacket(&models.PacketRequest{
+					SourceIP:      r3.IPAddress,
+					DestinationIP: r1.IPAddress,
+					Protocol:      "tcp",
+					Port:          80,
+					Data:          "request",
+					Seed:          &seed,
+				})
+			},
+		},
+		{
+			name: "simulated ping",
+			seed: 1234,
+			run: func(seed int64) (interface{}, error) {
+				return s.PingIP(&models.PingRequest{
+					IPAddress: r3.IPAddress,
+					SourceIP:  r1.IPAddress,
+					Mode:      models.PingModeSimulated,
+					Count:     20,
+					Seed:      &seed,
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outputs [2]string
+			for i := range outputs {
+				result, err := tt.run(tt.seed)
+				if err != nil {
+					t.Fatalf("run %d: %v", i+1, err)
+				}
+				data, err := json.Marshal(result)
+				if err != nil {
+					t.Fatalf("marshal run %d: %v", i+1, err)
+				}
+				outputs[i] = string(data)
+			}
+			if outputs[0] != outputs[1] {
+				t.Errorf("same seed %d gave different results:\n%s\n%s", tt.seed, outputs[0], outputs[1])
+			}
+		})
+	}
+}
+
+func TestSameSeedReplaysRandomAllocation(t *testing.T) {
+	seed := int64(99)
+	var runs [2][]string
+	for i := range runs {
+		services, _ := newTestService(t)
+		if _, err := services.Devices.UpdateSimulationConfig(&models.UpdateSimulationConfigRequest{Seed: &seed}); err != nil {
+			t.Fatalf("set seed: %v", err)
+		}
+		pool, err := services.IPAM.CreatePool(&models.CreateIPPoolRequest{
+			Name:       "random",
+			Subnet:     "172.16.0.0/16",
+			Allocation: models.AllocationRandom,
+		})
+		if err != nil {
+			t.Fatalf("create pool: %v", err)
+		}
+		for j := 0; j < 5; j++ {
+			allocation, err := services.IPAM.Reserve(pool.ID, &models.ReserveIPRequest{})
+			if err != nil {
+				t.Fatalf("reserve address %d: %v", j+1, err)
+			}
+			runs[i] = append(runs[i], allocation.Address)
+		}
+	}
+	if !reflect.DeepEqual(runs[0], runs[1]) {
+		t.Errorf("same seed %d allocated different addresses: %v and %v", seed, runs[0], runs[1])
+	}
+}
@@ -0,0 +1,531 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"network/internal/models"
+	"sort"
+	"time"
+)
+
+// Параметры RIP. Раунд соответствует периодическому обновлению (30 с)
+const (
+	ripUpdateInterval    = 30000.0 // мс виртуального времени между раундами
+	ripDistance          = 120
+	defaultRIPHoldDown   = 6 // 180 с
+	ripGarbageRounds     = 2 // недостижимый маршрут удаляется через 60 с после hold-down
+	maxRIPHoldDown       = 60
+	maxRIPRounds         = 100
+	ripConnectedMetric   = 0
+	ripUnreachableMetric = models.RIPInfinity
+)
+
+// ripNeighbor — соседний роутер RIP и соединение с ним
+type ripNeighbor struct {
+	id      uint
+	iface   string // локальный интерфейс
+	nextHop string // адрес соседа на соединении
+}
+
+// ripSpeaker — роутер с включенным RIP и его база маршрутов
+type ripSpeaker struct {
+	router    *models.Router
+	process   *models.RIPProcess
+	neighbors []ripNeighbor
+	table     map[string]*models.RIPRoute
+}
+
+// ripAdvert — маршрут в периодическом обновлении
+type ripAdvert struct {
+	prefix string
+	metric int
+}
+
+// ripDomain — состояние RIP во всей топологии на время прогона раундов
+type ripDomain struct {
+	speakers map[uint]*ripSpeaker
+	order    []uint
+	round    int
+	changes  []models.RIPChange
+	// invalidated — маршруты, ставшие недостижимыми в текущем раунде; их таймеры запускаются со следующего
+	invalidated map[*models.RIPRoute]bool
+}
+
+// buildRIPDomain восстанавливает сохраненные базы RIP роутеров, участвующих в RIP
+func buildRIPDomain(t *topology, processes []models.RIPProcess, state []models.RIPRoute) *ripDomain {
+	d := &ripDomain{speakers: make(map[uint]*ripSpeaker)}
+
+	for i := range processes {
+		process := &processes[i]
+		router := t.routers[process.RouterID]
+		if !process.Enabled || router == nil || router.Status != "active" {
+			continue
+		}
+		d.speakers[router.ID] = &ripSpeaker{
+			router:  router,
+			process: process,
+			table:   make(map[string]*models.RIPRoute),
+		}
+		d.order = append(d.order, router.ID)
+	}
+	sort.Slice(d.order, func(i, j int) bool { return d.order[i] < d.order[j] })
+
+	for _, id := range d.order {
+		sp := d.speakers[id]
+		seen := make(map[uint]bool)
+		for _, link := range t.links[id] {
+			if d.speakers[link.to] == nil || seen[link.to] {
+				continue
+			}
+			seen[link.to] = true
+			nextHop, _ := connectionEnd(t.routers[link.to], link.conn)
+			_, iface := connectionEnd(sp.router, link.conn)
+			sp.neighbors = append(sp.neighbors, ripNeighbor{id: link.to, iface: iface, nextHop: nextHop})
+		}
+	}
+
+	for i := range state {
+		route := state[i]
+		if sp, ok := d.speakers[route.RouterID]; ok {
+			sp.table[route.Prefix] = &route
+		}
+	}
+	return d
+}
+
+// connectedNetworks возвращает подсети работающих IPv4 интерфейсов роутера и его адрес
+func connectedNetworks(router *models.Router) map[string]string {
+	networks := make(map[string]string)
+	ownsRouterIP := false
+	for i := range router.Interfaces {
+		iface := &router.Interfaces[i]
+		if iface.IPv4Address == router.IPAddress {
+			ownsRouterIP = true
+		}
+		if iface.OperStatus != models.InterfaceStatusUp || iface.IPv4Address == "" {
+			continue
+		}
+		if prefix, err := normalizePrefix(fmt.Sprintf("%s/%d", iface.IPv4Address, iface.IPv4PrefixLength)); err == nil {
+			networks[prefix] = iface.Name
+		}
+	}
+	if !ownsRouterIP && net.ParseIP(router.IPAddress).To4() != nil {
+		networks[router.IPAddress+"/32"] = ""
+	}
+	return networks
+}
+
+func (sp *ripSpeaker) neighbor(id uint) *ripNeighbor {
+	for i := range sp.neighbors {
+		if sp.neighbors[i].id == id {
+			return &sp.neighbors[i]
+		}
+	}
+	return nil
+}
+
+func (sp *ripSpeaker) prefixes() []string {
+	prefixes := make([]string, 0, len(sp.table))
+	for prefix := range sp.table {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+func (d *ripDomain) record(routerID uint, route *models.RIPRoute, action models.RIPChangeAction, oldMetric int) {
+	d.changes = append(d.changes, models.RIPChange{
+		Round:     d.round,
+		RouterID:  routerID,
+		Prefix:    route.Prefix,
+		Action:    action,
+		OldMetric: oldMetric,
+		NewMetric: route.Metric,
+		NextHop:   route.NextHop,
+	})
+}
+
+// invalidate помечает маршрут недостижимым и запускает hold-down
+func (d *ripDomain) invalidate(sp *ripSpeaker, route *models.RIPRoute) {
+	old := route.Metric
+	route.PreviousMetric = route.Metric
+	route.Metric = ripUnreachableMetric
+	route.HoldDown = sp.process.HoldDownRounds
+	route.Flush = sp.process.HoldDownRounds + ripGarbageRounds
+	d.invalidated[route] = true
+	d.record(sp.router.ID, route, models.RIPChangeInvalidated, old)
+}
+
+// prepare применяет изменения топологии к базам: добавляет подключенные сети
+// и делает недостижимыми исчезнувшие сети и маршруты через потерянных соседей
+func (d *ripDomain) prepare() {
+	for _, id := range d.order {
+		sp := d.speakers[id]
+		connected := connectedNetworks(sp.router)
+
+		for _, prefix := range sp.prefixes() {
+			route := sp.table[prefix]
+			if route.Metric >= ripUnreachableMetric {
+				continue
+			}
+			if route.NeighborID == 0 {
+				if _, ok := connected[prefix]; !ok {
+					d.invalidate(sp, route)
+				}
+				continue
+			}
+			neighbor := sp.neighbor(route.NeighborID)
+			if neighbor == nil {
+				d.invalidate(sp, route)
+				continue
+			}
+			route.NextHop, route.Interface = neighbor.nextHop, neighbor.iface
+		}
+
+		prefixes := make([]string, 0, len(connected))
+		for prefix := range connected {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Strings(prefixes)
+		for _, prefix := range prefixes {
+			route, ok := sp.table[prefix]
+			if ok && route.NeighborID == 0 && route.Metric == ripConnectedMetric {
+				route.Interface = connected[prefix]
+				continue
+			}
+			old := ripUnreachableMetric
+			action := models.RIPChangeAdded
+			if ok {
+				old, action = route.Metric, models.RIPChangeUpdated
+			}
+			route = &models.RIPRoute{RouterID: id, Prefix: prefix, Interface: connected[prefix], Metric: ripConnectedMetric}
+			sp.table[prefix] = route
+			d.record(id, route, action, old)
+		}
+	}
+}
+
+// advertise формирует обновление для соседа с учетом split horizon и poison reverse
+func (sp *ripSpeaker) advertise(to uint) []ripAdvert {
+	var adverts []ripAdvert
+	for _, prefix := range sp.prefixes() {
+		route := sp.table[prefix]
+		metric := route.Metric
+		if route.NeighborID == to {
+			if sp.process.PoisonReverse {
+				metric = ripUnreachableMetric
+			} else if sp.process.SplitHorizon {
+				continue
+			}
+		}
+		adverts = append(adverts, ripAdvert{prefix: prefix, metric: metric})
+	}
+	return adverts
+}
+
+// receive обрабатывает маршрут из обновления соседа
+func (d *ripDomain) receive(sp *ripSpeaker, from *ripNeighbor, advert ripAdvert) {
+	metric := advert.metric + 1
+	if metric > ripUnreachableMetric {
+		metric = ripUnreachableMetric
+	}
+
+	route, ok := sp.table[advert.prefix]
+	switch {
+	case !ok:
+		if metric >= ripUnreachableMetric {
+			return
+		}
+		route = &models.RIPRoute{RouterID: sp.router.ID, Prefix: advert.prefix}
+		sp.table[advert.prefix] = route
+		d.learn(sp, route, from, metric, models.RIPChangeAdded)
+
+	case route.NeighborID == 0 && route.Metric < ripUnreachableMetric:
+		// Подключенная сеть
+
+	case route.NeighborID == from.id:
+		// Обновления от соседа, через которого проложен маршрут, принимаются всегда
+		if metric == route.Metric {
+			return
+		}
+		if metric >= ripUnreachableMetric {
+			if route.Metric < ripUnreachableMetric {
+				d.invalidate(sp, route)
+			}
+			return
+		}
+		d.learn(sp, route, from, metric, models.RIPChangeUpdated)
+
+	case route.Metric >= ripUnreachableMetric:
+		// Во время hold-down маршрут не хуже прежнего игнорируется
+		if metric >= ripUnreachableMetric || route.HoldDown > 0 && metric >= route.PreviousMetric {
+			return
+		}
+		d.learn(sp, route, from, metric, models.RIPChangeUpdated)
+
+	case metric < route.Metric:
+		d.learn(sp, route, from, metric, models.RIPChangeUpdated)
+	}
+}
+
+// learn прокладывает маршрут через соседа
+func (d *ripDomain) learn(sp *ripSpeaker, route *models.RIPRoute, from *ripNeighbor, metric int, action models.RIPChangeAction) {
+	old := route.Metric
+	if action == models.RIPChangeAdded {
+		old = ripUnreachableMetric
+	}
+	route.NeighborID = from.id
+	route.NextHop = from.nextHop
+	route.Interface = from.iface
+	route.Metric = metric
+	route.HoldDown = 0
+	route.Flush = 0
+	delete(d.invalidated, route)
+	d.record(sp.router.ID, route, action, old)
+}
+
+// tick отсчитывает таймеры недостижимых маршрутов и удаляет маршруты с истекшим flush
+func (d *ripDomain) tick() {
+	for _, id := range d.order {
+		sp := d.speakers[id]
+		for _, prefix := range sp.prefixes() {
+			route := sp.table[prefix]
+			if route.Metric < ripUnreachableMetric || d.invalidated[route] {
+				continue
+			}
+			if route.HoldDown > 0 {
+				route.HoldDown--
+			}
+			route.Flush--
+			if route.Flush <= 0 {
+				delete(sp.table, prefix)
+				d.record(id, route, models.RIPChangeFlushed, route.Metric)
+			}
+		}
+	}
+}
+
+// pending проверяет, остались ли недостижимые маршруты, ожидающие удаления
+func (d *ripDomain) pending() bool {
+	for _, sp := range d.speakers {
+		for _, route := range sp.table {
+			if route.Metric >= ripUnreachableMetric {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// run применяет изменения топологии и выполняет раунды обмена обновлениями,
+// пока базы не перестанут меняться, но не больше limit раундов.
+// Возвращает число раундов и признак сходимости.
+func (d *ripDomain) run(limit int) (int, bool) {
+	d.invalidated = make(map[*models.RIPRoute]bool)
+	d.prepare()
+
+	for round := 1; round <= limit; round++ {
+		d.round = round
+		d.invalidated = make(map[*models.RIPRoute]bool)
+		before := len(d.changes)
+
+		// Все роутеры одновременно рассылают обновления по базам предыдущего раунда
+		updates := make(map[uint]map[uint][]ripAdvert)
+		for _, id := range d.order {
+			sp := d.speakers[id]
+			for _, neighbor := range sp.neighbors {
+				if updates[neighbor.id] == nil {
+					updates[neighbor.id] = make(map[uint][]ripAdvert)
+				}
+				updates[neighbor.id][id] = sp.advertise(neighbor.id)
+			}
+		}
+
+		for _, id := range d.order {
+			sp := d.speakers[id]
+			for i := range sp.neighbors {
+				from := &sp.neighbors[i]
+				for _, advert := range updates[id][from.id] {
+					d.receive(sp, from, advert)
+				}
+			}
+		}
+		d.tick()
+
+		if len(d.changes) == before && !d.pending() {
+			return round - 1, true
+		}
+	}
+	return limit, false
+}
+
+// routes возвращает достижимые маршруты RIP роутера для установки в таблицу маршрутизации
+func (d *ripDomain) routes(routerID uint) []models.Route {
+	sp := d.speakers[routerID]
+	var routes []models.Route
+	for _, prefix := range sp.prefixes() {
+		route := sp.table[prefix]
+		if route.NeighborID == 0 || route.Metric >= ripUnreachableMetric {
+			continue
+		}
+		routes = append(routes, models.Route{
+			RouterID:      routerID,
+			Prefix:        prefix,
+			NextHop:       route.NextHop,
+			Interface:     route.Interface,
+			Metric:        route.Metric,
+			AdminDistance: ripDistance,
+			Protocol:      models.RouteProtocolRIP,
+		})
+	}
+	return routes
+}
+
+// state возвращает базы RIP всех роутеров для сохранения
+func (d *ripDomain) state() []models.RIPRoute {
+	var state []models.RIPRoute
+	for _, id := range d.order {
+		sp := d.speakers[id]
+		for _, prefix := range sp.prefixes() {
+			route := *sp.table[prefix]
+			route.ID = 0
+			state = append(state, route)
+		}
+	}
+	return state
+}
+
+// recomputeRIP продолжает работу RIP с сохраненного состояния на текущей топологии,
+// выполняя не больше limit раундов, устанавливает маршруты и записывает ход сходимости,
+// если базы изменились. Возвращает признак сходимости.
+func (s *DeviceService) recomputeRIP(limit int) (bool, error) {
+	t, err := s.loadTopology()
+	if err != nil {
+		return false, err
+	}
+	processes, err := s.repo.GetRIPProcesses()
+	if err != nil {
+		return false, fmt.Errorf("failed to get RIP processes: %w", err)
+	}
+	state, err := s.repo.GetRIPRoutes()
+	if err != nil {
+		return false, fmt.Errorf("failed to get RIP routes: %w", err)
+	}
+
+	domain := buildRIPDomain(t, processes, state)
+	rounds, converged := domain.run(limit)
+
+	var convergence *models.RIPConvergence
+	if len(domain.changes) > 0 {
+		convergence = &models.RIPConvergence{
+			Rounds:    rounds,
+			Converged: converged,
+			CreatedAt: time.Now().Format(time.RFC3339),
+			Changes:   domain.changes,
+		}
+	}
+	if err := s.repo.SaveRIPState(domain.state(), convergence); err != nil {
+		return false, fmt.Errorf("failed to save RIP state: %w", err)
+	}
+
+	var routes []models.Route
+	for _, id := range domain.order {
+		routes = append(routes, domain.routes(id)...)
+	}
+	return converged, s.repo.ReplaceRoutes(models.RouteProtocolRIP, routes)
+}
+
+// GetRIP возвращает настройки процесса RIP роутера и его базу маршрутов
+func (s *DeviceService) GetRIP(routerID uint) (*models.RIPInfo, error) {
+	if _, err := s.repo.GetRouterByID(routerID); err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	process, err := s.ripProcess(routerID)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := s.repo.GetRIPRoutesByRouterID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RIP routes: %w", err)
+	}
+	return &models.RIPInfo{RIPProcess: *process, Routes: routes}, nil
+}
+
+// ripProcess возвращает процесс RIP роутера; новый процесс получает настройки по умолчанию
+func (s *DeviceService) ripProcess(routerID uint) (*models.RIPProcess, error) {
+	process, err := s.repo.GetRIPProcess(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RIP process: %w", err)
+	}
+	if process.ID == 0 {
+		process.SplitHorizon = true
+		process.HoldDownRounds = defaultRIPHoldDown
+	}
+	return process, nil
+}
+
+// UpdateRIP изменяет настройки процесса RIP роутера и запускает раунды обновлений
+func (s *DeviceService) UpdateRIP(routerID uint, req *models.UpdateRIPRequest) (*models.RIPInfo, error) {
+	router, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	if err := requireRouter(router); err != nil {
+		return nil, err
+	}
+	process, err := s.ripProcess(routerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		process.Enabled = *req.Enabled
+	}
+	if req.SplitHorizon != nil {
+		process.SplitHorizon = *req.SplitHorizon
+	}
+	if req.PoisonReverse != nil {
+		process.PoisonReverse = *req.PoisonReverse
+	}
+	if req.HoldDownRounds != nil {
+		process.HoldDownRounds = *req.HoldDownRounds
+	}
+	if process.HoldDownRounds < 0 || process.HoldDownRounds > maxRIPHoldDown {
+		return nil, fmt.Errorf("invalid hold-down: %d (must be 0-%d rounds)", process.HoldDownRounds, maxRIPHoldDown)
+	}
+
+	if err := s.repo.SaveRIPProcess(process); err != nil {
+		return nil, fmt.Errorf("failed to save RIP process: %w", err)
+	}
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
+	return s.GetRIP(routerID)
+}
+
+func (s *DeviceService) GetRIPConvergences() ([]models.RIPConvergence, error) {
+	return s.repo.GetRIPConvergences()
+}
+
+// GetRIPConvergence возвращает изменения баз RIP за прогон, сгруппированные по раундам
+func (s *DeviceService) GetRIPConvergence(id uint) (*models.RIPTimeline, error) {
+	convergence, err := s.repo.GetRIPConvergence(id)
+	if err != nil {
+		return nil, fmt.Errorf("convergence not found: %w", err)
+	}
+
+	changes := convergence.Changes
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Round < changes[j].Round })
+	convergence.Changes = nil
+
+	timeline := &models.RIPTimeline{RIPConvergence: *convergence, Timeline: []models.RIPRound{}}
+	for _, change := range changes {
+		last := len(timeline.Timeline) - 1
+		if last < 0 || timeline.Timeline[last].Round != change.Round {
+			timeline.Timeline = append(timeline.Timeline, models.RIPRound{Round: change.Round})
+			last++
+		}
+		timeline.Timeline[last].Changes = append(timeline.Timeline[last].Changes, change)
+	}
+	return timeline, nil
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"testing"
+
+	"network/internal/models"
+)
+
+// ripStub — сеть за R3, которая становится недоступной при отказе канала R2 - R3
+const ripStub = "172.16.3.0/24"
+
+// ripLineTopology строит линию R1 - R2 - R3 с сетью ripStub за R3.
+// Если failed, канал R2 - R3 отказал: соединения нет, интерфейсы на нем выключены.
+func ripLineTopology(failed bool) *topology {
+	r1 := &models.Router{ID: 1, Name: "R1", IPAddress: "10.0.0.1", Status: "active", Interfaces: []models.Interface{
+		testInterface(11, "Gi0/0", "192.168.12.1/30"),
+	}}
+	r2 := &models.Router{ID: 2, Name: "R2", IPAddress: "10.0.0.2", Status: "active", Interfaces: []models.Interface{
+		testInterface(21, "Gi0/0", "192.168.12.2/30"),
+		testInterface(22, "Gi0/1", "192.168.23.1/30"),
+	}}
+	r3 := &models.Router{ID: 3, Name: "R3", IPAddress: "10.0.0.3", Status: "active", Interfaces: []models.Interface{
+		testInterface(31, "Gi0/0", "192.168.23.2/30"),
+		testInterface(32, "Gi0/1", "172.16.3.1/24"),
+	}}
+	conns := []*models.RouterConnection{testLink(1, r1, 11, r2, 21)}
+	if failed {
+		r2.Interfaces[1].OperStatus = models.InterfaceStatusDown
+		r3.Interfaces[0].OperStatus = models.InterfaceStatusDown
+	} else {
+		conns = append(conns, testLink(2, r2, 22, r3, 31))
+	}
+	return newTestTopology([]*models.Router{r1, r2, r3}, conns)
+}
+
+// ripProcesses возвращает одинаковые процессы RIP роутеров линии
+func ripProcesses(splitHorizon, poisonReverse bool, holdDown int) []models.RIPProcess {
+	processes := make([]models.RIPProcess, 0, 3)
+	for id := uint(1); id <= 3; id++ {
+		processes = append(processes, models.RIPProcess{
+			RouterID:       id,
+			Enabled:        true,
+			SplitHorizon:   splitHorizon,
+			PoisonReverse:  poisonReverse,
+			HoldDownRounds: holdDown,
+		})
+	}
+	return processes
+}
+
+// convergedRIPState возвращает базы RIP, сошедшиеся на исправной линии
+func convergedRIPState(t *testing.T, processes []models.RIPProcess) []models.RIPRoute {
+	t.Helper()
+	d := buildRIPDomain(ripLineTopology(false), processes, nil)
+	if _, converged := d.run(maxRIPRounds); !converged {
+		t.Fatal("RIP did not converge on the healthy line")
+	}
+	route := d.speakers[1].table[ripStub]
+	if route == nil || route.Metric != 2 || route.NeighborID != 2 {
+		t.Fatalf("R1 route to %s = %+v, want metric 2 via R2", ripStub, route)
+	}
+	return d.state()
+}
+
+// failRIPLink запускает RIP после отказа канала R2 - R3 с сошедшегося состояния
+func failRIPLink(t *testing.T, processes []models.RIPProcess) *ripDomain {
+	t.Helper()
+	state := convergedRIPState(t, processes)
+	d := buildRIPDomain(ripLineTopology(true), processes, state)
+	if _, converged := d.run(maxRIPRounds); !converged {
+		t.Fatal("RIP did not converge after the link failure")
+	}
+	return d
+}
+
+// stubChanges возвращает изменения баз по сети ripStub
+func stubChanges(d *ripDomain) []models.RIPChange {
+	var changes []models.RIPChange
+	for _, change := range d.changes {
+		if change.Prefix == ripStub {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// findRIPAdvert возвращает маршрут к префиксу из обновления
+func findRIPAdvert(adverts []ripAdvert, prefix string) *ripAdvert {
+	for i := range adverts {
+		if adverts[i].prefix == prefix {
+			return &adverts[i]
+		}
+	}
+	return nil
+}
+
+func TestRIPAdvertise(t *testing.T) {
+	tests := []struct {
+		name          string
+		splitHorizon  bool
+		poisonReverse bool
+		wantSent      bool
+		wantMetric    int
+	}{
+		{name: "no loop prevention", wantSent: true, wantMetric: 2},
+		{name: "split horizon", splitHorizon: true},
+		{name: "poison reverse", splitHorizon: true, poisonReverse: true, wantSent: true, wantMetric: models.RIPInfinity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processes := ripProcesses(tt.splitHorizon, tt.poisonReverse, defaultRIPHoldDown)
+			d := buildRIPDomain(ripLineTopology(false), processes, convergedRIPState(t, processes))
+			r1 := d.speakers[1]
+
+			// Маршрут получен от R2 и не должен возвращаться к нему с настоящей метрикой
+			advert := findRIPAdvert(r1.advertise(2), ripStub)
+			if (advert != nil) != tt.wantSent {
+				t.Fatalf("advert of %s to R2 = %+v, want sent %v", ripStub, advert, tt.wantSent)
+			}
+			if advert != nil && advert.metric != tt.wantMetric {
+				t.Errorf("advert metric to R2 = %d, want %d", advert.metric, tt.wantMetric)
+			}
+
+			// Подключенные сети R1 R2 получает всегда
+			if advert := findRIPAdvert(r1.advertise(2), "10.0.0.1/32"); advert == nil || advert.metric != 0 {
+				t.Errorf("advert of connected 10.0.0.1/32 to R2 = %+v, want metric 0", advert)
+			}
+		})
+	}
+}
+
+func TestRIPLinkFailure(t *testing.T) {
+	tests := []struct {
+		name          string
+		splitHorizon  bool
+		poisonReverse bool
+		holdDown      int
+		wantCount     bool // метрики ripStub растут до бесконечности
+	}{
+		{name: "count to infinity", wantCount: true},
+		{name: "split horizon", splitHorizon: true},
+		{name: "poison reverse", splitHorizon: true, poisonReverse: true},
+		{name: "hold-down", holdDown: defaultRIPHoldDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := failRIPLink(t, ripProcesses(tt.splitHorizon, tt.poisonReverse, tt.holdDown))
+
+			// Метрики, с которыми роутеры заново прокладывали маршрут к потерянной сети
+			var relearned []int
+			flushed := make(map[uint]bool)
+			for _, change := range stubChanges(d) {
+				switch change.Action {
+				case models.RIPChangeAdded, models.RIPChangeUpdated:
+					relearned = append(relearned, change.NewMetric)
+				case models.RIPChangeFlushed:
+					flushed[change.RouterID] = true
+				}
+			}
+
+			if tt.wantCount {
+				for i := 1; i < len(relearned); i++ {
+					if relearned[i] <= relearned[i-1] {
+						t.Fatalf("metrics %v do not grow", relearned)
+					}
+				}
+				if len(relearned) == 0 || relearned[len(relearned)-1] != models.RIPInfinity-1 {
+					t.Fatalf("metrics %v do not count up to %d", relearned, models.RIPInfinity-1)
+				}
+			} else if len(relearned) > 0 {
+				t.Fatalf("lost network relearned with metrics %v", relearned)
+			}
+
+			for _, id := range []uint{1, 2} {
+				if !flushed[id] {
+					t.Errorf("R%d did not flush %s", id, ripStub)
+				}
+				if route := findRoute(d.routes(id), ripStub); route != nil {
+					t.Errorf("R%d still routes %s: %+v", id, ripStub, route)
+				}
+			}
+		})
+	}
+}
+
+func TestRIPHoldDown(t *testing.T) {
+	processes := ripProcesses(false, false, defaultRIPHoldDown)
+	state := convergedRIPState(t, processes)
+
+	// Первый раунд после отказа: R2 теряет соседа, R1 получает от R2 недостижимый маршрут
+	d := buildRIPDomain(ripLineTopology(true), processes, state)
+	d.run(1)
+	for _, id := range []uint{1, 2} {
+		route := d.speakers[id].table[ripStub]
+		if route == nil || route.Metric != models.RIPInfinity {
+			t.Fatalf("R%d route to %s = %+v, want unreachable", id, ripStub, route)
+		}
+	}
+	r2 := d.speakers[2].table[ripStub]
+	if r2.PreviousMetric != 1 || r2.HoldDown != defaultRIPHoldDown-1 {
+		t.Errorf("R2 route = %+v, want previous metric 1 and hold-down %d", r2, defaultRIPHoldDown-1)
+	}
+
+	// Во время hold-down R2 игнорирует худший маршрут от R1, пока тот его еще объявлял
+	for _, change := range stubChanges(d) {
+		if change.RouterID == 2 && change.Action != models.RIPChangeInvalidated {
+			t.Errorf("R2 accepted %s during hold-down: %+v", ripStub, change)
+		}
+	}
+
+	// Маршрут удаляется после hold-down и сборки мусора; у R1 таймеры запущены на раунд позже
+	d = failRIPLink(t, processes)
+	wantRound := map[uint]int{
+		2: defaultRIPHoldDown + ripGarbageRounds,
+		1: defaultRIPHoldDown + ripGarbageRounds + 1,
+	}
+	for _, change := range stubChanges(d) {
+		if change.Action != models.RIPChangeFlushed {
+			continue
+		}
+		if change.Round != wantRound[change.RouterID] {
+			t.Errorf("R%d flushed %s in round %d, want %d", change.RouterID, ripStub, change.Round, wantRound[change.RouterID])
+		}
+		delete(wantRound, change.RouterID)
+	}
+	for id := range wantRound {
+		t.Errorf("R%d did not flush %s", id, ripStub)
+	}
+
+	// Восстановленный канал принимается от прежнего соседа и во время hold-down
+	d = buildRIPDomain(ripLineTopology(true), processes, state)
+	d.run(1)
+	d = buildRIPDomain(ripLineTopology(false), processes, d.state())
+	if _, converged := d.run(maxRIPRounds); !converged {
+		t.Fatal("RIP did not converge after the link was restored")
+	}
+	if route := findRoute(d.routes(1), ripStub); route == nil || route.Metric != 2 {
+		t.Errorf("R1 route to %s after restore = %+v, want metric 2", ripStub, route)
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"network/internal/models"
+)
+
+// defaultStaticDistance — административное расстояние статического маршрута по умолчанию
+const defaultStaticDistance = 1
+
+// normalizePrefix проверяет префикс и приводит его к адресу сети (10.0.0.1/24 -> 10.0.0.0/24)
+func normalizePrefix(prefix string) (string, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return "", fmt.Errorf("invalid prefix: %s", prefix)
+	}
+	return network.String(), nil
+}
+
+// validateRoute проверяет поля маршрута перед сохранением
+func validateRoute(route *models.Route) error {
+	prefix, err := normalizePrefix(route.Prefix)
+	if err != nil {
+		return err
+	}
+	route.Prefix = prefix
+
+	if route.NextHop != "" && net.ParseIP(route.NextHop) == nil {
+		return fmt.Errorf("invalid next hop: %s", route.NextHop)
+	}
+	if route.NextHop == "" && route.Interface == "" {
+		return fmt.Errorf("either next_hop or interface is required")
+	}
+	if route.Metric < 0 {
+		return fmt.Errorf("invalid metric: %d", route.Metric)
+	}
+	if route.AdminDistance < 0 || route.AdminDistance > 255 {
+		return fmt.Errorf("invalid administrative distance: %d (must be 0-255)", route.AdminDistance)
+	}
+	return nil
+}
+
+// lookupRoute выбирает маршрут к адресу по наибольшему совпадению префикса.
+// При равной длине префикса предпочитается меньшее административное расстояние, затем меньшая метрика.
+func lookupRoute(routes []models.Route, ip net.IP) *models.Route {
+	var best *models.Route
+	bestLen := -1
+
+	for i := range routes {
+		_, network, err := net.ParseCIDR(routes[i].Prefix)
+		if err != nil || !network.Contains(ip) {
+			continue
+		}
+
+		length, _ := network.Mask.Size()
+		switch {
+		case length > bestLen:
+		case length == bestLen && routes[i].AdminDistance < best.AdminDistance:
+		case length == bestLen && routes[i].AdminDistance == best.AdminDistance && routes[i].Metric < best.Metric:
+		default:
+			continue
+		}
+		best = &routes[i]
+		bestLen = length
+	}
+
+	return best
+}
+
+// connectedRoutes возвращает маршруты к подсетям работающих интерфейсов роутера
+func connectedRoutes(router *models.Router) []models.Route {
+	var routes []models.Route
+	for i := range router.Interfaces {
+		iface := &router.Interfaces[i]
+		if iface.OperStatus != models.InterfaceStatusUp {
+			continue
+		}
+		for _, prefix := range interfacePrefixes(iface) {
+			routes = append(routes, models.Route{
+				RouterID:  router.ID,
+				Prefix:    prefix,
+				Interface: iface.Name,
+				Protocol:  models.RouteProtocolConnected,
+			})
+		}
+	}
+	return routes
+}
+
+// GetRoutes возвращает таблицу маршрутизации роутера: подключенные сети и сохраненные маршруты
+func (s *DeviceService) GetRoutes(routerID uint) ([]models.Route, error) {
+	router, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+
+	routes, err := s.repo.GetRoutesByRouterID(routerID)
+	if err != nil {
+		return nil, err
+	}
+	routes = append(gatewayRoutes(router), routes...)
+	return append(connectedRoutes(router), routes...), nil
+}
+
+// CreateRoute добавляет статический маршрут в таблицу маршрутизации роутера
+func (s *DeviceService) CreateRoute(routerID uint, req *models.CreateRouteRequest) (*models.Route, error) {
+	router, err := s.repo.GetRouterByID(routerID)
+	if err != nil {
+		return nil, fmt.Errorf("router not found: %w", err)
+	}
+	if err := requireRoutingTable(router); err != nil {
+		return nil, err
+	}
+
+	route := &models.Route{
+		RouterID:      routerID,
+		Prefix:        req.Prefix,
+		NextHop:       req.NextHop,
+		Interface:     req.Interface,
+		Metric:        req.Metric,
+		AdminDistance: defaultStaticDistance,
+		Protocol:      models.RouteProtocolStatic,
+	}
+	if req.AdminDistance != nil {
+		route.AdminDistance = *req.AdminDistance
+	}
+
+	if err := validateRoute(route); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateRoute(route); err != nil {
+		return nil, fmt.Errorf("failed to create route: %w", err)
+	}
+	// От статических маршрутов зависит достижимость next hop маршрутов BGP
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
+
+	return route, nil
+}
+
+// UpdateRoute изменяет статический маршрут
+func (s *DeviceService) UpdateRoute(routerID, routeID uint, req *models.UpdateRouteRequest) (*models.Route, error) {
+	route, err := s.repo.GetRoute(routerID, routeID)
+	if err != nil {
+		return nil, fmt.Errorf("route not found: %w", err)
+	}
+
+	if route.Protocol != models.RouteProtocolStatic {
+		return nil, fmt.Errorf("only static routes can be modified")
+	}
+
+	if req.Prefix != nil {
+		route.Prefix = *req.Prefix
+	}
+	if req.NextHop != nil {
+		route.NextHop = *req.NextHop
+	}
+	if req.Interface != nil {
+		route.Interface = *req.Interface
+	}
+	if req.Metric != nil {
+		route.Metric = *req.Metric
+	}
+	if req.AdminDistance != nil {
+		route.AdminDistance = *req.AdminDistance
+	}
+
+	if err := validateRoute(route); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateRoute(route); err != nil {
+		return nil, fmt.Errorf("failed to update route: %w", err)
+	}
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
+
+	return route, nil
+}
+
+func (s *DeviceService) DeleteRoute(routerID, routeID uint) error {
+	route, err := s.repo.GetRoute(routerID, routeID)
+	if err != nil {
+		return fmt.Errorf("route not found: %w", err)
+	}
+	if route.Protocol != models.RouteProtocolStatic {
+		return fmt.Errorf("only static routes can be deleted")
+	}
+	if err := s.repo.DeleteRoute(routerID, routeID); err != nil {
+		return err
+	}
+	return s.reconverge()
+}
+
+// reconverge пересчитывает маршруты протоколов динамической маршрутизации
+// после изменения состояния роутеров, соединений или интерфейсов
+func (s *DeviceService) reconverge() error {
+	if err := s.recomputeOSPF(); err != nil {
+		return fmt.Errorf("failed to recompute OSPF routes: %w", err)
+	}
+	if _, err := s.recomputeRIP(maxRIPRounds); err != nil {
+		return fmt.Errorf("failed to recompute RIP routes: %w", err)
+	}
+	// BGP проверяет достижимость next hop по уже пересчитанным маршрутам IGP
+	if err := s.recomputeBGP(); err != nil {
+		return fmt.Errorf("failed to recompute BGP routes: %w", err)
+	}
+	return nil
+}
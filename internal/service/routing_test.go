@@ -0,0 +1,160 @@
+package service
+
+import (
+	"net"
+	"testing"
+
+	"network/internal/models"
+)
+
+func TestLookupRoute(t *testing.T) {
+	routes := []models.Route{
+		{ID: 1, Prefix: "0.0.0.0/0", NextHop: "192.168.0.1", AdminDistance: 1},
+		{ID: 2, Prefix: "10.0.0.0/8", NextHop: "192.168.0.2", AdminDistance: 1},
+		{ID: 3, Prefix: "10.1.0.0/16", NextHop: "192.168.0.3", AdminDistance: 110, Metric: 20},
+		{ID: 4, Prefix: "10.1.0.0/16", NextHop: "192.168.0.4", AdminDistance: 1, Metric: 50},
+		{ID: 5, Prefix: "10.1.1.0/24", NextHop: "192.168.0.5", AdminDistance: 120, Metric: 3},
+		{ID: 6, Prefix: "10.1.1.0/24", NextHop: "192.168.0.6", AdminDistance: 120, Metric: 2},
+		{ID: 7, Prefix: "10.1.1.7/32", Interface: "Gi0/1"},
+	}
+
+	tests := []struct {
+		name string
+		ip   string
+		want uint
+	}{
+		{name: "longest prefix wins over shorter ones", ip: "10.1.2.3", want: 4},
+		{name: "host route is the longest match", ip: "10.1.1.7", want: 7},
+		{name: "equal prefixes prefer lower distance", ip: "10.1.200.1", want: 4},
+		{name: "equal distances prefer lower metric", ip: "10.1.1.8", want: 6},
+		{name: "covering prefix", ip: "10.200.0.1", want: 2},
+		{name: "default route", ip: "172.16.0.1", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := lookupRoute(routes, net.ParseIP(tt.ip))
+			if route == nil {
+				t.Fatalf("no route to %s, want route %d", tt.ip, tt.want)
+			}
+			if route.ID != tt.want {
+				t.Errorf("route to %s = %d (%s), want %d", tt.ip, route.ID, route.Prefix, tt.want)
+			}
+		})
+	}
+
+	t.Run("no default route", func(t *testing.T) {
+		if route := lookupRoute(routes[1:], net.ParseIP("172.16.0.1")); route != nil {
+			t.Errorf("route to 172.16.0.1 = %d (%s), want none", route.ID, route.Prefix)
+		}
+	})
+}
+
+func TestResolveNextHop(t *testing.T) {
+	r1 := &models.Router{ID: 1, Name: "R1", IPAddress: "10.0.0.1", Status: "active", Interfaces: []models.Interface{
+		testInterface(11, "Gi0/0", "192.168.12.1/30"),
+	}}
+	r2 := &models.Router{ID: 2, Name: "R2", IPAddress: "10.0.0.2", Status: "active", Interfaces: []models.Interface{
+		testInterface(21, "Gi0/0", "192.168.12.2/30"),
+	}}
+	conn := testLink(1, r1, 11, r2, 21)
+	topo := newTestTopology([]*models.Router{r1, r2}, []*models.RouterConnection{conn})
+
+	tests := []struct {
+		name     string
+		routes   []models.Route
+		nextHop  string
+		egress   string
+		want     *models.Router
+		resolved string
+	}{
+		{
+			name:     "directly connected",
+			nextHop:  "192.168.12.2",
+			want:     r2,
+			resolved: "192.168.12.2",
+		},
+		{
+			name: "recursive through two routes",
+			routes: []models.Route{
+				{Prefix: "172.16.0.0/16", NextHop: "10.9.0.1"},
+				{Prefix: "10.9.0.0/16", NextHop: "192.168.12.2"},
+			},
+			nextHop:  "172.16.5.5",
+			want:     r2,
+			resolved: "192.168.12.2",
+		},
+		{
+			name:     "unresolvable",
+			nextHop:  "172.16.5.5",
+			resolved: "172.16.5.5",
+		},
+		{
+			name:     "route through itself",
+			routes:   []models.Route{{Prefix: "10.9.0.0/16", NextHop: "10.9.0.1"}},
+			nextHop:  "10.9.0.1",
+			resolved: "10.9.0.1",
+		},
+		{
+			name:     "on the route's interface",
+			nextHop:  "192.168.12.2",
+			egress:   "Gi0/0",
+			want:     r2,
+			resolved: "192.168.12.2",
+		},
+		{
+			name:     "on another interface",
+			nextHop:  "192.168.12.2",
+			egress:   "Gi0/1",
+			resolved: "192.168.12.2",
+		},
+		{
+			name: "recursive through a route with an interface",
+			routes: []models.Route{
+				{Prefix: "10.9.0.0/16", NextHop: "192.168.12.2"},
+			},
+			nextHop:  "10.9.0.1",
+			egress:   "Gi0/0",
+			resolved: "10.9.0.1",
+		},
+		{
+			name: "loop of two routes",
+			routes: []models.Route{
+				{Prefix: "10.8.0.0/16", NextHop: "10.9.0.1"},
+				{Prefix: "10.9.0.0/16", NextHop: "10.8.0.1"},
+			},
+			nextHop: "10.8.0.1",
+		},
+		{
+			name: "chain longer than the recursion limit",
+			routes: func() []models.Route {
+				var routes []models.Route
+				for i := 1; i <= maxNextHopRecursion; i++ {
+					routes = append(routes, models.Route{
+						Prefix:  net.IPv4(10, 100, byte(i), 0).String() + "/24",
+						NextHop: net.IPv4(10, 100, byte(i+1), 1).String(),
+					})
+				}
+				return append(routes, models.Route{
+					Prefix:  net.IPv4(10, 100, maxNextHopRecursion+1, 0).String() + "/24",
+					NextHop: "192.168.12.2",
+				})
+			}(),
+			nextHop: "10.100.1.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r1.Routes = tt.routes
+			next, link, resolved := topo.resolveNextHop(r1.ID, tt.nextHop, tt.egress)
+			if next != tt.want {
+				t.Fatalf("next hop %s resolved to %v, want %v", tt.nextHop, next, tt.want)
+			}
+			if tt.want != nil && link != conn {
+				t.Errorf("next hop %s resolved over connection %v, want %d", tt.nextHop, link, conn.ID)
+			}
+			if tt.resolved != "" && resolved != tt.resolved {
+				t.Errorf("resolution of %s stopped at %s, want %s", tt.nextHop, resolved, tt.resolved)
+			}
+		})
+	}
+}
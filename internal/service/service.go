@@ -4,10 +4,13 @@ import "network/internal/repository"
 
 type Service struct {
 	Devices *DeviceService
+	IPAM    *IPAMService
 }
 
 func NewService(repos *repository.Repository) *Service {
+	ipam := NewIPAMService(repos.IPAM, repos.Devices)
 	return &Service{
-		Devices: NewDeviceService(repos.Devices),
+		Devices: NewDeviceService(repos.Devices, ipam),
+		IPAM:    ipam,
 	}
 }
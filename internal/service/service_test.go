@@ -0,0 +1,153 @@
+package service
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"network/internal/models"
+	"network/internal/repository"
+	storage "network/internal/storage/sqlite"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestService возвращает сервисы поверх пустой базы во временном каталоге теста и саму базу
+func newTestService(t *testing.T) (*Service, *gorm.DB) {
+	t.Helper()
+	db, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	db.Logger = logger.Discard
+	if err := db.AutoMigrate(
+		&models.Router{},
+		&models.Port{},
+		&models.RouterConnection{},
+		&models.Interface{},
+		&models.Route{},
+		&models.SimulationConfig{},
+		&models.IPPool{},
+		&models.IPAllocation{},
+		&models.OSPFProcess{},
+		&models.BGPProcess{},
+		&models.BGPNeighbor{},
+		&models.BGPNetwork{},
+		&models.BGPRouteMapEntry{},
+		&models.RIPProcess{},
+		&models.RIPRoute{},
+		&models.RIPConvergence{},
+		&models.RIPChange{},
+		&models.SwitchConfig{},
+		&models.STPConfig{},
+		&models.ARPConfig{},
+		&models.ARPEntry{},
+		&models.ACLEntry{},
+		&models.FirewallConfig{},
+		&models.NATRule{},
+		&models.DHCPPool{},
+		&models.DHCPLease{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewService(repository.NewRepository(db)), db
+}
+
+// mustCreateRouter создает подключенный роутер с адресом ip на Loopback0.
+// Интерфейсы роутера входят в магистральную область OSPF.
+func mustCreateRouter(t *testing.T, s *DeviceService, name, ip string) *models.Router {
+	t.Helper()
+	router, err := s.CreateRouter(&models.CreateRouterRequest{
+		Name:      name,
+		IPAddress: ip,
+		Interfaces: []models.CreateInterfaceRequest{
+			{Name: "Gi0/0", OSPFArea: "0"},
+			{Name: "Gi0/1", OSPFArea: "0"},
+			{Name: "Loopback0", Type: models.InterfaceTypeLoopback, IPv4Address: ip, IPv4PrefixLength: 32, OSPFArea: "0"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create router %s: %v", name, err)
+	}
+	if err := s.repo.ConnectRouter(router.ID); err != nil {
+		t.Fatalf("connect router %s: %v", name, err)
+	}
+	router.Connected = true
+	return router
+}
+
+// mustEnableOSPF включает OSPF на роутерах
+func mustEnableOSPF(t *testing.T, s *DeviceService, routers ...*models.Router) {
+	t.Helper()
+	enabled := true
+	for _, router := range routers {
+		if _, err := s.UpdateOSPF(router.ID, &models.UpdateOSPFRequest{Enabled: &enabled}); err != nil {
+			t.Fatalf("enable OSPF on %s: %v", router.Name, err)
+		}
+	}
+}
+
+// mustConnect соединяет роутеры a и b каналом с заданными задержкой, джиттером и потерями
+func mustConnect(t *testing.T, s *DeviceService, a, b *models.Router, delay, jitter, loss float64) *models.CreateConnectionResponse {
+	t.Helper()
+	conn, err := s.CreateConnection(&models.CreateConnectionRequest{
+		RouterFromIP: a.IPAddress,
+		RouterToIP:   b.IPAddress,
+		Delay:        &delay,
+		Jitter:       &jitter,
+		PacketLoss:   &loss,
+	})
+	if err != nil {
+		t.Fatalf("connect %s and %s: %v", a.Name, b.Name, err)
+	}
+	return conn
+}
+
+// newTestTopology строит топологию из роутеров и активных соединений без базы данных
+func newTestTopology(routers []*models.Router, conns []*models.RouterConnection) *topology {
+	t := &topology{
+		routers:     make(map[uint]*models.Router),
+		byIP:        make(map[string]*models.Router),
+		links:       make(map[uint][]topologyLink),
+		connections: make(map[uint]*models.RouterConnection),
+	}
+	for _, router := range routers {
+		t.routers[router.ID] = router
+		t.byIP[router.IPAddress] = router
+		for _, iface := range router.Interfaces {
+			if iface.IPv4Address != "" {
+				t.byIP[iface.IPv4Address] = router
+			}
+		}
+	}
+	for _, conn := range conns {
+		t.connections[conn.ID] = conn
+		t.links[conn.RouterFromID] = append(t.links[conn.RouterFromID], topologyLink{to: conn.RouterToID, conn: conn})
+		t.links[conn.RouterToID] = append(t.links[conn.RouterToID], topologyLink{to: conn.RouterFromID, conn: conn})
+	}
+	return t
+}
+
+// testInterface возвращает работающий интерфейс с адресом в записи CIDR
+func testInterface(id uint, name, cidr string) models.Interface {
+	iface := models.Interface{ID: id, Name: name, OperStatus: models.InterfaceStatusUp}
+	if ip, subnet, err := net.ParseCIDR(cidr); err == nil {
+		iface.IPv4Address = ip.String()
+		iface.IPv4PrefixLength, _ = subnet.Mask.Size()
+	}
+	return iface
+}
+
+// testLink возвращает активное соединение между интерфейсами двух роутеров
+func testLink(id uint, from *models.Router, fromIface uint, to *models.Router, toIface uint) *models.RouterConnection {
+	return &models.RouterConnection{
+		ID:              id,
+		RouterFromID:    from.ID,
+		RouterToID:      to.ID,
+		FromInterfaceID: fromIface,
+		ToInterfaceID:   toIface,
+		Status:          "active",
+		Bandwidth:       defaultLinkBandwidth,
+	}
+}
@@ -0,0 +1,632 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"network/internal/models"
+	"time"
+)
+
+// Параметры виртуальных часов
+const (
+	// defaultDetectionDelay — время до реакции протоколов маршрутизации на отказ соединения, мс
+	defaultDetectionDelay = 1000.0
+	defaultPacketInterval = 1000.0 // мс
+	defaultClockSpeed     = 1.0
+
+	maxScheduledPackets = 1000
+	maxEventsPerRun     = 100000
+	// simulationTick — период продвижения запущенных часов в реальном времени
+	simulationTick = 50 * time.Millisecond
+)
+
+// simPacket — пакет в симуляции и состояние его пересылки
+type simPacket struct {
+	models.SimulationPacket
+	sourceID  uint
+	ttl       int
+	attemptAt float64      // время отправки текущей попытки
+	pkt       packetHeader // заголовок пакета с учетом трансляций NAT на пути
+}
+
+// header возвращает заголовок пакета, отправляемого роутером-отправителем
+func (p *simPacket) header() packetHeader {
+	return packetHeader{
+		srcIP:    p.SourceIP,
+		dstIP:    p.DestinationIP,
+		protocol: p.Protocol,
+		srcPort:  defaultSourcePort,
+		dstPort:  p.Port,
+		flags:    tcpSYN,
+	}
+}
+
+// detectionDelay возвращает задержку обнаружения отказа из настроек симуляции
+func (s *DeviceService) detectionDelay() float64 {
+	if config, err := s.repo.GetSimulationConfig(); err == nil && config.DetectionDelay != nil {
+		return *config.DetectionDelay
+	}
+	return defaultDetectionDelay
+}
+
+// prepareClock подготавливает часы к обработке запроса: топология могла измениться
+// через API, поэтому перечитывается, а источник случайных чисел создается при первом обращении
+func (s *DeviceService) prepareClock() {
+	c := s.clock
+	c.topo = nil
+	if c.rng == nil {
+		c.rng, c.seed = s.newRand(nil)
+	}
+}
+
+// clockTopology возвращает топологию на текущий момент виртуального времени
+func (s *DeviceService) clockTopology() (*topology, error) {
+	c := s.clock
+	if c.topo == nil {
+		t, err := s.loadTopology()
+		if err != nil {
+			return nil, err
+		}
+		c.topo = t
+	}
+	return c.topo, nil
+}
+
+// sendPacket начинает очередную попытку передачи пакета с роутера-отправителя
+func (s *DeviceService) sendPacket(p *simPacket) string {
+	c := s.clock
+	t, err := s.clockTopology()
+	if err != nil {
+		return s.dropPacket(p, err.Error(), false)
+	}
+	source := t.routers[p.sourceID]
+	if source == nil {
+		return s.dropPacket(p, "source router not found", false)
+	}
+
+	p.Attempts++
+	p.attemptAt = c.now
+	p.ttl = defaultTTL
+	p.pkt = p.header()
+	p.Status = models.SimulationPacketInFlight
+	hop := newHop(source, nil)
+	hop.Latency = c.now - p.SentAt
+	p.Hops = []models.PacketHop{hop}
+	return s.routePacket(p, source, nil)
+}
+
+// routePacket обрабатывает пакет на роутере, получившем его через соединение in (nil — на отправителе):
+// доставляет его или передает в соединение к следующему роутеру, планируя прибытие через задержку соединения
+func (s *DeviceService) routePacket(p *simPacket, current *models.Router, in *models.RouterConnection) string {
+	c := s.clock
+	t, err := s.clockTopology()
+	if err != nil {
+		return s.dropPacket(p, err.Error(), false)
+	}
+
+	// Пакет, запрещенный ACL или межсетевым экраном, отбрасывается без повторной передачи
+	hop := &p.Hops[len(p.Hops)-1]
+	flow, err := t.inspect(hop, current, in, p.pkt)
+	if err != nil {
+		return s.dropPacket(p, err.Error(), false)
+	}
+	received := p.pkt
+	nat, err := t.translateIn(hop, current, in, &p.pkt)
+	if err != nil {
+		return s.dropPacket(p, err.Error(), false)
+	}
+	if t.ownsIP(current, p.pkt.dstIP) {
+		t.commit(hop, current, received, p.pkt, flow)
+		// Закрытый порт отвечает отказом, повторная передача не нужна
+		if err := checkPort(current, p.Protocol, p.pkt.dstPort); err != nil {
+			return s.dropPacket(p, err.Error(), false)
+		}
+		p.Status = models.SimulationPacketDelivered
+		p.Latency = c.now - p.SentAt
+		p.Error = ""
+		return fmt.Sprintf("packet %d delivered to %s", p.ID, current.IPAddress)
+	}
+	if p.ttl <= 0 {
+		return s.dropPacket(p, errTTLExceeded.Error(), true)
+	}
+	if err := checkTransit(current, p.sourceID); err != nil {
+		return s.dropPacket(p, err.Error(), true)
+	}
+
+	next, conn, err := t.nextHop(current, net.ParseIP(p.pkt.dstIP), p.pkt.dstIP)
+	if err != nil {
+		return s.dropPacket(p, err.Error(), true)
+	}
+	if err := t.translateOut(hop, current, conn, &p.pkt, received, nat); err != nil {
+		return s.dropPacket(p, err.Error(), false)
+	}
+	if !flow.tracked {
+		if err := t.filterPacket(hop, current, conn, models.ACLOutbound, p.pkt); err != nil {
+			return s.dropPacket(p, err.Error(), false)
+		}
+	}
+	t.commit(hop, current, received, p.pkt, flow)
+	p.ttl--
+
+	// Задержка и потеря определяются при передаче, но потеря обнаруживается только по прибытии.
+	// Путь через коммутаторы проходится целиком до следующего роутера.
+	hops := t.crossLink(current, next, conn)
+	base := c.now - p.SentAt
+	delay, lost := 0.0, false
+	for i, link := range t.pathLinks(hops) {
+		delay += linkDelay(c.rng, link, p.Size)
+		hops[i].Latency = base + delay
+		if !lost && c.rng.Float64() < link.PacketLoss {
+			lost = true
+		}
+	}
+	last := hops[len(hops)-1]
+	c.schedule(c.now+delay, models.SimulationEvent{
+		Type:         models.SimulationEventPacket,
+		PacketID:     p.ID,
+		RouterID:     last.RouterID,
+		ConnectionID: last.ConnectionID,
+	}, func() string {
+		return s.arrivePacket(p, hops, lost, conn)
+	})
+	return fmt.Sprintf("packet %d forwarded by %s to %s over connection %d", p.ID, current.IPAddress, next.IPAddress, hops[0].ConnectionID)
+}
+
+// arrivePacket обрабатывает прибытие пакета на следующий роутер через соединение conn
+func (s *DeviceService) arrivePacket(p *simPacket, hops []models.PacketHop, lost bool, conn *models.RouterConnection) string {
+	t, err := s.clockTopology()
+	if err != nil {
+		return s.dropPacket(p, err.Error(), false)
+	}
+	for _, hop := range hops {
+		if t.connections[hop.ConnectionID] == nil {
+			return s.dropPacket(p, fmt.Sprintf("connection %d went down while the packet was in flight", hop.ConnectionID), true)
+		}
+	}
+	last := hops[len(hops)-1]
+	if lost {
+		return s.dropPacket(p, fmt.Sprintf("packet lost on the way to %s", last.IPAddress), true)
+	}
+	router := t.routers[last.RouterID]
+	if router == nil {
+		return s.dropPacket(p, fmt.Sprintf("router %d not found", last.RouterID), false)
+	}
+
+	hops[len(hops)-1].Latency = s.clock.now - p.SentAt
+	p.Hops = append(p.Hops, hops...)
+	return s.routePacket(p, router, conn)
+}
+
+// dropPacket отбрасывает пакет. Потерянный сегмент TCP отправитель передает
+// повторно по таймауту, пока не исчерпаны попытки.
+func (s *DeviceService) dropPacket(p *simPacket, reason string, retransmit bool) string {
+	c := s.clock
+	p.Error = reason
+	if retransmit && p.Protocol == "tcp" && p.Attempts <= tcpMaxRetries {
+		at := p.attemptAt + tcpRetransmitTimeout
+		if at < c.now {
+			at = c.now
+		}
+		p.Status = models.SimulationPacketScheduled
+		c.schedule(at, models.SimulationEvent{
+			Type:     models.SimulationEventPacket,
+			PacketID: p.ID,
+			RouterID: p.sourceID,
+		}, func() string {
+			return s.sendPacket(p)
+		})
+		return fmt.Sprintf("packet %d dropped: %s, retransmission at %.3f ms", p.ID, reason, at)
+	}
+
+	p.Status = models.SimulationPacketDropped
+	p.Latency = c.now - p.SentAt
+	if p.Protocol == "tcp" && retransmit {
+		p.Error = "connection timed out: " + reason
+	}
+	return fmt.Sprintf("packet %d dropped: %s", p.ID, reason)
+}
+
+// setLinkStatus меняет статус соединения в момент виртуального времени.
+// Интерфейсы на концах реагируют сразу, а протоколы маршрутизации —
+// по истечении задержки обнаружения отказа.
+func (s *DeviceService) setLinkStatus(connID uint, status string) string {
+	c := s.clock
+	conn, err := s.repo.GetConnectionByID(connID)
+	if err != nil {
+		return fmt.Sprintf("connection %d not found", connID)
+	}
+	if conn.Status == status {
+		return fmt.Sprintf("connection %d is already %s", connID, status)
+	}
+
+	if _, ok := c.links[connID]; !ok {
+		c.links[connID] = conn.Status
+	}
+	conn.Status = status
+	if err := s.repo.UpdateConnection(conn); err != nil {
+		return fmt.Sprintf("failed to update connection %d: %v", connID, err)
+	}
+	if err := s.syncOperStatus(conn.FromInterfaceID, conn.ToInterfaceID); err != nil {
+		return err.Error()
+	}
+	c.topo = nil
+
+	at := c.now + s.detectionDelay()
+	s.scheduleRoutingTimers(at, connID)
+	return fmt.Sprintf("connection %d is %s, routing reacts at %.3f ms", connID, status, at)
+}
+
+// scheduleRoutingTimers планирует реакцию протоколов маршрутизации на изменение соединения
+// в момент at: OSPF по истечении dead interval пересчитывает SPF, RIP рассылает triggered update,
+// BGP по истечении hold timer сбрасывает сессии через соединение и пересчитывает лучшие пути
+func (s *DeviceService) scheduleRoutingTimers(at float64, connID uint) {
+	c := s.clock
+	c.schedule(at, models.SimulationEvent{
+		Type:         models.SimulationEventTimer,
+		Timer:        models.SimulationTimerOSPFDead,
+		ConnectionID: connID,
+	}, func() string {
+		c.topo = nil
+		if err := s.recomputeOSPF(); err != nil {
+			return fmt.Sprintf("failed to recompute OSPF routes: %v", err)
+		}
+		return "OSPF SPF recomputed"
+	})
+	s.scheduleRIPUpdate(at)
+	c.schedule(at, models.SimulationEvent{
+		Type:         models.SimulationEventTimer,
+		Timer:        models.SimulationTimerBGPHold,
+		ConnectionID: connID,
+	}, func() string {
+		c.topo = nil
+		if err := s.recomputeBGP(); err != nil {
+			return fmt.Sprintf("failed to recompute BGP routes: %v", err)
+		}
+		return "BGP best paths recomputed"
+	})
+}
+
+// scheduleRIPUpdate планирует раунд обновлений RIP в момент at, если более ранний
+// раунд еще не запланирован. Запланированный позже раунд заменяется.
+func (s *DeviceService) scheduleRIPUpdate(at float64) {
+	c := s.clock
+	if c.ripTimer != 0 && c.ripAt <= at {
+		return
+	}
+	var id uint64
+	id = c.schedule(at, models.SimulationEvent{
+		Type:  models.SimulationEventTimer,
+		Timer: models.SimulationTimerRIPUpdate,
+	}, func() string {
+		return s.ripUpdate(id)
+	})
+	c.ripTimer, c.ripAt = id, at
+}
+
+// ripUpdate выполняет раунд обновлений RIP и, пока базы не сошлись, планирует следующий
+// через период обновлений, поэтому hold-down и flush истекают в виртуальном времени
+func (s *DeviceService) ripUpdate(id uint64) string {
+	c := s.clock
+	if id != c.ripTimer {
+		return "replaced by an earlier RIP update"
+	}
+	c.ripTimer = 0
+	c.topo = nil
+	converged, err := s.recomputeRIP(1)
+	if err != nil {
+		return fmt.Sprintf("failed to recompute RIP routes: %v", err)
+	}
+	// BGP проверяет достижимость next hop по изменившимся маршрутам RIP
+	if err := s.recomputeBGP(); err != nil {
+		return fmt.Sprintf("failed to recompute BGP routes: %v", err)
+	}
+	if converged {
+		return "RIP converged"
+	}
+	s.scheduleRIPUpdate(c.now + ripUpdateInterval)
+	return fmt.Sprintf("RIP update round, next at %.3f ms", c.ripAt)
+}
+
+// validateScheduleTime возвращает момент планирования: заданный или текущий
+func (c *simClock) validateScheduleTime(at *float64) (float64, error) {
+	if at == nil {
+		return c.now, nil
+	}
+	if *at < c.now {
+		return 0, fmt.Errorf("cannot schedule at %v ms: clock is at %v ms", *at, c.now)
+	}
+	return *at, nil
+}
+
+// SchedulePackets планирует отправку пакетов с роутера в заданный момент виртуального времени
+func (s *DeviceService) SchedulePackets(req *models.SchedulePacketRequest) ([]models.SimulationPacket, error) {
+	c := s.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s.prepareClock()
+
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.Interval == 0 {
+		req.Interval = defaultPacketInterval
+	}
+	if req.Count < 1 || req.Count > maxScheduledPackets {
+		return nil, fmt.Errorf("invalid count: %d (must be 1-%d)", req.Count, maxScheduledPackets)
+	}
+	if req.Interval < 0 {
+		return nil, fmt.Errorf("invalid interval: %v", req.Interval)
+	}
+	if req.Port < 1 || req.Port > 65535 {
+		return nil, fmt.Errorf("invalid port: %d", req.Port)
+	}
+	if net.ParseIP(req.DestinationIP) == nil {
+		return nil, fmt.Errorf("invalid destination IP: %s", req.DestinationIP)
+	}
+
+	var size int
+	switch req.Protocol {
+	case "tcp":
+		size = tcpHeaderSize + len(req.Data)
+	case "udp":
+		size = udpHeaderSize + len(req.Data)
+	default:
+		return nil, fmt.Errorf("unsupported protocol: %s", req.Protocol)
+	}
+
+	start, err := c.validateScheduleTime(req.At)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.clockTopology()
+	if err != nil {
+		return nil, err
+	}
+	source := t.byIP[req.SourceIP]
+	if source == nil {
+		return nil, fmt.Errorf("source router with IP %s not found", req.SourceIP)
+	}
+
+	packets := make([]models.SimulationPacket, 0, req.Count)
+	for i := 0; i < req.Count; i++ {
+		p := &simPacket{
+			SimulationPacket: models.SimulationPacket{
+				ID:            uint(len(c.packets) + 1),
+				SourceIP:      req.SourceIP,
+				DestinationIP: req.DestinationIP,
+				Protocol:      req.Protocol,
+				Port:          req.Port,
+				Size:          size,
+				SentAt:        start + float64(i)*req.Interval,
+				Status:        models.SimulationPacketScheduled,
+				Hops:          []models.PacketHop{},
+			},
+			sourceID: source.ID,
+		}
+		c.packets = append(c.packets, p)
+		c.schedule(p.SentAt, models.SimulationEvent{
+			Type:     models.SimulationEventPacket,
+			PacketID: p.ID,
+			RouterID: source.ID,
+		}, func() string {
+			return s.sendPacket(p)
+		})
+		packets = append(packets, p.SimulationPacket)
+	}
+	return packets, nil
+}
+
+// ScheduleFailure планирует отказ соединения и, если задана длительность, его восстановление
+func (s *DeviceService) ScheduleFailure(req *models.ScheduleFailureRequest) ([]models.SimulationEvent, error) {
+	c := s.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s.prepareClock()
+
+	if _, err := s.repo.GetConnectionByID(req.ConnectionID); err != nil {
+		return nil, fmt.Errorf("connection not found: %w", err)
+	}
+	if req.Duration < 0 {
+		return nil, fmt.Errorf("invalid duration: %v", req.Duration)
+	}
+	at, err := c.validateScheduleTime(req.At)
+	if err != nil {
+		return nil, err
+	}
+
+	connID := req.ConnectionID
+	down := models.SimulationEvent{Type: models.SimulationEventLinkDown, ConnectionID: connID}
+	down.ID = c.schedule(at, down, func() string {
+		return s.setLinkStatus(connID, "inactive")
+	})
+	down.Time = at
+	events := []models.SimulationEvent{down}
+
+	if req.Duration > 0 {
+		up := models.SimulationEvent{Type: models.SimulationEventLinkUp, ConnectionID: connID}
+		up.ID = c.schedule(at+req.Duration, up, func() string {
+			return s.setLinkStatus(connID, "active")
+		})
+		up.Time = at + req.Duration
+		events = append(events, up)
+	}
+	return events, nil
+}
+
+func (s *DeviceService) GetClock() *models.SimulationClock {
+	c := s.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state()
+}
+
+// StepClock обрабатывает следующие события, перемещая часы к моменту каждого из них
+func (s *DeviceService) StepClock(req *models.StepClockRequest) (*models.SimulationClock, error) {
+	c := s.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.Count < 1 || req.Count > maxEventsPerRun {
+		return nil, fmt.Errorf("invalid count: %d (must be 1-%d)", req.Count, maxEventsPerRun)
+	}
+	if c.running {
+		return nil, fmt.Errorf("clock is running, pause it first")
+	}
+
+	s.prepareClock()
+	c.advance(math.Inf(1), req.Count)
+	return c.state(), nil
+}
+
+// RunClock обрабатывает все события до заданного момента и переводит на него часы
+func (s *DeviceService) RunClock(req *models.RunClockRequest) (*models.SimulationClock, error) {
+	c := s.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil, fmt.Errorf("clock is running, pause it first")
+	}
+	if req.Time < c.now {
+		return nil, fmt.Errorf("cannot run back to %v ms: clock is at %v ms", req.Time, c.now)
+	}
+
+	s.prepareClock()
+	c.advance(req.Time, maxEventsPerRun)
+	// Часы останавливаются на последнем событии, если лимит событий исчерпан
+	if len(c.queue) == 0 || c.queue[0].Time > req.Time {
+		c.setNow(req.Time)
+	}
+	return c.state(), nil
+}
+
+// StartClock запускает часы: виртуальное время идет со скоростью speed относительно реального
+func (s *DeviceService) StartClock(req *models.StartClockRequest) (*models.SimulationClock, error) {
+	c := s.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if req.Speed == 0 {
+		req.Speed = defaultClockSpeed
+	}
+	if req.Speed < 0 {
+		return nil, fmt.Errorf("invalid speed: %v", req.Speed)
+	}
+	c.speed = req.Speed
+	if !c.running {
+		s.prepareClock()
+		c.running = true
+		c.stop = make(chan struct{})
+		go s.runClock(c.stop)
+	}
+	return c.state(), nil
+}
+
+// runClock продвигает запущенные часы, пока они не будут остановлены.
+// События обрабатываются под блокировкой изменений топологии, как и запросы API.
+func (s *DeviceService) runClock(stop chan struct{}) {
+	ticker := time.NewTicker(simulationTick)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if !s.tick(stop, float64(now.Sub(last).Microseconds())/1000) {
+				return
+			}
+			last = now
+		}
+	}
+}
+
+// tick продвигает запущенные часы на elapsed мс реального времени.
+// Возвращает false, если часы остановили, пока тик ожидал блокировки.
+func (s *DeviceService) tick(stop chan struct{}, elapsed float64) bool {
+	c := s.clock
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-stop:
+		return false
+	default:
+	}
+	until := c.now + elapsed*c.speed
+	c.topo = nil
+	c.advance(until, maxEventsPerRun)
+	if len(c.queue) == 0 || c.queue[0].Time > until {
+		c.setNow(until)
+	}
+	return true
+}
+
+// PauseClock останавливает запущенные часы
+func (s *DeviceService) PauseClock() *models.SimulationClock {
+	c := s.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pause()
+	return c.state()
+}
+
+func (c *simClock) pause() {
+	if c.running {
+		close(c.stop)
+		c.running = false
+	}
+}
+
+// ResetClock останавливает часы, очищает очередь и журнал и возвращает
+// соединениям статусы, которые были у них до изменения симуляцией
+func (s *DeviceService) ResetClock() (*models.SimulationClock, error) {
+	c := s.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pause()
+	restored := false
+	for connID, status := range c.links {
+		conn, err := s.repo.GetConnectionByID(connID)
+		if err != nil {
+			continue
+		}
+		if conn.Status != status {
+			conn.Status = status
+			if err := s.repo.UpdateConnection(conn); err != nil {
+				return nil, fmt.Errorf("failed to restore connection %d: %w", connID, err)
+			}
+			if err := s.syncOperStatus(conn.FromInterfaceID, conn.ToInterfaceID); err != nil {
+				return nil, err
+			}
+		}
+		restored = true
+	}
+	if restored {
+		if err := s.reconverge(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Аренды DHCP переносятся в новое виртуальное время с тем же остатком,
+	// а записи таблиц, изученные в прежнем, удаляются
+	if err := s.repo.ShiftDHCPLeases(-c.now); err != nil {
+		return nil, fmt.Errorf("failed to shift DHCP leases: %w", err)
+	}
+	s.mac.reset()
+	s.arp.reset()
+	s.conntrack.reset()
+	s.nat.reset()
+	c.reset()
+	c.rng, c.seed = s.newRand(nil)
+	return c.state(), nil
+}
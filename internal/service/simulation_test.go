@@ -0,0 +1,160 @@
+package service
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"network/internal/models"
+)
+
+// clockPacket возвращает пакет симуляции по идентификатору
+func clockPacket(t *testing.T, s *DeviceService, id uint) models.SimulationPacket {
+	t.Helper()
+	for _, packet := range s.GetClock().Packets {
+		if packet.ID == id {
+			return packet
+		}
+	}
+	t.Fatalf("packet %d not found", id)
+	return models.SimulationPacket{}
+}
+
+// mustRunClock переводит часы на момент until
+func mustRunClock(t *testing.T, s *DeviceService, until float64) *models.SimulationClock {
+	t.Helper()
+	clock, err := s.RunClock(&models.RunClockRequest{Time: until})
+	if err != nil {
+		t.Fatalf("run clock to %v ms: %v", until, err)
+	}
+	return clock
+}
+
+// mustSchedulePacket планирует отправку одного сегмента TCP на порт 80 в момент at
+func mustSchedulePacket(t *testing.T, s *DeviceService, source, dest string, at float64) models.SimulationPacket {
+	t.Helper()
+	packets, err := s.SchedulePackets(&models.SchedulePacketRequest{
+		SourceIP:      source,
+		DestinationIP: dest,
+		Protocol:      "tcp",
+		Port:          80,
+		At:            &at,
+	})
+	if err != nil {
+		t.Fatalf("schedule packet %s -> %s: %v", source, dest, err)
+	}
+	return packets[0]
+}
+
+func TestClockDeliversPacketsInVirtualTime(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	r1 := mustCreateRouter(t, s, "R1", "10.0.0.1")
+	r2 := mustCreateRouter(t, s, "R2", "10.0.0.2")
+	mustConnect(t, s, r1, r2, 10, 0, 0)
+
+	packet := mustSchedulePacket(t, s, r1.IPAddress, r2.IPAddress, 100)
+	if packet.Status != models.SimulationPacketScheduled {
+		t.Fatalf("new packet status = %s, want scheduled", packet.Status)
+	}
+
+	// Отправка: часы переходят к моменту события, пакет уходит в соединение
+	clock, err := s.StepClock(&models.StepClockRequest{})
+	if err != nil {
+		t.Fatalf("step clock: %v", err)
+	}
+	if clock.Time != 100 || clock.Processed != 1 {
+		t.Fatalf("clock after one step = %v ms, %d events, want 100 ms, 1 event", clock.Time, clock.Processed)
+	}
+	if got := clockPacket(t, s, packet.ID); got.Status != models.SimulationPacketInFlight {
+		t.Fatalf("packet after sending = %s, want in-flight", got.Status)
+	}
+
+	// До истечения задержки соединения пакет остается в пути
+	mustRunClock(t, s, 105)
+	if got := clockPacket(t, s, packet.ID); got.Status != models.SimulationPacketInFlight {
+		t.Fatalf("packet at 105 ms = %s, want in-flight", got.Status)
+	}
+
+	clock = mustRunClock(t, s, 200)
+	if clock.Time != 200 || len(clock.Pending) != 0 {
+		t.Fatalf("clock = %v ms with %d pending events, want 200 ms and none", clock.Time, len(clock.Pending))
+	}
+	got := clockPacket(t, s, packet.ID)
+	if got.Status != models.SimulationPacketDelivered {
+		t.Fatalf("packet = %s (%s), want delivered", got.Status, got.Error)
+	}
+	if math.Abs(got.Latency-10) > 0.01 {
+		t.Errorf("packet latency = %v ms, want the 10 ms link delay", got.Latency)
+	}
+
+	// Часы не идут назад, а события нельзя планировать в прошлое
+	if _, err := s.RunClock(&models.RunClockRequest{Time: 150}); err == nil {
+		t.Error("running the clock back succeeded")
+	}
+	past := 150.0
+	if _, err := s.SchedulePackets(&models.SchedulePacketRequest{
+		SourceIP: r1.IPAddress, DestinationIP: r2.IPAddress, Protocol: "tcp", Port: 80, At: &past,
+	}); err == nil {
+		t.Error("scheduling a packet in the past succeeded")
+	}
+}
+
+func TestClockReroutesAfterDetectionDelay(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	r1 := mustCreateRouter(t, s, "R1", "10.0.0.1")
+	r2 := mustCreateRouter(t, s, "R2", "10.0.0.2")
+	r3 := mustCreateRouter(t, s, "R3", "10.0.0.3")
+	direct := mustConnect(t, s, r1, r2, 1, 0, 0)
+	mustConnect(t, s, r1, r3, 1, 0, 0)
+	mustConnect(t, s, r3, r2, 1, 0, 0)
+	mustEnableOSPF(t, s, r1, r2, r3)
+
+	start := 0.0
+	events, err := s.ScheduleFailure(&models.ScheduleFailureRequest{ConnectionID: direct.ID, At: &start})
+	if err != nil {
+		t.Fatalf("schedule failure: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != models.SimulationEventLinkDown {
+		t.Fatalf("failure events = %+v, want a single link-down", events)
+	}
+
+	// Отказ обрабатывается сразу, протоколы маршрутизации реагируют через задержку обнаружения
+	clock := mustRunClock(t, s, 0)
+	var dead *models.SimulationEvent
+	for i, event := range clock.Pending {
+		if event.Timer == models.SimulationTimerOSPFDead {
+			dead = &clock.Pending[i]
+		}
+	}
+	if dead == nil || dead.Time != defaultDetectionDelay {
+		t.Fatalf("pending events = %+v, want the OSPF dead timer at %v ms", clock.Pending, defaultDetectionDelay)
+	}
+
+	// До пересчета SPF маршрут ведет в упавшее соединение: TCP повторяет передачу и сдается
+	early := mustSchedulePacket(t, s, r1.IPAddress, r2.IPAddress, 100)
+	// После пересчета пакет идет в обход через R3
+	late := mustSchedulePacket(t, s, r1.IPAddress, r2.IPAddress, 1500)
+	mustRunClock(t, s, 2000)
+
+	got := clockPacket(t, s, early.ID)
+	if got.Status != models.SimulationPacketDropped || got.Attempts != tcpMaxRetries+1 {
+		t.Errorf("packet before reconvergence = %s after %d attempts, want dropped after %d", got.Status, got.Attempts, tcpMaxRetries+1)
+	}
+	if !strings.HasPrefix(got.Error, "connection timed out") {
+		t.Errorf("packet before reconvergence error = %q, want a TCP timeout", got.Error)
+	}
+
+	got = clockPacket(t, s, late.ID)
+	if got.Status != models.SimulationPacketDelivered {
+		t.Fatalf("packet after reconvergence = %s (%s), want delivered", got.Status, got.Error)
+	}
+	var path []string
+	for _, hop := range got.Hops {
+		path = append(path, hop.Name)
+	}
+	if strings.Join(path, " ") != "R1 R3 R2" {
+		t.Errorf("packet path = %v, want R1 R3 R2", path)
+	}
+}
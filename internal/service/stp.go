@@ -0,0 +1,465 @@
+package service
+
+import (
+	"fmt"
+	"network/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Значения STP по умолчанию и допустимые диапазоны
+const (
+	defaultBridgePriority = 32768
+	bridgePriorityStep    = 4096
+	maxBridgePriority     = 61440
+	defaultForwardDelay   = 15
+	minForwardDelay       = 4
+	maxForwardDelay       = 30
+	defaultPortPriority   = 128
+)
+
+// stpTimers — моменты, когда порты коммутаторов получили роль, в которой передают кадры.
+// По ним порты STP проходят состояния listening и learning. Как и таблицы MAC-адресов,
+// хранятся только в памяти.
+type stpTimers struct {
+	mu    sync.Mutex
+	since map[uint]time.Time
+}
+
+func newSTPTimers() *stpTimers {
+	return &stpTimers{since: make(map[uint]time.Time)}
+}
+
+// forwarding возвращает момент, с которого порт находится в роли root или designated,
+// и признак того, что роль порт получил только что
+func (s *stpTimers) forwarding(ifaceID uint, now time.Time) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if since, ok := s.since[ifaceID]; ok {
+		return since, false
+	}
+	s.since[ifaceID] = now
+	return now, true
+}
+
+// reset забывает порты, которые заблокированы или больше не работают
+func (s *stpTimers) reset(keep map[uint]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.since {
+		if !keep[id] {
+			delete(s.since, id)
+		}
+	}
+}
+
+// bridgeID — идентификатор моста: приоритет и MAC-адрес
+type bridgeID struct {
+	priority int
+	mac      string
+}
+
+func (b bridgeID) less(o bridgeID) bool {
+	if b.priority != o.priority {
+		return b.priority < o.priority
+	}
+	return b.mac < o.mac
+}
+
+func (b bridgeID) String() string {
+	return fmt.Sprintf("%d.%s", b.priority, b.mac)
+}
+
+// stpVector — вектор приоритета BPDU: стоимость пути до корня, мост и порт отправителя
+type stpVector struct {
+	cost   int
+	bridge bridgeID
+	port   int
+}
+
+func (v stpVector) less(o stpVector) bool {
+	if v.cost != o.cost {
+		return v.cost < o.cost
+	}
+	if v.bridge != o.bridge {
+		return v.bridge.less(o.bridge)
+	}
+	return v.port < o.port
+}
+
+// stpPort — порт коммутатора с активным соединением
+type stpPort struct {
+	iface  *models.Interface
+	number int
+	link   topologyLink
+	cost   int
+}
+
+// stpPortCost вычисляет стоимость порта по пропускной способности соединения:
+// короткие стоимости 802.1D для STP и длинные стоимости 802.1w для RSTP
+func stpPortCost(mode models.STPMode, bandwidth float64) int {
+	if bandwidth <= 0 {
+		bandwidth = 1
+	}
+	if mode == models.STPModeRSTP {
+		cost := int(20000000 / bandwidth)
+		if cost < 1 {
+			cost = 1
+		}
+		return cost
+	}
+	switch {
+	case bandwidth >= 10000:
+		return 2
+	case bandwidth >= 1000:
+		return 4
+	case bandwidth >= 100:
+		return 19
+	case bandwidth >= 10:
+		return 100
+	default:
+		return 250
+	}
+}
+
+// defaultSTPConfig заполняет настройки STP коммутатора, для которого они не сохранены
+func defaultSTPConfig(config *models.STPConfig) {
+	if config.ID != 0 {
+		return
+	}
+	config.Mode = models.STPModeRSTP
+	config.BridgePriority = defaultBridgePriority
+	config.ForwardDelay = defaultForwardDelay
+}
+
+// stpConfig возвращает настройки STP коммутатора в топологии
+func (t *topology) stpConfig(switchID uint) models.STPConfig {
+	config, ok := t.stpConfigs[switchID]
+	if !ok {
+		config = models.STPConfig{RouterID: switchID}
+		defaultSTPConfig(&config)
+	}
+	return config
+}
+
+// bridgeMAC возвращает MAC-адрес моста — наименьший MAC-адрес портов коммутатора
+func bridgeMAC(device *models.Router) string {
+	mac := ""
+	for _, iface := range device.Interfaces {
+		if iface.MACAddress != "" && (mac == "" || iface.MACAddress < mac) {
+			mac = iface.MACAddress
+		}
+	}
+	if mac == "" {
+		mac = fmt.Sprintf("00:00:00:00:%02x:%02x", device.ID>>8&0xff, device.ID&0xff)
+	}
+	return mac
+}
+
+// portNumber возвращает номер порта — позицию интерфейса на коммутаторе
+func portNumber(device *models.Router, iface *models.Interface) int {
+	if iface == nil {
+		return 0
+	}
+	for i := range device.Interfaces {
+		if device.Interfaces[i].ID == iface.ID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// spanningTree строит остовное дерево коммутаторов: в каждом связном L2-домене
+// выбирается корневой мост, на остальных — корневой порт, на каждом соединении
+// между коммутаторами — назначенный порт. Остальные порты блокируются, и соединения
+// через них не передают кадры. Порты STP начинают передавать кадры только после
+// состояний listening и learning; порты RSTP — сразу после получения роли.
+// Порт, получивший роль root или designated, вызывает изменение топологии,
+// и коммутаторы домена очищают таблицы MAC-адресов.
+func (t *topology) spanningTree(timers *stpTimers) {
+	t.stp = make(map[uint]*models.STPInfo)
+	t.blocked = make(map[*models.RouterConnection]bool)
+
+	var switches []uint
+	for id := range t.routers {
+		if t.isSwitch(id) {
+			switches = append(switches, id)
+		}
+	}
+	sort.Slice(switches, func(i, j int) bool { return switches[i] < switches[j] })
+
+	bridges := make(map[uint]bridgeID, len(switches))
+	ports := make(map[uint][]*stpPort, len(switches))
+	byConn := make(map[uint]map[*models.RouterConnection]*stpPort, len(switches))
+	for _, id := range switches {
+		device := t.routers[id]
+		config := t.stpConfig(id)
+		bridges[id] = bridgeID{config.BridgePriority, bridgeMAC(device)}
+		byConn[id] = make(map[*models.RouterConnection]*stpPort)
+		for _, link := range t.l2links[id] {
+			iface := connectionInterface(device, link.conn)
+			port := &stpPort{
+				iface:  iface,
+				number: portNumber(device, iface),
+				link:   link,
+				cost:   stpPortCost(config.Mode, link.conn.Bandwidth),
+			}
+			ports[id] = append(ports[id], port)
+			byConn[id][link.conn] = port
+		}
+	}
+
+	// Корень домена — мост с наименьшим идентификатором; стоимости путей до него — по Дейкстре.
+	// Стоимость пути растет на стоимость порта, которым мост принимает BPDU.
+	root := make(map[uint]uint, len(switches))
+	cost := make(map[uint]int, len(switches))
+	for _, id := range switches {
+		if _, ok := root[id]; ok {
+			continue
+		}
+		domain := []uint{id}
+		root[id] = id
+		for i := 0; i < len(domain); i++ {
+			for _, port := range ports[domain[i]] {
+				if next := port.link.to; t.isSwitch(next) {
+					if _, ok := root[next]; !ok {
+						root[next] = id
+						domain = append(domain, next)
+					}
+				}
+			}
+		}
+		best := id
+		for _, member := range domain {
+			if bridges[member].less(bridges[best]) {
+				best = member
+			}
+		}
+		for _, member := range domain {
+			root[member] = best
+		}
+
+		done := make(map[uint]bool, len(domain))
+		cost[best] = 0
+		for {
+			current, found := uint(0), false
+			for _, member := range domain {
+				if c, ok := cost[member]; ok && !done[member] && (!found || c < cost[current]) {
+					current, found = member, true
+				}
+			}
+			if !found {
+				break
+			}
+			done[current] = true
+			for _, port := range ports[current] {
+				next := port.link.to
+				if !t.isSwitch(next) || done[next] {
+					continue
+				}
+				c := cost[current] + byConn[next][port.link.conn].cost
+				if old, ok := cost[next]; !ok || c < old {
+					cost[next] = c
+				}
+			}
+		}
+	}
+
+	now := time.Now()
+	active := make(map[uint]bool)
+	changed := make(map[uint]bool)
+	for _, id := range switches {
+		device := t.routers[id]
+		config := t.stpConfig(id)
+		info := &models.STPInfo{
+			STPConfig:    config,
+			BridgeID:     bridges[id].String(),
+			RootID:       bridges[root[id]].String(),
+			RootPathCost: cost[id],
+			IsRoot:       root[id] == id,
+			Ports:        []models.STPPort{},
+		}
+
+		// Корневой порт принимает лучший BPDU от соседнего моста
+		var rootPort *stpPort
+		var rootVector stpVector
+		if !info.IsRoot {
+			for _, port := range ports[id] {
+				next := port.link.to
+				if !t.isSwitch(next) {
+					continue
+				}
+				vector := stpVector{cost[next] + port.cost, bridges[next], byConn[next][port.link.conn].number}
+				if rootPort == nil || vector.less(rootVector) || vector == rootVector && port.number < rootPort.number {
+					rootPort, rootVector = port, vector
+				}
+			}
+			if rootPort != nil && rootPort.iface != nil {
+				info.RootPort = rootPort.iface.Name
+			}
+		}
+
+		states := make(map[uint]models.STPPort, len(ports[id]))
+		var unnamed []models.STPPort
+		for _, port := range ports[id] {
+			role := models.STPRoleDesignated
+			next := port.link.to
+			switch {
+			case !t.isSwitch(next):
+				// Порт к роутеру или хосту — назначенный порт своего сегмента
+			case port == rootPort:
+				role = models.STPRoleRoot
+			default:
+				own := stpVector{cost[id], bridges[id], port.number}
+				peer := stpVector{cost[next], bridges[next], byConn[next][port.link.conn].number}
+				if peer.less(own) {
+					role = models.STPRoleAlternate
+				}
+			}
+
+			state := models.STPStateForwarding
+			if role == models.STPRoleAlternate {
+				state = models.STPStateDiscarding
+				if config.Mode == models.STPModeSTP {
+					state = models.STPStateBlocking
+				}
+			} else if port.iface != nil {
+				active[port.iface.ID] = true
+				since, fresh := timers.forwarding(port.iface.ID, now)
+				if fresh {
+					changed[root[id]] = true
+				}
+				delay := time.Duration(config.ForwardDelay) * time.Second
+				if config.Mode == models.STPModeSTP {
+					switch elapsed := now.Sub(since); {
+					case elapsed < delay:
+						state = models.STPStateListening
+					case elapsed < 2*delay:
+						state = models.STPStateLearning
+					}
+				}
+			}
+			if state != models.STPStateForwarding {
+				t.blocked[port.link.conn] = true
+			}
+
+			view := models.STPPort{
+				InterfaceID: interfaceID(port.iface),
+				PortID:      fmt.Sprintf("%d.%d", defaultPortPriority, port.number),
+				Role:        role,
+				State:       state,
+				Cost:        port.cost,
+				NeighborID:  next,
+			}
+			if port.iface == nil {
+				unnamed = append(unnamed, view)
+				continue
+			}
+			view.Interface = port.iface.Name
+			states[port.iface.ID] = view
+		}
+
+		// Порты показываются в порядке интерфейсов, неподключенные порты отключены
+		for i := range device.Interfaces {
+			iface := &device.Interfaces[i]
+			if iface.Type != models.InterfaceTypeEthernet {
+				continue
+			}
+			view, ok := states[iface.ID]
+			if !ok {
+				view = models.STPPort{
+					InterfaceID: iface.ID,
+					Interface:   iface.Name,
+					PortID:      fmt.Sprintf("%d.%d", defaultPortPriority, i+1),
+					Role:        models.STPRoleDisabled,
+					State:       models.STPStateDisabled,
+				}
+			}
+			info.Ports = append(info.Ports, view)
+		}
+		// Соединения, созданные до появления интерфейсов, показываются после портов
+		info.Ports = append(info.Ports, unnamed...)
+		t.stp[id] = info
+	}
+	timers.reset(active)
+
+	if t.mac != nil {
+		for _, id := range switches {
+			if changed[root[id]] {
+				t.mac.flush(id)
+			}
+		}
+	}
+}
+
+// stpConfig возвращает настройки STP коммутатора; новый коммутатор получает значения по умолчанию
+func (s *DeviceService) stpConfig(switchID uint) (*models.STPConfig, error) {
+	config, err := s.repo.GetSTPConfig(switchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get STP config: %w", err)
+	}
+	defaultSTPConfig(config)
+	return config, nil
+}
+
+// GetSTP возвращает состояние остовного дерева на коммутаторе: корневой мост,
+// стоимость пути до него, роли и состояния портов
+func (s *DeviceService) GetSTP(switchID uint) (*models.STPInfo, error) {
+	if _, err := s.getSwitch(switchID); err != nil {
+		return nil, err
+	}
+	topo, err := s.loadTopology()
+	if err != nil {
+		return nil, err
+	}
+	info, ok := topo.stp[switchID]
+	if !ok {
+		return nil, fmt.Errorf("switch %d not found in topology", switchID)
+	}
+	return info, nil
+}
+
+// UpdateSTP изменяет настройки STP коммутатора и пересчитывает маршруты,
+// так как смена корня может изменить соседство устройств через коммутаторы
+func (s *DeviceService) UpdateSTP(switchID uint, req *models.UpdateSTPRequest) (*models.STPInfo, error) {
+	if _, err := s.getSwitch(switchID); err != nil {
+		return nil, err
+	}
+	config, err := s.stpConfig(switchID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Mode != nil {
+		config.Mode = *req.Mode
+	}
+	if req.BridgePriority != nil {
+		config.BridgePriority = *req.BridgePriority
+	}
+	if req.ForwardDelay != nil {
+		config.ForwardDelay = *req.ForwardDelay
+	}
+
+	switch config.Mode {
+	case models.STPModeSTP, models.STPModeRSTP:
+	default:
+		return nil, fmt.Errorf("invalid STP mode: %s", config.Mode)
+	}
+	if config.BridgePriority < 0 || config.BridgePriority > maxBridgePriority || config.BridgePriority%bridgePriorityStep != 0 {
+		return nil, fmt.Errorf("invalid bridge priority: %d (must be a multiple of %d up to %d)", config.BridgePriority, bridgePriorityStep, maxBridgePriority)
+	}
+	if config.ForwardDelay < minForwardDelay || config.ForwardDelay > maxForwardDelay {
+		return nil, fmt.Errorf("invalid forward delay: %d (must be %d-%d)", config.ForwardDelay, minForwardDelay, maxForwardDelay)
+	}
+
+	if err := s.repo.SaveSTPConfig(config); err != nil {
+		return nil, fmt.Errorf("failed to save STP config: %w", err)
+	}
+	if err := s.reconverge(); err != nil {
+		return nil, err
+	}
+	return s.GetSTP(switchID)
+}
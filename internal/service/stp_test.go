@@ -0,0 +1,142 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"network/internal/models"
+)
+
+// findSTPPort возвращает состояние порта коммутатора по имени интерфейса
+func findSTPPort(t *testing.T, info *models.STPInfo, name string) models.STPPort {
+	t.Helper()
+	for _, port := range info.Ports {
+		if port.Interface == name {
+			return port
+		}
+	}
+	t.Fatalf("port %s not found in %+v", name, info.Ports)
+	return models.STPPort{}
+}
+
+// mustGetSTP возвращает состояние остовного дерева на коммутаторе
+func mustGetSTP(t *testing.T, s *DeviceService, sw *models.Router) *models.STPInfo {
+	t.Helper()
+	info, err := s.GetSTP(sw.ID)
+	if err != nil {
+		t.Fatalf("get STP of %s: %v", sw.Name, err)
+	}
+	return info
+}
+
+// packetPath возвращает имена устройств, через которые прошел пакет
+func packetPath(packet models.SimulationPacket) string {
+	names := make([]string, 0, len(packet.Hops))
+	for _, hop := range packet.Hops {
+		names = append(names, hop.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestSTPBlocksSwitchingLoop(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	sw1 := mustCreateSwitch(t, s, "SW1", "10.0.0.11")
+	sw2 := mustCreateSwitch(t, s, "SW2", "10.0.0.12")
+	sw3 := mustCreateSwitch(t, s, "SW3", "10.0.0.13")
+	uplink := mustCable(t, s, sw1, "Fa0/1", sw2, "Fa0/1")
+	mustCable(t, s, sw1, "Fa0/2", sw3, "Fa0/1")
+	mustCable(t, s, sw2, "Fa0/2", sw3, "Fa0/2")
+	r1 := mustCreateLANRouter(t, s, "R1", "10.0.0.1", "192.168.10.1")
+	r2 := mustCreateLANRouter(t, s, "R2", "10.0.0.2", "192.168.10.2")
+	mustCable(t, s, r1, "Gi0/0", sw2, "Fa0/3")
+	mustCable(t, s, r2, "Gi0/0", sw3, "Fa0/3")
+
+	// При равных приоритетах корнем становится SW1 с наименьшим MAC-адресом,
+	// а на соединении SW2-SW3 блокируется порт моста с большим идентификатором
+	root := mustGetSTP(t, s, sw1)
+	if !root.IsRoot {
+		t.Fatalf("SW1 is not the root bridge, root is %s", root.RootID)
+	}
+	for _, sw := range []*models.Router{sw2, sw3} {
+		info := mustGetSTP(t, s, sw)
+		if info.IsRoot || info.RootID != root.BridgeID || info.RootPort != "Fa0/1" {
+			t.Errorf("%s root = %s via %q, want %s via Fa0/1", sw.Name, info.RootID, info.RootPort, root.BridgeID)
+		}
+	}
+	if port := findSTPPort(t, mustGetSTP(t, s, sw2), "Fa0/2"); port.Role != models.STPRoleDesignated || port.State != models.STPStateForwarding {
+		t.Errorf("SW2 Fa0/2 = %s/%s, want designated/forwarding", port.Role, port.State)
+	}
+	if port := findSTPPort(t, mustGetSTP(t, s, sw3), "Fa0/2"); port.Role != models.STPRoleAlternate || port.State != models.STPStateDiscarding {
+		t.Errorf("SW3 Fa0/2 = %s/%s, want alternate/discarding", port.Role, port.State)
+	}
+
+	// Кадры обходят заблокированное соединение через корень, а после отказа
+	// канала к корню альтернативный порт SW3 начинает передавать кадры
+	before := mustSchedulePacket(t, s, r1.IPAddress, "192.168.10.2", 0)
+	failAt := 100.0
+	if _, err := s.ScheduleFailure(&models.ScheduleFailureRequest{ConnectionID: uplink, At: &failAt}); err != nil {
+		t.Fatalf("schedule failure: %v", err)
+	}
+	after := mustSchedulePacket(t, s, r1.IPAddress, "192.168.10.2", 200)
+	mustRunClock(t, s, 5000)
+
+	for _, tt := range []struct {
+		packet models.SimulationPacket
+		want   string
+	}{
+		{before, "R1 SW2 SW1 SW3 R2"},
+		{after, "R1 SW2 SW3 R2"},
+	} {
+		got := clockPacket(t, s, tt.packet.ID)
+		if got.Status != models.SimulationPacketDelivered || packetPath(got) != tt.want {
+			t.Errorf("packet sent at %v ms = %s over %q, want delivered over %q", got.SentAt, got.Status, packetPath(got), tt.want)
+		}
+	}
+	if info := mustGetSTP(t, s, sw2); info.RootPort != "Fa0/2" {
+		t.Errorf("SW2 root port after failure = %q, want Fa0/2", info.RootPort)
+	}
+	if port := findSTPPort(t, mustGetSTP(t, s, sw3), "Fa0/2"); port.Role != models.STPRoleDesignated || port.State != models.STPStateForwarding {
+		t.Errorf("SW3 Fa0/2 after failure = %s/%s, want designated/forwarding", port.Role, port.State)
+	}
+}
+
+func TestSTPBridgePriorityMovesRoot(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	sw1 := mustCreateSwitch(t, s, "SW1", "10.0.0.11")
+	sw2 := mustCreateSwitch(t, s, "SW2", "10.0.0.12")
+	sw3 := mustCreateSwitch(t, s, "SW3", "10.0.0.13")
+	mustCable(t, s, sw1, "Fa0/1", sw2, "Fa0/1")
+	mustCable(t, s, sw1, "Fa0/2", sw3, "Fa0/1")
+	mustCable(t, s, sw2, "Fa0/2", sw3, "Fa0/2")
+
+	// Меньший приоритет важнее MAC-адреса: корнем становится SW3,
+	// и блокируется порт SW2 на соединении SW1-SW2
+	priority := defaultBridgePriority - bridgePriorityStep
+	if _, err := s.UpdateSTP(sw3.ID, &models.UpdateSTPRequest{BridgePriority: &priority}); err != nil {
+		t.Fatalf("set SW3 priority: %v", err)
+	}
+	if info := mustGetSTP(t, s, sw3); !info.IsRoot || !strings.HasPrefix(info.BridgeID, "28672.") {
+		t.Fatalf("SW3 = %s, root %v, want the root bridge with priority 28672", info.BridgeID, info.IsRoot)
+	}
+	if port := findSTPPort(t, mustGetSTP(t, s, sw2), "Fa0/1"); port.Role != models.STPRoleAlternate {
+		t.Errorf("SW2 Fa0/1 = %s, want alternate", port.Role)
+	}
+
+	// В режиме STP заблокированный порт находится в состоянии blocking
+	mode := models.STPModeSTP
+	for _, sw := range []*models.Router{sw1, sw2, sw3} {
+		if _, err := s.UpdateSTP(sw.ID, &models.UpdateSTPRequest{Mode: &mode}); err != nil {
+			t.Fatalf("set %s mode: %v", sw.Name, err)
+		}
+	}
+	if port := findSTPPort(t, mustGetSTP(t, s, sw2), "Fa0/1"); port.State != models.STPStateBlocking {
+		t.Errorf("SW2 Fa0/1 in STP mode = %s, want blocking", port.State)
+	}
+
+	invalid := priority + 1
+	if _, err := s.UpdateSTP(sw1.ID, &models.UpdateSTPRequest{BridgePriority: &invalid}); err == nil {
+		t.Error("bridge priority that is not a multiple of 4096 was accepted")
+	}
+}
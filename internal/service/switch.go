@@ -0,0 +1,529 @@
+package service
+
+import (
+	"fmt"
+	"network/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Параметры коммутаторов
+const (
+	defaultSwitchPortCount = 8
+	defaultMACAgingTime    = 300 // с
+	minMACAgingTime        = 10
+	maxMACAgingTime        = 1000000
+)
+
+// requireRouter проверяет, что устройство маршрутизирует пакеты
+func requireRouter(device *models.Router) error {
+	if device.Type == models.DeviceTypeSwitch || device.Type == models.DeviceTypeHost {
+		return fmt.Errorf("device %s is a %s and does not route packets", device.IPAddress, device.Type)
+	}
+	return nil
+}
+
+// requireRoutingTable проверяет, что у устройства есть таблица маршрутизации.
+// Хосты не пересылают пакеты, но выбирают маршрут для собственных.
+func requireRoutingTable(device *models.Router) error {
+	if device.Type == models.DeviceTypeSwitch {
+		return fmt.Errorf("device %s is a switch and has no routing table", device.IPAddress)
+	}
+	return nil
+}
+
+// validateDeviceInterface проверяет, что интерфейс допустим для типа устройства
+func validateDeviceInterface(deviceType models.DeviceType, iface *models.Interface) error {
+	switch iface.FirewallZone {
+	case "", models.FirewallZoneInside, models.FirewallZoneOutside:
+	default:
+		return fmt.Errorf("invalid firewall zone: %s", iface.FirewallZone)
+	}
+	if deviceType != models.DeviceTypeSwitch {
+		if iface.SwitchportMode != "" || iface.AccessVLAN != 0 || iface.NativeVLAN != 0 || iface.AllowedVLANs != "" {
+			return fmt.Errorf("VLAN settings of interface %s can only be set on switch ports", iface.Name)
+		}
+		return nil
+	}
+	if iface.IPv4Address != "" || iface.IPv6Address != "" || iface.DHCPClient {
+		return fmt.Errorf("switch port %s cannot have an IP address", iface.Name)
+	}
+	if iface.Type == models.InterfaceTypeSubinterface {
+		return fmt.Errorf("switch port %s cannot be a subinterface", iface.Name)
+	}
+	if iface.InboundACL != "" || iface.OutboundACL != "" || iface.FirewallZone != "" {
+		return fmt.Errorf("switch port %s cannot filter packets", iface.Name)
+	}
+	return validateSwitchport(iface)
+}
+
+// defaultSwitchInterfaces возвращает порты коммутатора Fa0/1..Fa0/N
+func defaultSwitchInterfaces() []models.CreateInterfaceRequest {
+	reqs := make([]models.CreateInterfaceRequest, 0, defaultSwitchPortCount)
+	for i := 1; i <= defaultSwitchPortCount; i++ {
+		reqs = append(reqs, models.CreateInterfaceRequest{
+			Name: fmt.Sprintf("Fa0/%d", i),
+		})
+	}
+	return reqs
+}
+
+// macKey — MAC-адрес в VLAN: таблица коммутатора ведется отдельно для каждой VLAN
+type macKey struct {
+	vlan int
+	mac  string
+}
+
+// macEntry — MAC-адрес, изученный на порту коммутатора
+type macEntry struct {
+	ifaceID uint
+	iface   string
+	seen    float64 // виртуальное время, мс
+}
+
+// macTables — таблицы MAC-адресов коммутаторов. Как и на настоящих коммутаторах,
+// таблицы хранятся только в памяти и очищаются при перезапуске.
+// Записи устаревают по виртуальным часам симуляции.
+type macTables struct {
+	mu     sync.Mutex
+	clock  *simClock
+	tables map[uint]map[macKey]macEntry
+}
+
+func newMACTables(clock *simClock) *macTables {
+	return &macTables{clock: clock, tables: make(map[uint]map[macKey]macEntry)}
+}
+
+// expired проверяет, истекло ли время хранения записи к моменту now
+func (e macEntry) expired(now float64, aging time.Duration) bool {
+	return aging > 0 && now-e.seen > float64(aging.Milliseconds())
+}
+
+// learn запоминает порт, на котором получен кадр VLAN с MAC-адресом отправителя
+func (m *macTables) learn(switchID uint, vlan int, mac string, iface *models.Interface) {
+	if mac == "" || iface == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	table := m.tables[switchID]
+	if table == nil {
+		table = make(map[macKey]macEntry)
+		m.tables[switchID] = table
+	}
+	table[macKey{vlan, mac}] = macEntry{ifaceID: iface.ID, iface: iface.Name, seen: m.clock.time()}
+}
+
+// lookup возвращает порт для MAC-адреса в VLAN; устаревшие записи удаляются
+func (m *macTables) lookup(switchID uint, vlan int, mac string, aging time.Duration) (macEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := macKey{vlan, mac}
+	entry, ok := m.tables[switchID][key]
+	if ok && entry.expired(m.clock.time(), aging) {
+		delete(m.tables[switchID], key)
+		return macEntry{}, false
+	}
+	return entry, ok
+}
+
+// entries возвращает действующие записи таблицы коммутатора
+func (m *macTables) entries(switchID uint, aging time.Duration) []models.MACEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.time()
+	entries := []models.MACEntry{}
+	for key, entry := range m.tables[switchID] {
+		if entry.expired(now, aging) {
+			delete(m.tables[switchID], key)
+			continue
+		}
+		entries = append(entries, models.MACEntry{
+			VLAN:        key.vlan,
+			MACAddress:  key.mac,
+			InterfaceID: entry.ifaceID,
+			Interface:   entry.iface,
+			Age:         int((now - entry.seen) / 1000),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].VLAN != entries[j].VLAN {
+			return entries[i].VLAN < entries[j].VLAN
+		}
+		return entries[i].MACAddress < entries[j].MACAddress
+	})
+	return entries
+}
+
+// flush очищает таблицу коммутатора
+func (m *macTables) flush(switchID uint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tables, switchID)
+}
+
+// reset очищает таблицы всех коммутаторов при сбросе часов
+func (m *macTables) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tables = make(map[uint]map[macKey]macEntry)
+}
+
+// isSwitch проверяет, является ли устройство коммутатором
+func (t *topology) isSwitch(id uint) bool {
+	device := t.routers[id]
+	return device != nil && device.Type == models.DeviceTypeSwitch
+}
+
+// connectionInterface возвращает интерфейс устройства на конце соединения
+func connectionInterface(device *models.Router, conn *models.RouterConnection) *models.Interface {
+	if conn.RouterToID == device.ID {
+		return findInterface(device, conn.ToInterfaceID)
+	}
+	return findInterface(device, conn.FromInterfaceID)
+}
+
+// segmentEnd — конец сегмента: соединение с коммутатором и интерфейс устройства на нем
+type segmentEnd struct {
+	conn  uint
+	iface uint
+}
+
+// l2path — путь кадра через коммутаторы до устройства и интерфейс, который его принимает
+type l2path struct {
+	links []topologyLink
+	iface *models.Interface
+}
+
+func interfaceID(iface *models.Interface) uint {
+	if iface == nil {
+		return 0
+	}
+	return iface.ID
+}
+
+// bridge соединяет устройства, подключенные к коммутаторам. Устройства, между
+// которыми есть путь через коммутаторы в одной VLAN, становятся соседями через сегмент:
+// для них создается виртуальное соединение между их интерфейсами, а путь через
+// коммутаторы запоминается для пересылки кадров. Кадры без тега отправляет сам
+// интерфейс, кадры с тегом — его подынтерфейсы 802.1Q.
+func (t *topology) bridge(order []uint) {
+	segments := make(map[[2]segmentEnd]*models.RouterConnection)
+	for _, id := range order {
+		if t.isSwitch(id) {
+			continue
+		}
+		device := t.routers[id]
+		for _, first := range t.l2links[id] {
+			if !t.isSwitch(first.to) {
+				t.links[id] = append(t.links[id], first)
+				continue
+			}
+			if t.blocked[first.conn] {
+				continue
+			}
+
+			physical := connectionInterface(device, first.conn)
+			sources := []*models.Interface{physical}
+			if physical != nil {
+				for _, sub := range subinterfaces(device, physical) {
+					if sub.OperStatus == models.InterfaceStatusUp {
+						sources = append(sources, sub)
+					}
+				}
+			}
+
+			for _, source := range sources {
+				tag := 0
+				if source != nil {
+					tag = source.VLAN
+				}
+				for _, path := range t.segmentPaths(id, first, tag) {
+					last := path.links[len(path.links)-1]
+					key := [2]segmentEnd{{first.conn.ID, interfaceID(source)}, {last.conn.ID, interfaceID(path.iface)}}
+					if key[1].conn < key[0].conn || key[1].conn == key[0].conn && key[1].iface < key[0].iface {
+						key[0], key[1] = key[1], key[0]
+					}
+					conn := segments[key]
+					if conn == nil {
+						conn = newSegment(device, t.routers[last.to], source, path.iface, path.links)
+						segments[key] = conn
+						t.segments[conn] = path.links
+					}
+					t.links[id] = append(t.links[id], topologyLink{to: last.to, conn: conn})
+				}
+			}
+		}
+	}
+}
+
+// segmentPaths находит поиском в ширину по коммутаторам пути кадра с тегом tag
+// от устройства через соединение first до других устройств сегмента.
+// Кадр проходит только через порты, пропускающие его VLAN и не заблокированные STP.
+func (t *topology) segmentPaths(fromID uint, first topologyLink, tag int) []l2path {
+	vlan, ok := ingressVLAN(connectionInterface(t.routers[first.to], first.conn), tag)
+	if !ok {
+		return nil
+	}
+	first.vlan = vlan
+
+	type state struct {
+		id   uint
+		vlan int
+	}
+	start := state{first.to, vlan}
+	parent := map[state][]topologyLink{start: {first}}
+	queue := []state{start}
+	var paths []l2path
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		sw := t.routers[current.id]
+		in := parent[current][len(parent[current])-1].conn
+		for _, link := range t.l2links[current.id] {
+			if link.conn == in || t.blocked[link.conn] {
+				continue
+			}
+			tag, ok := egressTag(connectionInterface(sw, link.conn), current.vlan)
+			if !ok {
+				continue
+			}
+			next := t.routers[link.to]
+			if !t.isSwitch(link.to) {
+				iface := endpointInterface(next, connectionInterface(next, link.conn), tag)
+				if link.to != fromID && (iface != nil || tag == 0) {
+					path := append(append([]topologyLink{}, parent[current]...), link)
+					paths = append(paths, l2path{links: path, iface: iface})
+				}
+				continue
+			}
+
+			vlan, ok := ingressVLAN(connectionInterface(next, link.conn), tag)
+			if !ok {
+				continue
+			}
+			link.vlan = vlan
+			if _, seen := parent[state{link.to, vlan}]; !seen {
+				parent[state{link.to, vlan}] = append(append([]topologyLink{}, parent[current]...), link)
+				queue = append(queue, state{link.to, vlan})
+			}
+		}
+	}
+	return paths
+}
+
+// newSegment создает виртуальное соединение между интерфейсами на концах пути через коммутаторы.
+// Задержки складываются, пропускная способность ограничена самым медленным участком.
+func newSegment(from, to *models.Router, fromIface, toIface *models.Interface, path []topologyLink) *models.RouterConnection {
+	conn := &models.RouterConnection{
+		RouterFromID:    from.ID,
+		RouterToID:      to.ID,
+		FromInterfaceID: interfaceID(fromIface),
+		ToInterfaceID:   interfaceID(toIface),
+		Status:          "active",
+		Bandwidth:       path[0].conn.Bandwidth,
+	}
+
+	delivered := 1.0
+	for _, link := range path {
+		conn.Delay += link.conn.Delay
+		conn.Jitter += link.conn.Jitter
+		delivered *= 1 - link.conn.PacketLoss
+		if link.conn.Bandwidth < conn.Bandwidth {
+			conn.Bandwidth = link.conn.Bandwidth
+		}
+	}
+	conn.PacketLoss = 1 - delivered
+	return conn
+}
+
+// segmentPath возвращает путь через коммутаторы от устройства from по соединению
+// сегмента или nil для прямого соединения
+func (t *topology) segmentPath(fromID uint, conn *models.RouterConnection) []topologyLink {
+	path := t.segments[conn]
+	if path == nil || conn.RouterFromID == fromID {
+		return path
+	}
+
+	// Путь хранится от начала сегмента, обратный путь проходит те же соединения и VLAN
+	nodes := make([]topologyLink, 0, len(path)+1)
+	nodes = append(nodes, topologyLink{to: conn.RouterFromID})
+	nodes = append(nodes, path...)
+	reversed := make([]topologyLink, len(path))
+	for i := range path {
+		node := nodes[len(path)-1-i]
+		reversed[i] = topologyLink{to: node.to, conn: path[len(path)-1-i].conn, vlan: node.vlan}
+	}
+	return reversed
+}
+
+// crossLink передает пакет от устройства from соседу to и возвращает пройденные
+// переходы. На пути через коммутаторы каждый коммутатор изучает MAC-адрес
+// отправителя кадра в его VLAN и пересылает кадр по таблице или рассылает его во все порты VLAN.
+func (t *topology) crossLink(from, to *models.Router, conn *models.RouterConnection) []models.PacketHop {
+	path := t.segmentPath(from.ID, conn)
+	if path == nil {
+		return []models.PacketHop{newHop(to, conn)}
+	}
+
+	var srcMAC, dstMAC string
+	if iface := connectionInterface(from, path[0].conn); iface != nil {
+		srcMAC = iface.MACAddress
+	}
+	if iface := connectionInterface(to, path[len(path)-1].conn); iface != nil {
+		dstMAC = iface.MACAddress
+	}
+
+	hops := make([]models.PacketHop, 0, len(path))
+	for i, link := range path {
+		device := t.routers[link.to]
+		hop := newHop(device, link.conn)
+		if i < len(path)-1 {
+			hop.VLAN = link.vlan
+			hop.Action = t.switchFrame(device, link.conn, path[i+1].conn, link.vlan, srcMAC, dstMAC)
+		}
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+// switchFrame обрабатывает кадр VLAN vlan на коммутаторе, получившем его через соединение in.
+// Кадр пересылается в порт из таблицы, если он ведет по пути out, иначе рассылается.
+func (t *topology) switchFrame(sw *models.Router, in, out *models.RouterConnection, vlan int, srcMAC, dstMAC string) models.FrameAction {
+	if t.mac == nil {
+		return models.FrameFlooded
+	}
+	t.mac.learn(sw.ID, vlan, srcMAC, connectionInterface(sw, in))
+
+	egress := connectionInterface(sw, out)
+	if entry, ok := t.mac.lookup(sw.ID, vlan, dstMAC, t.macAging(sw.ID)); ok && dstMAC != "" && egress != nil && entry.ifaceID == egress.ID {
+		return models.FrameForwarded
+	}
+	t.flood(sw.ID, in, vlan, srcMAC)
+	return models.FrameFlooded
+}
+
+// flood рассылает кадр VLAN по всем коммутаторам сегмента через порты этой VLAN;
+// каждый из них изучает MAC-адрес отправителя на порту, через который кадр пришел
+func (t *topology) flood(switchID uint, in *models.RouterConnection, vlan int, srcMAC string) {
+	visited := map[uint]bool{switchID: true}
+	type arrival struct {
+		id   uint
+		in   *models.RouterConnection
+		vlan int
+	}
+	queue := []arrival{{switchID, in, vlan}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		sw := t.routers[current.id]
+		for _, link := range t.l2links[current.id] {
+			if link.conn == current.in || t.blocked[link.conn] || !t.isSwitch(link.to) || visited[link.to] {
+				continue
+			}
+			tag, ok := egressTag(connectionInterface(sw, link.conn), current.vlan)
+			if !ok {
+				continue
+			}
+			next := t.routers[link.to]
+			port := connectionInterface(next, link.conn)
+			vlan, ok := ingressVLAN(port, tag)
+			if !ok {
+				continue
+			}
+			visited[link.to] = true
+			t.mac.learn(next.ID, vlan, srcMAC, port)
+			queue = append(queue, arrival{next.ID, link.conn, vlan})
+		}
+	}
+}
+
+// macAging возвращает время хранения MAC-адресов коммутатора
+func (t *topology) macAging(switchID uint) time.Duration {
+	seconds, ok := t.agingTimes[switchID]
+	if !ok {
+		seconds = defaultMACAgingTime
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// switchConfig возвращает настройки коммутатора; новый коммутатор получает значения по умолчанию
+func (s *DeviceService) switchConfig(switchID uint) (*models.SwitchConfig, error) {
+	config, err := s.repo.GetSwitchConfig(switchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get switch config: %w", err)
+	}
+	if config.ID == 0 {
+		config.MACAgingTime = defaultMACAgingTime
+	}
+	return config, nil
+}
+
+// getSwitch возвращает устройство, если оно является коммутатором
+func (s *DeviceService) getSwitch(id uint) (*models.Router, error) {
+	device, err := s.repo.GetRouterByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("switch not found: %w", err)
+	}
+	if device.Type != models.DeviceTypeSwitch {
+		return nil, fmt.Errorf("device %s is not a switch", device.IPAddress)
+	}
+	return device, nil
+}
+
+// GetMACTable возвращает изученные коммутатором MAC-адреса на работающих портах
+func (s *DeviceService) GetMACTable(switchID uint) (*models.MACTable, error) {
+	device, err := s.getSwitch(switchID)
+	if err != nil {
+		return nil, err
+	}
+	config, err := s.switchConfig(switchID)
+	if err != nil {
+		return nil, err
+	}
+
+	table := &models.MACTable{SwitchConfig: *config, Entries: []models.MACEntry{}}
+	aging := time.Duration(config.MACAgingTime) * time.Second
+	for _, entry := range s.mac.entries(switchID, aging) {
+		if iface := findInterface(device, entry.InterfaceID); iface != nil && iface.OperStatus == models.InterfaceStatusUp {
+			table.Entries = append(table.Entries, entry)
+		}
+	}
+	return table, nil
+}
+
+// UpdateMACTable изменяет время хранения MAC-адресов коммутатора
+func (s *DeviceService) UpdateMACTable(switchID uint, req *models.UpdateMACTableRequest) (*models.MACTable, error) {
+	if _, err := s.getSwitch(switchID); err != nil {
+		return nil, err
+	}
+	config, err := s.switchConfig(switchID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MACAgingTime != nil {
+		config.MACAgingTime = *req.MACAgingTime
+	}
+	if config.MACAgingTime != 0 && (config.MACAgingTime < minMACAgingTime || config.MACAgingTime > maxMACAgingTime) {
+		return nil, fmt.Errorf("invalid MAC aging time: %d (must be 0 or %d-%d)", config.MACAgingTime, minMACAgingTime, maxMACAgingTime)
+	}
+
+	if err := s.repo.SaveSwitchConfig(config); err != nil {
+		return nil, fmt.Errorf("failed to save switch config: %w", err)
+	}
+	return s.GetMACTable(switchID)
+}
+
+// FlushMACTable очищает таблицу MAC-адресов коммутатора
+func (s *DeviceService) FlushMACTable(switchID uint) error {
+	if _, err := s.getSwitch(switchID); err != nil {
+		return err
+	}
+	s.mac.flush(switchID)
+	return nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"testing"
+
+	"network/internal/models"
+)
+
+// mustCreateSwitch создает коммутатор с портами по умолчанию Fa0/1..Fa0/8
+func mustCreateSwitch(t *testing.T, s *DeviceService, name, ip string) *models.Router {
+	t.Helper()
+	sw, err := s.CreateRouter(&models.CreateRouterRequest{Name: name, Type: models.DeviceTypeSwitch, IPAddress: ip})
+	if err != nil {
+		t.Fatalf("create switch %s: %v", name, err)
+	}
+	return sw
+}
+
+// mustCreateLANRouter создает роутер с адресом ip, интерфейсом Gi0/0 с адресом addr/24
+// и свободным интерфейсом Gi0/1
+func mustCreateLANRouter(t *testing.T, s *DeviceService, name, ip, addr string) *models.Router {
+	t.Helper()
+	router, err := s.CreateRouter(&models.CreateRouterRequest{
+		Name:      name,
+		IPAddress: ip,
+		Interfaces: []models.CreateInterfaceRequest{
+			{Name: "Gi0/0", IPv4Address: addr, IPv4PrefixLength: 24},
+			{Name: "Gi0/1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create router %s: %v", name, err)
+	}
+	return router
+}
+
+// mustCable соединяет интерфейсы двух устройств без автоматической адресации
+func mustCable(t *testing.T, s *DeviceService, a *models.Router, aIface string, b *models.Router, bIface string) uint {
+	t.Helper()
+	auto := false
+	conn, err := s.CreateConnection(&models.CreateConnectionRequest{
+		RouterFromID:  a.ID,
+		RouterToID:    b.ID,
+		FromInterface: aIface,
+		ToInterface:   bIface,
+		AutoAddress:   &auto,
+	})
+	if err != nil {
+		t.Fatalf("connect %s %s to %s %s: %v", a.Name, aIface, b.Name, bIface, err)
+	}
+	return conn.ID
+}
+
+func TestSwitchLearnsAndAgesMACAddresses(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	sw := mustCreateSwitch(t, s, "SW1", "10.0.0.10")
+	r1 := mustCreateLANRouter(t, s, "R1", "10.0.0.1", "192.168.10.1")
+	r2 := mustCreateLANRouter(t, s, "R2", "10.0.0.2", "192.168.10.2")
+	mustCable(t, s, r1, "Gi0/0", sw, "Fa0/1")
+	mustCable(t, s, r2, "Gi0/0", sw, "Fa0/2")
+
+	// Первый кадр к неизвестному R2 рассылается, ответный кадр к изученному R1 пересылается в его порт
+	toR2 := mustSchedulePacket(t, s, r1.IPAddress, "192.168.10.2", 0)
+	toR1 := mustSchedulePacket(t, s, r2.IPAddress, "192.168.10.1", 100)
+	mustRunClock(t, s, 1000)
+	for _, tt := range []struct {
+		packet models.SimulationPacket
+		want   models.FrameAction
+	}{
+		{toR2, models.FrameFlooded},
+		{toR1, models.FrameForwarded},
+	} {
+		if got := switchAction(t, clockPacket(t, s, tt.packet.ID), sw); got != tt.want {
+			t.Errorf("packet %s -> %s on SW1 = %s, want %s", tt.packet.SourceIP, tt.packet.DestinationIP, got, tt.want)
+		}
+	}
+
+	table, err := s.GetMACTable(sw.ID)
+	if err != nil {
+		t.Fatalf("get MAC table: %v", err)
+	}
+	learned := make(map[string]string)
+	for _, entry := range table.Entries {
+		learned[entry.MACAddress] = entry.Interface
+	}
+	r1MAC, r2MAC := r1.Interfaces[0].MACAddress, r2.Interfaces[0].MACAddress
+	if len(learned) != 2 || learned[r1MAC] != "Fa0/1" || learned[r2MAC] != "Fa0/2" {
+		t.Fatalf("MAC table = %+v, want %s on Fa0/1 and %s on Fa0/2", table.Entries, r1MAC, r2MAC)
+	}
+
+	// Запись R1 не обновлялась дольше времени хранения и устаревает по виртуальным часам
+	aging := minMACAgingTime
+	if _, err := s.UpdateMACTable(sw.ID, &models.UpdateMACTableRequest{MACAgingTime: &aging}); err != nil {
+		t.Fatalf("set MAC aging time: %v", err)
+	}
+	mustRunClock(t, s, float64(aging*1000)+50)
+	table, err = s.GetMACTable(sw.ID)
+	if err != nil {
+		t.Fatalf("get MAC table: %v", err)
+	}
+	if len(table.Entries) != 1 || table.Entries[0].MACAddress != r2MAC {
+		t.Fatalf("MAC table after aging = %+v, want only %s", table.Entries, r2MAC)
+	}
+
+	again := mustSchedulePacket(t, s, r2.IPAddress, "192.168.10.1", float64(aging*1000)+60)
+	mustRunClock(t, s, float64(aging*1000)+1000)
+	if got := switchAction(t, clockPacket(t, s, again.ID), sw); got != models.FrameFlooded {
+		t.Errorf("packet to R1 after aging on SW1 = %s, want flooded", got)
+	}
+}
+
+// switchAction возвращает действие коммутатора sw над доставленным пакетом
+func switchAction(t *testing.T, packet models.SimulationPacket, sw *models.Router) models.FrameAction {
+	t.Helper()
+	if packet.Status != models.SimulationPacketDelivered {
+		t.Fatalf("packet %s -> %s = %s (%s), want delivered", packet.SourceIP, packet.DestinationIP, packet.Status, packet.Error)
+	}
+	for _, hop := range packet.Hops {
+		if hop.RouterID == sw.ID {
+			return hop.Action
+		}
+	}
+	t.Fatalf("packet %s -> %s did not cross %s", packet.SourceIP, packet.DestinationIP, sw.Name)
+	return ""
+}
@@ -0,0 +1,422 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"network/internal/models"
+)
+
+// topologyLink — ребро графа: соседний роутер и соединение, ведущее к нему
+type topologyLink struct {
+	to   uint
+	conn *models.RouterConnection
+	vlan int // VLAN кадра на коммутаторе to в пути через сегмент
+}
+
+// topology — граф роутеров и активных соединений между ними с таблицами маршрутизации.
+// links связывает соседей уровня 3, в том числе через коммутаторы,
+// l2links — все активные соединения устройств.
+type topology struct {
+	routers     map[uint]*models.Router
+	byIP        map[string]*models.Router
+	links       map[uint][]topologyLink
+	connections map[uint]*models.RouterConnection
+
+	l2links    map[uint][]topologyLink
+	segments   map[*models.RouterConnection][]topologyLink
+	mac        *macTables
+	agingTimes map[uint]int
+
+	stpConfigs map[uint]models.STPConfig
+	stp        map[uint]*models.STPInfo
+	blocked    map[*models.RouterConnection]bool // соединения с портом, который не передает кадры
+
+	arp           *arpCaches
+	staticEntries map[uint][]models.ARPEntry // статические записи ARP и таблицы соседей
+
+	acls    map[uint]map[string][]models.ACLEntry // списки доступа роутеров по именам, записи по возрастанию sequence
+	aclHits *aclCounters
+
+	firewalls map[uint]models.FirewallConfig
+	conntrack *conntrackTables
+
+	natRules   map[uint][]models.NATRule // правила NAT роутеров, статические первыми
+	nat        *natTables
+	natGlobals map[string]*models.Router // глобальные адреса NAT, на запросы ARP о которых отвечает роутер
+}
+
+// loadTopology строит граф из роутеров и активных соединений в базе данных
+func (s *DeviceService) loadTopology() (*topology, error) {
+	routers, err := s.repo.GetAllRouters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routers: %w", err)
+	}
+
+	connections, err := s.repo.GetAllConnections()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connections: %w", err)
+	}
+
+	switchConfigs, err := s.repo.GetSwitchConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get switch configs: %w", err)
+	}
+
+	stpConfigs, err := s.repo.GetSTPConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get STP configs: %w", err)
+	}
+
+	staticEntries, err := s.repo.GetStaticARPEntries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get static ARP entries: %w", err)
+	}
+
+	aclEntries, err := s.repo.GetACLEntries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ACLs: %w", err)
+	}
+
+	firewalls, err := s.repo.GetFirewallConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get firewall configs: %w", err)
+	}
+
+	natRules, err := s.repo.GetNATRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NAT rules: %w", err)
+	}
+
+	t := &topology{
+		routers:     make(map[uint]*models.Router, len(routers)),
+		byIP:        make(map[string]*models.Router, len(routers)),
+		links:       make(map[uint][]topologyLink),
+		connections: make(map[uint]*models.RouterConnection),
+		l2links:     make(map[uint][]topologyLink),
+		segments:    make(map[*models.RouterConnection][]topologyLink),
+		mac:         s.mac,
+		agingTimes:  make(map[uint]int, len(switchConfigs)),
+		stpConfigs:  make(map[uint]models.STPConfig, len(stpConfigs)),
+
+		arp:           s.arp,
+		staticEntries: make(map[uint][]models.ARPEntry),
+
+		acls:    make(map[uint]map[string][]models.ACLEntry),
+		aclHits: s.aclHits,
+
+		firewalls: make(map[uint]models.FirewallConfig, len(firewalls)),
+		conntrack: s.conntrack,
+
+		natRules:   make(map[uint][]models.NATRule),
+		nat:        s.nat,
+		natGlobals: make(map[string]*models.Router),
+	}
+	for _, config := range switchConfigs {
+		t.agingTimes[config.RouterID] = config.MACAgingTime
+	}
+	for _, config := range stpConfigs {
+		t.stpConfigs[config.RouterID] = config
+	}
+	for _, entry := range staticEntries {
+		t.staticEntries[entry.RouterID] = append(t.staticEntries[entry.RouterID], entry)
+	}
+	for _, entry := range aclEntries {
+		if t.acls[entry.RouterID] == nil {
+			t.acls[entry.RouterID] = make(map[string][]models.ACLEntry)
+		}
+		t.acls[entry.RouterID][entry.Name] = append(t.acls[entry.RouterID][entry.Name], entry)
+	}
+	for _, config := range firewalls {
+		t.firewalls[config.RouterID] = config
+	}
+	for _, rule := range natRules {
+		t.natRules[rule.RouterID] = append(t.natRules[rule.RouterID], rule)
+	}
+	operUp := make(map[uint]bool)
+	order := make([]uint, 0, len(routers))
+	for i := range routers {
+		router := &routers[i]
+		t.routers[router.ID] = router
+		order = append(order, router.ID)
+		if router.IPAddress != "" {
+			t.byIP[router.IPAddress] = router
+		}
+		for _, iface := range router.Interfaces {
+			if iface.OperStatus != models.InterfaceStatusUp {
+				continue
+			}
+			operUp[iface.ID] = true
+			if iface.IPv4Address != "" {
+				t.byIP[iface.IPv4Address] = router
+			}
+			if iface.IPv6Address != "" {
+				t.byIP[iface.IPv6Address] = router
+			}
+		}
+	}
+
+	for i := range connections {
+		conn := &connections[i]
+		if conn.Status != "active" {
+			continue
+		}
+		// Соединения с удаленными роутерами пропускаем
+		if t.routers[conn.RouterFromID] == nil || t.routers[conn.RouterToID] == nil {
+			continue
+		}
+		// Как и соединения, у которых не работает интерфейс на одном из концов
+		if conn.FromInterfaceID != 0 && !operUp[conn.FromInterfaceID] ||
+			conn.ToInterfaceID != 0 && !operUp[conn.ToInterfaceID] {
+			continue
+		}
+		t.connections[conn.ID] = conn
+		// Соединения двунаправленные
+		t.l2links[conn.RouterFromID] = append(t.l2links[conn.RouterFromID], topologyLink{to: conn.RouterToID, conn: conn})
+		t.l2links[conn.RouterToID] = append(t.l2links[conn.RouterToID], topologyLink{to: conn.RouterFromID, conn: conn})
+	}
+	for routerID, rules := range t.natRules {
+		sortNATRules(rules)
+		for _, rule := range rules {
+			for _, ip := range natGlobalAddresses(rule) {
+				t.natGlobals[ip] = t.routers[routerID]
+			}
+		}
+	}
+	t.spanningTree(s.stp)
+	t.bridge(order)
+
+	return t, nil
+}
+
+// defaultTTL — начальное значение TTL пересылаемого пакета
+const defaultTTL = 64
+
+var (
+	errNoRoute     = errors.New("no route to host")
+	errTTLExceeded = errors.New("TTL exceeded in transit")
+)
+
+// linkBetween возвращает активное соединение между двумя соседними роутерами
+func (t *topology) linkBetween(fromID, toID uint) *models.RouterConnection {
+	for _, link := range t.links[fromID] {
+		if link.to == toID {
+			return link.conn
+		}
+	}
+	return nil
+}
+
+// linkToAddress возвращает соединение с соседом, интерфейс которого владеет адресом ip.
+// Через коммутаторы соседи могут быть связаны несколькими сегментами в разных VLAN.
+// Непустой egress оставляет только соединения этого интерфейса роутера fromID.
+func (t *topology) linkToAddress(fromID uint, next *models.Router, ip, egress string) *models.RouterConnection {
+	var first *models.RouterConnection
+	for _, link := range t.links[fromID] {
+		if link.to != next.ID || !onInterface(t.routers[fromID], link.conn, egress) {
+			continue
+		}
+		if first == nil {
+			first = link.conn
+		}
+		if iface := connectionInterface(next, link.conn); iface != nil && (iface.IPv4Address == ip || iface.IPv6Address == ip) {
+			return link.conn
+		}
+	}
+	return first
+}
+
+// onInterface проверяет, что соединение подключено к интерфейсу name устройства.
+// Пустое имя и соединения без интерфейсов подходят всегда.
+func onInterface(device *models.Router, conn *models.RouterConnection, name string) bool {
+	if name == "" {
+		return true
+	}
+	iface := connectionInterface(device, conn)
+	return iface == nil || iface.Name == name
+}
+
+// ownsIP проверяет, принадлежит ли адрес роутеру или его работающему интерфейсу
+func (t *topology) ownsIP(router *models.Router, ip string) bool {
+	return t.byIP[ip] == router
+}
+
+// routesOf возвращает таблицу маршрутизации роутера вместе с маршрутами
+// до непосредственно подключенных соседей и подсетей его интерфейсов
+func (t *topology) routesOf(routerID uint) []models.Route {
+	router := t.routers[routerID]
+	routes := make([]models.Route, 0, len(router.Routes)+len(router.Interfaces)+len(t.links[routerID]))
+	for _, link := range t.links[routerID] {
+		routes = append(routes, models.Route{
+			RouterID: routerID,
+			Prefix:   t.routers[link.to].IPAddress + "/32",
+			Protocol: models.RouteProtocolConnected,
+		})
+	}
+	routes = append(routes, connectedRoutes(router)...)
+	routes = append(routes, gatewayRoutes(router)...)
+	return append(routes, router.Routes...)
+}
+
+// maxNextHopRecursion ограничивает глубину рекурсивного поиска next hop
+const maxNextHopRecursion = 8
+
+// resolveNextHop находит соседний роутер, через который достижим next hop.
+// Next hop, не подключенный непосредственно (например, у маршрутов iBGP),
+// разрешается рекурсивно по таблице маршрутизации роутера.
+// Маршрут с интерфейсом (egress) допускает только next hop, подключенный к этому интерфейсу.
+// Возвращает также адрес непосредственно подключенного next hop, на котором завершился поиск.
+func (t *topology) resolveNextHop(routerID uint, nextHopIP, egress string) (*models.Router, *models.RouterConnection, string) {
+	routes := t.routesOf(routerID)
+	for i := 0; i < maxNextHopRecursion; i++ {
+		next := t.byIP[nextHopIP]
+		if next == nil {
+			next = t.natGlobals[nextHopIP]
+		}
+		if next != nil {
+			if conn := t.linkToAddress(routerID, next, nextHopIP, egress); conn != nil {
+				return next, conn, nextHopIP
+			}
+		}
+		if egress != "" {
+			return nil, nil, nextHopIP
+		}
+
+		ip := net.ParseIP(nextHopIP)
+		if ip == nil {
+			return nil, nil, nextHopIP
+		}
+		route := lookupRoute(routes, ip)
+		if route == nil || route.NextHop == "" || route.NextHop == nextHopIP {
+			return nil, nil, nextHopIP
+		}
+		nextHopIP = route.NextHop
+	}
+	return nil, nil, nextHopIP
+}
+
+func newHop(router *models.Router, conn *models.RouterConnection) models.PacketHop {
+	hop := models.PacketHop{
+		RouterID:  router.ID,
+		Name:      router.Name,
+		IPAddress: router.IPAddress,
+		Type:      router.Type,
+	}
+	if conn != nil {
+		hop.ConnectionID = conn.ID
+	}
+	return hop
+}
+
+// nextHop выбирает маршрут до адреса назначения на роутере current
+// и возвращает следующий роутер и соединение, ведущее к нему
+func (t *topology) nextHop(current *models.Router, ip net.IP, destIP string) (*models.Router, *models.RouterConnection, error) {
+	route := lookupRoute(t.routesOf(current.ID), ip)
+	if route == nil {
+		return nil, nil, errNoRoute
+	}
+
+	// Без next hop адрес назначения считается непосредственно подключенным
+	nextHopIP := route.NextHop
+	if nextHopIP == "" {
+		nextHopIP = destIP
+	}
+
+	next, conn, resolved := t.resolveNextHop(current.ID, nextHopIP, route.Interface)
+	if conn == nil {
+		// Next hop в подсети интерфейса, но ни одно устройство на канале не ответило на запрос ARP
+		if err := t.arpIncomplete(current, resolved); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("next hop %s unreachable", nextHopIP)
+	}
+	if err := t.resolveARP(current, next, conn, resolved); err != nil {
+		return nil, nil, err
+	}
+	return next, conn, nil
+}
+
+// checkTransit проверяет, что устройство может переслать чужой пакет:
+// выключенные роутеры и хосты транзитные пакеты отбрасывают
+func checkTransit(current *models.Router, sourceID uint) error {
+	if current.ID == sourceID {
+		return nil
+	}
+	if current.Status != "active" {
+		return fmt.Errorf("router %s is %s", current.IPAddress, current.Status)
+	}
+	if current.Type == models.DeviceTypeHost {
+		return fmt.Errorf("host %s does not forward packets", current.IPAddress)
+	}
+	return nil
+}
+
+// forward пересылает пакет от роутера-отправителя к адресу назначения,
+// на каждом переходе выбирая маршрут по наибольшему совпадению префикса.
+// Каждый транзитный роутер уменьшает ttl пакета, ACL интерфейсов
+// и межсетевые экраны роутеров могут его отбросить, а NAT — изменить адреса.
+// Возвращает пройденные переходы, в том числе при ошибке доставки.
+func (t *topology) forward(fromID uint, pkt packetHeader, ttl int) ([]models.PacketHop, error) {
+	hops, _, err := t.deliver(fromID, pkt, ttl)
+	return hops, err
+}
+
+// deliver пересылает пакет как forward и возвращает также заголовок пакета
+// на последнем переходе — с адресами после трансляций NAT на пути
+func (t *topology) deliver(fromID uint, pkt packetHeader, ttl int) ([]models.PacketHop, packetHeader, error) {
+	if net.ParseIP(pkt.dstIP) == nil {
+		return nil, pkt, fmt.Errorf("invalid destination IP: %s", pkt.dstIP)
+	}
+
+	current, ok := t.routers[fromID]
+	if !ok {
+		return nil, pkt, fmt.Errorf("router %d not found", fromID)
+	}
+	if pkt.srcIP == "" {
+		pkt.srcIP = current.IPAddress
+	}
+	hops := []models.PacketHop{newHop(current, nil)}
+
+	var in *models.RouterConnection // соединение, через которое пакет пришел на current
+	for ; ; ttl-- {
+		hop := &hops[len(hops)-1]
+		flow, err := t.inspect(hop, current, in, pkt)
+		if err != nil {
+			return hops, pkt, err
+		}
+		// Адрес назначения транслируется до выбора маршрута, адрес отправителя — при отправке
+		received := pkt
+		nat, err := t.translateIn(hop, current, in, &pkt)
+		if err != nil {
+			return hops, pkt, err
+		}
+		if t.ownsIP(current, pkt.dstIP) {
+			t.commit(hop, current, received, pkt, flow)
+			return hops, pkt, nil
+		}
+		if ttl <= 0 {
+			return hops, pkt, errTTLExceeded
+		}
+		if err := checkTransit(current, fromID); err != nil {
+			return hops, pkt, err
+		}
+
+		next, conn, err := t.nextHop(current, net.ParseIP(pkt.dstIP), pkt.dstIP)
+		if err != nil {
+			return hops, pkt, err
+		}
+		if err := t.translateOut(hop, current, conn, &pkt, received, nat); err != nil {
+			return hops, pkt, err
+		}
+		if !flow.tracked {
+			if err := t.filterPacket(hop, current, conn, models.ACLOutbound, pkt); err != nil {
+				return hops, pkt, err
+			}
+		}
+		t.commit(hop, current, received, pkt, flow)
+
+		hops = append(hops, t.crossLink(current, next, conn)...)
+		in = conn
+		current = next
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"network/internal/icmp"
+	"network/internal/models"
+	"time"
+)
+
+// Параметры traceroute по умолчанию и допустимые пределы
+const (
+	defaultTracerouteMaxHops = 30
+	defaultTracerouteProbes  = 3
+
+	maxTracerouteProbes = 10
+)
+
+// applyTracerouteDefaults заполняет незаданные параметры и проверяет пределы
+func applyTracerouteDefaults(req *models.TracerouteRequest) error {
+	if req.MaxHops == 0 {
+		req.MaxHops = defaultTracerouteMaxHops
+	}
+	if req.Probes == 0 {
+		req.Probes = defaultTracerouteProbes
+	}
+	if req.Timeout == 0 {
+		req.Timeout = defaultPingTimeout
+	}
+
+	if req.MaxHops < 1 || req.MaxHops > 255 {
+		return fmt.Errorf("invalid max hops: %d (must be 1-255)", req.MaxHops)
+	}
+	if req.Probes < 1 || req.Probes > maxTracerouteProbes {
+		return fmt.Errorf("invalid probes: %d (must be 1-%d)", req.Probes, maxTracerouteProbes)
+	}
+	if req.Timeout < 1 || req.Timeout > maxPingTimeout {
+		return fmt.Errorf("invalid timeout: %d ms (must be 1-%d)", req.Timeout, maxPingTimeout)
+	}
+	return nil
+}
+
+// Traceroute определяет путь до адреса. Режимы и отправитель выбираются так же, как для ping.
+func (s *DeviceService) Traceroute(req *models.TracerouteRequest) (*models.TracerouteResult, error) {
+	if err := applyTracerouteDefaults(req); err != nil {
+		return nil, err
+	}
+
+	switch req.Mode {
+	case "", models.PingModeAuto:
+		if s.repo.IsIPTaken(req.IPAddress) && s.fillSimulationSource(&req.SourceIP) {
+			return s.tracerouteSimulated(req)
+		}
+		return s.tracerouteReal(req)
+	case models.PingModeReal:
+		return s.tracerouteReal(req)
+	case models.PingModeSimulated:
+		s.fillSimulationSource(&req.SourceIP)
+		return s.tracerouteSimulated(req)
+	default:
+		return nil, fmt.Errorf("invalid mode: %s", req.Mode)
+	}
+}
+
+// addTracerouteProbe добавляет результат пробы к переходу
+func addTracerouteProbe(hop *models.TracerouteHop, probe models.PingProbe) {
+	if probe.Status == "timeout" || probe.Status == "error" {
+		hop.RTT = append(hop.RTT, nil)
+		return
+	}
+
+	rtt := probe.RTT
+	hop.RTT = append(hop.RTT, &rtt)
+	hop.IPAddress = probe.From
+	hop.Status = probe.Status
+}
+
+// finishTraceroute проверяет, нужно ли продолжать трассировку после перехода
+func finishTraceroute(result *models.TracerouteResult, hop *models.TracerouteHop) bool {
+	result.Hops = append(result.Hops, *hop)
+	switch hop.Status {
+	case "success":
+		result.Status = "success"
+		return true
+	case "unreachable":
+		return true
+	}
+	return false
+}
+
+// tracerouteSimulated проходит путь пересылки между виртуальными роутерами,
+// увеличивая TTL пробы на каждом шаге. Как и ping, выполняется под блокировкой топологии.
+func (s *DeviceService) tracerouteSimulated(req *models.TracerouteRequest) (*models.TracerouteResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.SourceIP == "" {
+		return nil, fmt.Errorf("source_ip is required for simulated traceroute when no router is connected")
+	}
+
+	topo, err := s.loadTopology()
+	if err != nil {
+		return nil, err
+	}
+
+	source := topo.byIP[req.SourceIP]
+	if source == nil {
+		return nil, fmt.Errorf("source router with IP %s not found", req.SourceIP)
+	}
+	if topo.byIP[req.IPAddress] == nil {
+		return nil, fmt.Errorf("destination router with IP %s not found", req.IPAddress)
+	}
+
+	rng, seed := s.newRand(req.Seed)
+	result := &models.TracerouteResult{
+		IPAddress: req.IPAddress,
+		SourceIP:  source.IPAddress,
+		Mode:      models.PingModeSimulated,
+		Seed:      &seed,
+		Status:    "failed",
+	}
+
+	for ttl := 1; ttl <= req.MaxHops; ttl++ {
+		hop := &models.TracerouteHop{TTL: ttl, Status: "timeout"}
+		for i := 0; i < req.Probes; i++ {
+			probe := topo.echo(rng, source, req.IPAddress, ttl, icmpEchoHeaderSize, float64(req.Timeout))
+			addTracerouteProbe(hop, probe)
+		}
+		if router := topo.byIP[hop.IPAddress]; router != nil {
+			hop.Name = router.Name
+		}
+
+		if finishTraceroute(result, hop) {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// tracerouteReal отправляет с хоста ICMP echo запросы с ограниченным TTL.
+// Сообщения time exceeded доставляются только в raw сокет, поэтому нужны права root.
+func (s *DeviceService) tracerouteReal(req *models.TracerouteRequest) (*models.TracerouteResult, error) {
+	addr, err := net.ResolveIPAddr("ip4", req.IPAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", req.IPAddress, err)
+	}
+
+	result := &models.TracerouteResult{
+		IPAddress: req.IPAddress,
+		Mode:      models.PingModeReal,
+		Status:    "failed",
+	}
+
+	conn, err := icmp.Listen()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer conn.Close()
+
+	if !conn.Privileged() {
+		result.Error = "traceroute requires a raw ICMP socket (run with root privileges)"
+		return result, nil
+	}
+
+	timeout := time.Duration(req.Timeout) * time.Millisecond
+	seq := 0
+	for ttl := 1; ttl <= req.MaxHops; ttl++ {
+		if err := conn.SetTTL(ttl); err != nil {
+			result.Error = fmt.Sprintf("failed to set TTL: %v", err)
+			return result, nil
+		}
+
+		hop := &models.TracerouteHop{TTL: ttl, Status: "timeout"}
+		for i := 0; i < req.Probes; i++ {
+			seq++
+			probe, err := sendProbe(conn, addr.IP, seq, nil, timeout)
+			if err != nil {
+				result.Error = err.Error()
+			}
+			addTracerouteProbe(hop, probe)
+		}
+
+		if finishTraceroute(result, hop) {
+			break
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+
+	"network/internal/models"
+)
+
+func TestTracerouteWithoutSource(t *testing.T) {
+	services, _ := newTestService(t)
+	s := services.Devices
+	r1 := mustCreateRouter(t, s, "R1", "10.0.0.1")
+	r2 := mustCreateRouter(t, s, "R2", "10.0.0.2")
+	r3 := mustCreateRouter(t, s, "R3", "10.0.0.3")
+	mustConnect(t, s, r1, r2, 1, 0, 0)
+	mustConnect(t, s, r2, r3, 1, 0, 0)
+	mustEnableOSPF(t, s, r1, r2, r3)
+
+	// Отправителем служит первый подключенный роутер
+	result, err := s.Traceroute(&models.TracerouteRequest{IPAddress: r3.IPAddress, Probes: 1})
+	if err != nil {
+		t.Fatalf("auto traceroute: %v", err)
+	}
+	if result.Mode != models.PingModeSimulated || result.SourceIP != r1.IPAddress || result.Status != "success" {
+		t.Fatalf("auto traceroute = %s %s from %s, want simulated success from %s", result.Mode, result.Status, result.SourceIP, r1.IPAddress)
+	}
+	if len(result.Hops) != 2 || result.Hops[0].Name != "R2" || result.Hops[1].Name != "R3" {
+		t.Errorf("hops = %+v, want R2 then R3", result.Hops)
+	}
+
+	for _, router := range []*models.Router{r1, r2, r3} {
+		if err := s.repo.DisconnectRouter(router.ID); err != nil {
+			t.Fatalf("disconnect %s: %v", router.Name, err)
+		}
+	}
+	if _, err := s.Traceroute(&models.TracerouteRequest{IPAddress: r3.IPAddress, Mode: models.PingModeSimulated}); err == nil {
+		t.Error("simulated traceroute without source and connected router succeeded")
+	}
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"fmt"
+	"network/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Допустимые номера VLAN 802.1Q
+const (
+	defaultVLAN = 1
+	minVLAN     = 1
+	maxVLAN     = 4094
+)
+
+// parseVLANList разбирает список VLAN вида 10,20,30-40. Пустой список означает все VLAN.
+func parseVLANList(list string) (map[int]bool, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	vlans := make(map[int]bool)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		first, last := item, item
+		if i := strings.Index(item, "-"); i >= 0 {
+			first, last = item[:i], item[i+1:]
+		}
+		from, err1 := strconv.Atoi(strings.TrimSpace(first))
+		to, err2 := strconv.Atoi(strings.TrimSpace(last))
+		if err1 != nil || err2 != nil || from < minVLAN || to > maxVLAN || from > to {
+			return nil, fmt.Errorf("invalid VLAN list: %s", list)
+		}
+		for vlan := from; vlan <= to; vlan++ {
+			vlans[vlan] = true
+		}
+	}
+	return vlans, nil
+}
+
+// formatVLANList записывает множество VLAN в виде 10,20,30-40
+func formatVLANList(vlans map[int]bool) string {
+	ids := make([]int, 0, len(vlans))
+	for vlan := range vlans {
+		ids = append(ids, vlan)
+	}
+	sort.Ints(ids)
+
+	var parts []string
+	for i := 0; i < len(ids); {
+		j := i
+		for j+1 < len(ids) && ids[j+1] == ids[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(ids[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", ids[i], ids[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+func validateVLAN(vlan int) error {
+	if vlan < minVLAN || vlan > maxVLAN {
+		return fmt.Errorf("invalid VLAN: %d (must be %d-%d)", vlan, minVLAN, maxVLAN)
+	}
+	return nil
+}
+
+// validateSwitchport проверяет настройки VLAN порта коммутатора и заполняет значения по умолчанию:
+// порт доступа в VLAN 1, native VLAN транка 1
+func validateSwitchport(iface *models.Interface) error {
+	switch iface.SwitchportMode {
+	case "":
+		iface.SwitchportMode = models.SwitchportAccess
+	case models.SwitchportAccess, models.SwitchportTrunk:
+	default:
+		return fmt.Errorf("invalid switchport mode: %s", iface.SwitchportMode)
+	}
+	if iface.AccessVLAN == 0 {
+		iface.AccessVLAN = defaultVLAN
+	}
+	if iface.NativeVLAN == 0 {
+		iface.NativeVLAN = defaultVLAN
+	}
+	if err := validateVLAN(iface.AccessVLAN); err != nil {
+		return err
+	}
+	if err := validateVLAN(iface.NativeVLAN); err != nil {
+		return err
+	}
+
+	allowed, err := parseVLANList(iface.AllowedVLANs)
+	if err != nil {
+		return err
+	}
+	iface.AllowedVLANs = formatVLANList(allowed)
+	return nil
+}
+
+// validateSubinterface проверяет имя и тег 802.1Q подынтерфейса
+func validateSubinterface(iface *models.Interface) error {
+	if iface.Type != models.InterfaceTypeSubinterface {
+		if iface.VLAN != 0 {
+			return fmt.Errorf("VLAN can only be set on subinterfaces")
+		}
+		return nil
+	}
+	if parentInterfaceName(iface.Name) == "" {
+		return fmt.Errorf("subinterface name %s must be <interface>.<number>", iface.Name)
+	}
+	return validateVLAN(iface.VLAN)
+}
+
+// parentInterfaceName возвращает имя родительского интерфейса подынтерфейса Gi0/0.10
+func parentInterfaceName(name string) string {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 || i == len(name)-1 {
+		return ""
+	}
+	return name[:i]
+}
+
+// subinterfaces возвращает подынтерфейсы интерфейса устройства
+func subinterfaces(device *models.Router, parent *models.Interface) []*models.Interface {
+	var subs []*models.Interface
+	for i := range device.Interfaces {
+		iface := &device.Interfaces[i]
+		if iface.Type == models.InterfaceTypeSubinterface && parentInterfaceName(iface.Name) == parent.Name {
+			subs = append(subs, iface)
+		}
+	}
+	return subs
+}
+
+// portAllows проверяет, пропускает ли порт коммутатора VLAN
+func portAllows(port *models.Interface, vlan int) bool {
+	if port == nil || port.SwitchportMode != models.SwitchportTrunk {
+		return vlan == accessVLAN(port)
+	}
+	allowed, err := parseVLANList(port.AllowedVLANs)
+	return err == nil && (allowed == nil || allowed[vlan])
+}
+
+func accessVLAN(port *models.Interface) int {
+	if port == nil || port.AccessVLAN == 0 {
+		return defaultVLAN
+	}
+	return port.AccessVLAN
+}
+
+func nativeVLAN(port *models.Interface) int {
+	if port == nil || port.NativeVLAN == 0 {
+		return defaultVLAN
+	}
+	return port.NativeVLAN
+}
+
+// ingressVLAN определяет VLAN кадра с тегом tag (0 — без тега), принятого портом коммутатора.
+// Порт доступа принимает только кадры без тега, транк — кадры разрешенных VLAN.
+func ingressVLAN(port *models.Interface, tag int) (int, bool) {
+	if port == nil || port.SwitchportMode != models.SwitchportTrunk {
+		return accessVLAN(port), tag == 0
+	}
+	vlan := tag
+	if tag == 0 {
+		vlan = nativeVLAN(port)
+	}
+	return vlan, portAllows(port, vlan)
+}
+
+// egressTag возвращает тег, с которым кадр VLAN vlan покидает порт коммутатора:
+// порт доступа и native VLAN транка передают кадры без тега
+func egressTag(port *models.Interface, vlan int) (int, bool) {
+	if !portAllows(port, vlan) {
+		return 0, false
+	}
+	if port == nil || port.SwitchportMode != models.SwitchportTrunk || vlan == nativeVLAN(port) {
+		return 0, true
+	}
+	return vlan, true
+}
+
+// endpointInterface возвращает интерфейс конечного устройства, принимающий кадр с тегом tag
+// на физическом интерфейсе: кадры без тега принимает сам интерфейс, с тегом — подынтерфейс этого VLAN
+func endpointInterface(device *models.Router, physical *models.Interface, tag int) *models.Interface {
+	if tag == 0 {
+		return physical
+	}
+	if physical == nil {
+		return nil
+	}
+	for _, sub := range subinterfaces(device, physical) {
+		if sub.VLAN == tag && sub.OperStatus == models.InterfaceStatusUp {
+			return sub
+		}
+	}
+	return nil
+}
+
+// GetVLANs возвращает VLAN коммутатора с портами доступа и транками, по которым они проходят
+func (s *DeviceService) GetVLANs(switchID uint) ([]models.VLANInfo, error) {
+	device, err := s.getSwitch(switchID)
+	if err != nil {
+		return nil, err
+	}
+
+	vlans := make(map[int]*models.VLANInfo)
+	get := func(vlan int) *models.VLANInfo {
+		if vlans[vlan] == nil {
+			vlans[vlan] = &models.VLANInfo{VLAN: vlan, AccessPorts: []string{}, TrunkPorts: []string{}}
+		}
+		return vlans[vlan]
+	}
+	get(defaultVLAN)
+	for i := range device.Interfaces {
+		port := &device.Interfaces[i]
+		if port.SwitchportMode != models.SwitchportTrunk {
+			info := get(accessVLAN(port))
+			info.AccessPorts = append(info.AccessPorts, port.Name)
+		}
+	}
+	// Транки показываются во всех VLAN, которые используются на коммутаторе
+	for i := range device.Interfaces {
+		port := &device.Interfaces[i]
+		if port.SwitchportMode != models.SwitchportTrunk {
+			continue
+		}
+		get(nativeVLAN(port))
+		allowed, _ := parseVLANList(port.AllowedVLANs)
+		for vlan := range allowed {
+			get(vlan)
+		}
+	}
+	for i := range device.Interfaces {
+		port := &device.Interfaces[i]
+		if port.SwitchportMode != models.SwitchportTrunk {
+			continue
+		}
+		for vlan, info := range vlans {
+			if portAllows(port, vlan) {
+				info.TrunkPorts = append(info.TrunkPorts, port.Name)
+			}
+		}
+	}
+
+	result := make([]models.VLANInfo, 0, len(vlans))
+	for _, info := range vlans {
+		result = append(result, *info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].VLAN < result[j].VLAN })
+	return result, nil
+}
+
+// configureSwitchport применяет настройки порта из ConfigurePort к интерфейсу коммутатора
+func (s *DeviceService) configureSwitchport(switchID uint, req *models.ConfigurePortRequest) error {
+	if _, err := s.getSwitch(switchID); err != nil {
+		return err
+	}
+	iface, err := s.repo.GetInterfaceByName(switchID, req.Interface)
+	if err != nil {
+		return fmt.Errorf("interface %s not found: %w", req.Interface, err)
+	}
+
+	update := &models.UpdateInterfaceRequest{
+		AccessVLAN:   req.AccessVLAN,
+		NativeVLAN:   req.NativeVLAN,
+		AllowedVLANs: req.AllowedVLANs,
+	}
+	if req.Mode != "" {
+		update.SwitchportMode = &req.Mode
+	}
+	if req.Status != "" {
+		status := models.InterfaceStatus(req.Status)
+		update.AdminStatus = &status
+	}
+	if req.Speed != "" {
+		update.Speed = &req.Speed
+	}
+	if req.DuplexMode != "" {
+		update.DuplexMode = &req.DuplexMode
+	}
+	if req.Description != "" {
+		update.Description = &req.Description
+	}
+
+	_, err = s.UpdateInterface(switchID, iface.ID, update)
+	return err
+}
@@ -36,10 +36,40 @@ func main() {
 		&models.Router{},
 		&models.Port{},
 		&models.RouterConnection{},
+		&models.Interface{},
+		&models.Route{},
+		&models.SimulationConfig{},
+		&models.IPPool{},
+		&models.IPAllocation{},
+		&models.OSPFProcess{},
+		&models.BGPProcess{},
+		&models.BGPNeighbor{},
+		&models.BGPNetwork{},
+		&models.BGPRouteMapEntry{},
+		&models.RIPProcess{},
+		&models.RIPRoute{},
+		&models.RIPConvergence{},
+		&models.RIPChange{},
+		&models.SwitchConfig{},
+		&models.STPConfig{},
+		&models.ARPConfig{},
+		&models.ARPEntry{},
+		&models.ACLEntry{},
+		&models.FirewallConfig{},
+		&models.NATRule{},
+		&models.DHCPPool{},
+		&models.DHCPLease{},
 	); err != nil {
 		log.Fatal(err)
 	}
 
+	// Статусы портов up/down из прежних версий заменяются на open/closed
+	for old, status := range map[string]string{"up": models.PortStatusOpen, "down": models.PortStatusClosed} {
+		if err := db.Model(&models.Port{}).Where("status = ?", old).Update("status", status).Error; err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	// Настройка API сервера
 	app := fiber.New()
 	app.Use(logger.New())